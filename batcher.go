@@ -0,0 +1,224 @@
+package influxdb
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultPointBatchSize is the default number of points in a batch.
+	DefaultPointBatchSize = 1000
+
+	// DefaultPointBatchTimeout is the default maximum time points wait in a batch.
+	DefaultPointBatchTimeout = 1 * time.Second
+)
+
+// PointBatcher accepts points and groups them into batches. A batch is
+// emitted when it reaches the batch size or when the batch timeout elapses
+// after its first point, whichever comes first.
+//
+// Inputs batch the points they receive because every write to the cluster
+// costs a round trip: the batch size bounds the points written at once and
+// the timeout bounds how long a point waits to be written.
+//
+// Points are accepted through a bounded queue. Write blocks while the queue
+// is full, which applies backpressure to stream-based inputs such as TCP.
+// TryWrite drops the point instead and counts the drop, which suits
+// datagram inputs such as UDP where the sender cannot be slowed down.
+type PointBatcher struct {
+	size    int
+	timeout time.Duration
+
+	in    chan Point
+	out   chan []Point
+	flush chan struct{}
+	done  chan struct{}
+	once  sync.Once
+	wg    sync.WaitGroup
+
+	stats PointBatcherStats
+}
+
+// PointBatcherStats are the counters maintained by a PointBatcher.
+type PointBatcherStats struct {
+	BatchTotal   uint64 // number of batches emitted
+	PointTotal   uint64 // number of points accepted
+	SizeTotal    uint64 // number of batches emitted because they were full
+	TimeoutTotal uint64 // number of batches emitted because of the timeout
+	DropTotal    uint64 // number of points dropped because the queue was full
+}
+
+// NewPointBatcher returns a new instance of PointBatcher. The queue holds
+// up to size points waiting to be batched.
+func NewPointBatcher(size int, timeout time.Duration) *PointBatcher {
+	return &PointBatcher{
+		size:    size,
+		timeout: timeout,
+		in:      make(chan Point, size),
+		out:     make(chan []Point),
+		flush:   make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// SeriesWriter represents an object that writes points to a database.
+type SeriesWriter interface {
+	WriteSeries(database, retentionPolicy string, points []Point) (uint64, error)
+}
+
+// StartPointBatcher starts a batcher whose batches are written to a database
+// and retention policy by w. Write errors are logged. wg is marked done once
+// the batcher is stopped and its last batch is written.
+func StartPointBatcher(w SeriesWriter, database, retentionPolicy string, size int, timeout time.Duration, wg *sync.WaitGroup) *PointBatcher {
+	b := NewPointBatcher(size, timeout)
+	b.Start()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for batch := range b.Out() {
+			if _, err := w.WriteSeries(database, retentionPolicy, batch); err != nil {
+				log.Printf("unable to write points to %s: %s", database, err)
+			}
+		}
+	}()
+	return b
+}
+
+// Start begins batching points in a separate goroutine.
+func (b *PointBatcher) Start() {
+	b.wg.Add(1)
+	go b.run()
+}
+
+// Stop stops batching. Queued points are emitted as a final batch and then
+// the output channel is closed. Points written after Stop are dropped.
+// It is safe to call Stop more than once.
+func (b *PointBatcher) Stop() {
+	b.once.Do(func() { close(b.done) })
+	b.wg.Wait()
+}
+
+// Write adds a point to the queue, blocking while the queue is full.
+// Returns false and drops the point if the batcher is stopped.
+func (b *PointBatcher) Write(p Point) bool {
+	select {
+	case <-b.done:
+		return false
+	default:
+	}
+
+	select {
+	case b.in <- p:
+		atomic.AddUint64(&b.stats.PointTotal, 1)
+		return true
+	case <-b.done:
+		return false
+	}
+}
+
+// TryWrite adds a point to the queue. Returns false and drops the point
+// if the queue is full or the batcher is stopped.
+func (b *PointBatcher) TryWrite(p Point) bool {
+	select {
+	case <-b.done:
+		return false
+	default:
+	}
+
+	select {
+	case b.in <- p:
+		atomic.AddUint64(&b.stats.PointTotal, 1)
+		return true
+	default:
+		atomic.AddUint64(&b.stats.DropTotal, 1)
+		return false
+	}
+}
+
+// Flush emits the pending batch and any queued points without waiting for
+// the batch to fill.
+func (b *PointBatcher) Flush() {
+	select {
+	case b.flush <- struct{}{}:
+	case <-b.done:
+	}
+}
+
+// Out returns the channel that batches are emitted on.
+// The channel is closed after the batcher is stopped.
+func (b *PointBatcher) Out() <-chan []Point { return b.out }
+
+// Stats returns a snapshot of the batcher's counters.
+func (b *PointBatcher) Stats() PointBatcherStats {
+	return PointBatcherStats{
+		BatchTotal:   atomic.LoadUint64(&b.stats.BatchTotal),
+		PointTotal:   atomic.LoadUint64(&b.stats.PointTotal),
+		SizeTotal:    atomic.LoadUint64(&b.stats.SizeTotal),
+		TimeoutTotal: atomic.LoadUint64(&b.stats.TimeoutTotal),
+		DropTotal:    atomic.LoadUint64(&b.stats.DropTotal),
+	}
+}
+
+// run groups queued points into batches until the batcher is stopped.
+func (b *PointBatcher) run() {
+	defer b.wg.Done()
+	defer close(b.out)
+
+	var batch []Point
+	var timer <-chan time.Time
+
+	// emit sends the pending batch to the output channel.
+	emit := func() {
+		if len(batch) == 0 {
+			return
+		}
+		b.out <- batch
+		atomic.AddUint64(&b.stats.BatchTotal, 1)
+		batch, timer = nil, nil
+	}
+
+	for {
+		select {
+		case p := <-b.in:
+			// Start the timeout when the first point of a batch arrives.
+			if batch == nil {
+				batch = make([]Point, 0, b.size)
+				timer = time.After(b.timeout)
+			}
+			batch = append(batch, p)
+
+			if len(batch) >= b.size {
+				atomic.AddUint64(&b.stats.SizeTotal, 1)
+				emit()
+			}
+
+		case <-timer:
+			atomic.AddUint64(&b.stats.TimeoutTotal, 1)
+			emit()
+
+		case <-b.flush:
+			batch = b.drain(batch)
+			emit()
+
+		case <-b.done:
+			// Emit any queued points before exiting.
+			batch = b.drain(batch)
+			emit()
+			return
+		}
+	}
+}
+
+// drain appends all queued points to batch without blocking.
+func (b *PointBatcher) drain(batch []Point) []Point {
+	for {
+		select {
+		case p := <-b.in:
+			batch = append(batch, p)
+		default:
+			return batch
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package influxdb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb"
+)
+
+// Ensure the batcher emits a batch once it reaches the batch size.
+func TestPointBatcher_Size(t *testing.T) {
+	b := influxdb.NewPointBatcher(3, time.Hour)
+	b.Start()
+	defer b.Stop()
+
+	for i := 0; i < 3; i++ {
+		b.Write(influxdb.Point{Name: "cpu", Values: map[string]interface{}{"value": i}})
+	}
+
+	select {
+	case batch := <-b.Out():
+		if len(batch) != 3 {
+			t.Fatalf("unexpected batch size: %d", len(batch))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for batch")
+	}
+
+	if stats := b.Stats(); stats.BatchTotal != 1 || stats.PointTotal != 3 || stats.SizeTotal != 1 || stats.TimeoutTotal != 0 {
+		t.Fatalf("unexpected stats: %#v", stats)
+	}
+}
+
+// Ensure the batcher emits a partial batch after the timeout.
+func TestPointBatcher_Timeout(t *testing.T) {
+	b := influxdb.NewPointBatcher(100, 10*time.Millisecond)
+	b.Start()
+	defer b.Stop()
+
+	b.Write(influxdb.Point{Name: "cpu"})
+
+	select {
+	case batch := <-b.Out():
+		if len(batch) != 1 {
+			t.Fatalf("unexpected batch size: %d", len(batch))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for batch")
+	}
+
+	if stats := b.Stats(); stats.TimeoutTotal != 1 {
+		t.Fatalf("unexpected timeout total: %d", stats.TimeoutTotal)
+	}
+}
+
+// Ensure the batcher emits the pending batch when flushed.
+func TestPointBatcher_Flush(t *testing.T) {
+	b := influxdb.NewPointBatcher(100, time.Hour)
+	b.Start()
+	defer b.Stop()
+
+	b.Write(influxdb.Point{Name: "cpu"})
+	b.Write(influxdb.Point{Name: "mem"})
+	go b.Flush()
+
+	select {
+	case batch := <-b.Out():
+		if len(batch) != 2 {
+			t.Fatalf("unexpected batch size: %d", len(batch))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for batch")
+	}
+}
+
+// Ensure the batcher drops points with TryWrite when the queue is full.
+func TestPointBatcher_TryWrite_Drop(t *testing.T) {
+	// Points are not batched until the batcher is started so the queue fills.
+	b := influxdb.NewPointBatcher(2, time.Hour)
+	for i, exp := range []bool{true, true, false, false} {
+		if ok := b.TryWrite(influxdb.Point{Name: "cpu"}); ok != exp {
+			t.Fatalf("%d. unexpected result: %v", i, ok)
+		}
+	}
+
+	if stats := b.Stats(); stats.PointTotal != 2 || stats.DropTotal != 2 {
+		t.Fatalf("unexpected stats: %#v", stats)
+	}
+
+	// Queued points are still batched once started.
+	b.Start()
+	defer b.Stop()
+	select {
+	case batch := <-b.Out():
+		if len(batch) != 2 {
+			t.Fatalf("unexpected batch size: %d", len(batch))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for batch")
+	}
+}
+
+// Ensure the batcher emits queued points and closes its output when stopped.
+func TestPointBatcher_Stop(t *testing.T) {
+	b := influxdb.NewPointBatcher(100, time.Hour)
+	b.Start()
+	b.Write(influxdb.Point{Name: "cpu"})
+
+	var n int
+	done := make(chan struct{})
+	go func() {
+		for batch := range b.Out() {
+			n += len(batch)
+		}
+		close(done)
+	}()
+	b.Stop()
+
+	select {
+	case <-done:
+		if n != 1 {
+			t.Fatalf("unexpected point count: %d", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for output to close")
+	}
+}
+
+// Ensure the batcher can be stopped more than once and drops points written after it stops.
+func TestPointBatcher_Stop_Write(t *testing.T) {
+	b := influxdb.NewPointBatcher(1, time.Hour)
+	b.Start()
+	go func() {
+		for range b.Out() {
+		}
+	}()
+	b.Stop()
+	b.Stop()
+
+	// Writes must return instead of blocking on the unread queue.
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			if b.Write(influxdb.Point{Name: "cpu"}) {
+				t.Errorf("unexpected write after stop")
+			}
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for write")
+	}
+
+	if b.TryWrite(influxdb.Point{Name: "cpu"}) {
+		t.Fatal("unexpected try write after stop")
+	}
+}
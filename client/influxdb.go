@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/influxdb/influxdb/influxql"
@@ -28,6 +30,11 @@ type Client struct {
 type Query struct {
 	Command  string
 	Database string
+
+	// Chunked requests that the server stream results in chunks of at most
+	// ChunkSize points. The server's default chunk size is used if not set.
+	Chunked   bool
+	ChunkSize int
 }
 
 type Write struct {
@@ -46,16 +53,22 @@ func NewClient(c Config) (*Client, error) {
 	return &client, nil
 }
 
+// Query executes a query and returns its results. Chunked results are
+// combined into one result per statement. Use QueryChunked to process large
+// results without holding them in memory.
 func (c *Client) Query(q Query) (*Results, error) {
-	u := c.url
-
-	u.Path = "query"
-	values := u.Query()
-	values.Set("q", q.Command)
-	values.Set("db", q.Database)
-	u.RawQuery = values.Encode()
+	if q.Chunked {
+		var results Results
+		if err := c.QueryChunked(q, func(chunk *Results) error {
+			mergeChunk(&results, chunk)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		return &results, nil
+	}
 
-	resp, err := c.httpClient.Get(u.String())
+	resp, err := c.query(q)
 	if err != nil {
 		return nil, err
 	}
@@ -71,6 +84,109 @@ func (c *Client) Query(q Query) (*Results, error) {
 	return &results, nil
 }
 
+// QueryChunked executes a query with chunked results and calls fn with each
+// chunk as it is read. Rows of a series may be split across several chunks.
+// Reading stops and the request is closed once fn returns an error, which is
+// then returned.
+func (c *Client) QueryChunked(q Query, fn func(*Results) error) error {
+	q.Chunked = true
+	resp, err := c.query(q)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	for {
+		var chunk Results
+		if err := dec.Decode(&chunk); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err := fn(&chunk); err != nil {
+			return err
+		}
+
+		// Errors outside of a statement end the stream.
+		if chunk.Err != nil {
+			return nil
+		}
+	}
+}
+
+// query sends a query request and returns the response.
+func (c *Client) query(q Query) (*http.Response, error) {
+	u := c.url
+
+	u.Path = "query"
+	values := u.Query()
+	values.Set("q", q.Command)
+	values.Set("db", q.Database)
+	if q.Chunked {
+		values.Set("chunked", "true")
+		if q.ChunkSize > 0 {
+			values.Set("chunk_size", strconv.Itoa(q.ChunkSize))
+		}
+	}
+	u.RawQuery = values.Encode()
+
+	return c.httpClient.Get(u.String())
+}
+
+// mergeChunk adds a chunk of results to the results of each statement.
+// Rows for the same series which were split across chunks are joined back
+// together.
+func mergeChunk(results *Results, chunk *Results) {
+	if chunk.Err != nil {
+		results.Err = chunk.Err
+		return
+	}
+
+	for _, res := range chunk.Results {
+		// Grow the results to include the statement.
+		for len(results.Results) <= res.StatementID {
+			results.Results = append(results.Results, Result{StatementID: len(results.Results)})
+		}
+		dst := &results.Results[res.StatementID]
+		if res.Err != nil {
+			dst.Err = res.Err
+		}
+
+	rows:
+		for _, row := range res.Rows {
+			// Append values to an earlier row if it is the same series.
+			for i := range dst.Rows {
+				if sameSeries(&dst.Rows[i], &row) {
+					dst.Rows[i].Values = append(dst.Rows[i].Values, row.Values...)
+					continue rows
+				}
+			}
+			dst.Rows = append(dst.Rows, row)
+		}
+	}
+}
+
+// sameSeries returns true if two rows belong to the same series.
+func sameSeries(a, b *influxql.Row) bool {
+	if a.Name != b.Name || len(a.Tags) != len(b.Tags) || len(a.Columns) != len(b.Columns) || a.Err != nil || b.Err != nil {
+		return false
+	}
+	for k, v := range a.Tags {
+		if b.Tags[k] != v {
+			return false
+		}
+	}
+	for i := range a.Columns {
+		if a.Columns[i] != b.Columns[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func (c *Client) Write(writes ...Write) (*Results, error) {
 	c.url.Path = "write"
 	type data struct {
@@ -122,18 +238,27 @@ func (c *Client) Ping() (time.Duration, string, error) {
 type Result struct {
 	Rows []influxql.Row
 	Err  error
+
+	// The index of the statement within the query and whether more results
+	// follow for the same statement. Only set when results are streamed.
+	StatementID int
+	Partial     bool
 }
 
 // MarshalJSON encodes the result into JSON.
 func (r *Result) MarshalJSON() ([]byte, error) {
 	// Define a struct that outputs "error" as a string.
 	var o struct {
-		Rows []influxql.Row `json:"rows,omitempty"`
-		Err  string         `json:"error,omitempty"`
+		StatementID int            `json:"statement_id,omitempty"`
+		Rows        []influxql.Row `json:"rows,omitempty"`
+		Partial     bool           `json:"partial,omitempty"`
+		Err         string         `json:"error,omitempty"`
 	}
 
 	// Copy fields to output struct.
+	o.StatementID = r.StatementID
 	o.Rows = r.Rows
+	o.Partial = r.Partial
 	if r.Err != nil {
 		o.Err = r.Err.Error()
 	}
@@ -144,8 +269,10 @@ func (r *Result) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON decodes the data into the Result struct
 func (r *Result) UnmarshalJSON(b []byte) error {
 	var o struct {
-		Rows []influxql.Row `json:"rows,omitempty"`
-		Err  string         `json:"error,omitempty"`
+		StatementID int            `json:"statement_id,omitempty"`
+		Rows        []influxql.Row `json:"rows,omitempty"`
+		Partial     bool           `json:"partial,omitempty"`
+		Err         string         `json:"error,omitempty"`
 	}
 
 	dec := json.NewDecoder(bytes.NewBuffer(b))
@@ -154,7 +281,9 @@ func (r *Result) UnmarshalJSON(b []byte) error {
 	if err != nil {
 		return err
 	}
+	r.StatementID = o.StatementID
 	r.Rows = o.Rows
+	r.Partial = o.Partial
 	if o.Err != "" {
 		r.Err = errors.New(o.Err)
 	}
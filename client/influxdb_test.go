@@ -2,7 +2,9 @@ package client_test
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -65,6 +67,86 @@ func TestClient_Query(t *testing.T) {
 	}
 }
 
+func TestClient_Query_Chunked(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("chunked") != "true" || r.URL.Query().Get("chunk_size") != "2" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, `{"results":[{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2009-11-10T23:00:00Z",1],["2009-11-10T23:00:10Z",2]]}],"partial":true}]}
+{"results":[{"rows":[{"name":"mem","columns":["time","value"],"values":[["2009-11-10T23:00:00Z",4]]}],"partial":true}]}
+{"results":[{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2009-11-10T23:00:20Z",3]]}]}]}
+{"results":[{"statement_id":1,"error":"not executed"}]}
+`)
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	c, err := client.NewClient(client.Config{URL: *u})
+	if err != nil {
+		t.Fatalf("unexpected error.  expected %v, actual %v", nil, err)
+	}
+
+	results, err := c.Query(client.Query{Command: "SELECT value FROM cpu, mem; SHOW DATABASES", Chunked: true, ChunkSize: 2})
+	if err != nil {
+		t.Fatalf("unexpected error.  expected %v, actual %v", nil, err)
+	}
+
+	// Rows split across chunks are joined back together.
+	if len(results.Results) != 2 {
+		t.Fatalf("unexpected result count: %d", len(results.Results))
+	} else if rows := results.Results[0].Rows; len(rows) != 2 || rows[0].Name != "cpu" || len(rows[0].Values) != 3 || rows[1].Name != "mem" || len(rows[1].Values) != 1 {
+		t.Fatalf("unexpected rows: %#v", rows)
+	} else if err := results.Results[1].Err; err == nil || err.Error() != "not executed" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClient_QueryChunked(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("chunked") != "true" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, `{"results":[{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2009-11-10T23:00:00Z",1]]}],"partial":true}]}
+{"results":[{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2009-11-10T23:00:10Z",2]]}]}]}
+`)
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	c, err := client.NewClient(client.Config{URL: *u})
+	if err != nil {
+		t.Fatalf("unexpected error.  expected %v, actual %v", nil, err)
+	}
+
+	// Each chunk is passed to the callback as it is read.
+	var n int
+	if err := c.QueryChunked(client.Query{Command: "SELECT value FROM cpu"}, func(results *client.Results) error {
+		n++
+		if len(results.Results) != 1 || len(results.Results[0].Rows) != 1 {
+			t.Fatalf("unexpected results: %#v", results)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error.  expected %v, actual %v", nil, err)
+	} else if n != 2 {
+		t.Fatalf("unexpected chunk count: %d", n)
+	}
+
+	// Reading stops when the callback returns an error.
+	errStop := errors.New("stop")
+	n = 0
+	if err := c.QueryChunked(client.Query{Command: "SELECT value FROM cpu"}, func(results *client.Results) error {
+		n++
+		return errStop
+	}); err != errStop {
+		t.Fatalf("unexpected error.  expected %v, actual %v", errStop, err)
+	} else if n != 1 {
+		t.Fatalf("unexpected chunk count: %d", n)
+	}
+}
+
 func TestClient_BasicAuth(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		u, p, ok := r.BasicAuth()
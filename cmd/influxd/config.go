@@ -13,8 +13,10 @@ import (
 	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/influxdb/influxdb"
 	"github.com/influxdb/influxdb/collectd"
 	"github.com/influxdb/influxdb/graphite"
+	"github.com/influxdb/influxdb/opentsdb"
 )
 
 const (
@@ -67,22 +69,16 @@ type Config struct {
 		SSLPort     int      `toml:"ssl-port"`
 		SSLCertPath string   `toml:"ssl-cert"`
 		ReadTimeout Duration `toml:"read-timeout"`
+		ChunkSize   int      `toml:"chunk-size"`
 	} `toml:"api"`
 
 	Graphites []Graphite `toml:"graphite"`
 	Collectd  Collectd   `toml:"collectd"`
+	OpenTSDB  OpenTSDB   `toml:"opentsdb"`
 
 	InputPlugins struct {
-		UDPInput struct {
-			Enabled  bool   `toml:"enabled"`
-			Port     int    `toml:"port"`
-			Database string `toml:"database"`
-		} `toml:"udp"`
-		UDPServersInput []struct {
-			Enabled  bool   `toml:"enabled"`
-			Port     int    `toml:"port"`
-			Database string `toml:"database"`
-		} `toml:"udp_servers"`
+		UDPInput        UDPInputConfig   `toml:"udp"`
+		UDPServersInput []UDPInputConfig `toml:"udp_servers"`
 	} `toml:"input_plugins"`
 
 	Broker struct {
@@ -129,13 +125,6 @@ func NewConfig() *Config {
 		c.Hostname = "localhost"
 	}
 
-	// FIX(benbjohnson): Append where the udp servers are actually used.
-	// config.UDPServers = append(config.UDPServers, UDPInputConfig{
-	// 	Enabled:  tomlConfiguration.InputPlugins.UDPInput.Enabled,
-	// 	Database: tomlConfiguration.InputPlugins.UDPInput.Database,
-	// 	Port:     tomlConfiguration.InputPlugins.UDPInput.Port,
-	// })
-
 	return c
 }
 
@@ -159,6 +148,11 @@ func (c *Config) WriteBatchSize() int {
 	return DefaultWriteBatchSize
 }
 
+// UDPInputs returns the configuration for the "udp" and all "udp_servers" inputs.
+func (c *Config) UDPInputs() []UDPInputConfig {
+	return append([]UDPInputConfig{c.InputPlugins.UDPInput}, c.InputPlugins.UDPServersInput...)
+}
+
 // MaxOpenShards returns the maximum number of shards to keep open at once.
 func (c *Config) MaxOpenShards() int {
 	return c.Data.MaxOpenShards
@@ -294,6 +288,12 @@ type Collectd struct {
 	Database string `toml:"database"`
 	Enabled  bool   `toml:"enabled"`
 	TypesDB  string `toml:"typesdb"`
+
+	// Mapping is "values" to write each value to its own measurement or
+	// "fields" to write each data source as a field of a single point.
+	Mapping string `toml:"mapping"`
+
+	BatchConfig
 }
 
 // ConnnectionString returns the connection string for this collectd config in the form host:port.
@@ -313,6 +313,35 @@ func (c *Collectd) ConnectionString(defaultBindAddr string) string {
 	return fmt.Sprintf("%s:%d", addr, port)
 }
 
+// OpenTSDB represents the configuration for the OpenTSDB input.
+type OpenTSDB struct {
+	Addr string `toml:"address"`
+	Port uint16 `toml:"port"`
+
+	Enabled         bool   `toml:"enabled"`
+	Database        string `toml:"database"`
+	RetentionPolicy string `toml:"retention-policy"`
+
+	BatchConfig
+}
+
+// ConnectionString returns the connection string for this OpenTSDB config in the form host:port.
+func (o *OpenTSDB) ConnectionString(defaultBindAddr string) string {
+	addr := o.Addr
+	// If no address specified, use default.
+	if addr == "" {
+		addr = defaultBindAddr
+	}
+
+	port := o.Port
+	// If no port specified, use default.
+	if port == 0 {
+		port = opentsdb.DefaultPort
+	}
+
+	return fmt.Sprintf("%s:%d", addr, port)
+}
+
 type Graphite struct {
 	Addr string `toml:"address"`
 	Port uint16 `toml:"port"`
@@ -322,6 +351,13 @@ type Graphite struct {
 	Protocol      string `toml:"protocol"`
 	NamePosition  string `toml:"name-position"`
 	NameSeparator string `toml:"name-separator"`
+
+	// Templates map metric paths to measurements, tags and fields.
+	// Tags are added to every point in the form "key=value".
+	Templates []string `toml:"templates"`
+	Tags      []string `toml:"tags"`
+
+	BatchConfig
 }
 
 // ConnnectionString returns the connection string for this Graphite config in the form host:port.
@@ -335,7 +371,9 @@ func (g *Graphite) ConnectionString(defaultBindAddr string) string {
 
 	port := g.Port
 	// If no port specified, use default.
-	if port == 0 {
+	if port == 0 && strings.ToLower(g.Protocol) == "pickle" {
+		port = graphite.DefaultGraphitePicklePort
+	} else if port == 0 {
 		port = graphite.DefaultGraphitePort
 	}
 
@@ -356,5 +394,55 @@ func (g *Graphite) LastEnabled() bool {
 	return g.NamePosition == strings.ToLower("last")
 }
 
+// DefaultTags returns the tags added to every point received by the Graphite Server.
+func (g *Graphite) DefaultTags() (map[string]string, error) {
+	tags := make(map[string]string)
+	for _, s := range g.Tags {
+		a := strings.SplitN(s, "=", 2)
+		if len(a) != 2 || a[0] == "" {
+			return nil, fmt.Errorf("invalid graphite tag: %q", s)
+		}
+		tags[a[0]] = a[1]
+	}
+	return tags, nil
+}
+
+// BatchConfig represents the point batching configuration of an input.
+type BatchConfig struct {
+	BatchSize    int      `toml:"batch-size"`
+	BatchTimeout Duration `toml:"batch-timeout"`
+}
+
+// BatchSizeOrDefault returns the number of points written in a batch, or the default if not set.
+func (c *BatchConfig) BatchSizeOrDefault() int {
+	if c.BatchSize == 0 {
+		return influxdb.DefaultPointBatchSize
+	}
+	return c.BatchSize
+}
+
+// BatchTimeoutOrDefault returns the maximum time points wait to be written, or the default if not set.
+func (c *BatchConfig) BatchTimeoutOrDefault() time.Duration {
+	if c.BatchTimeout == 0 {
+		return influxdb.DefaultPointBatchTimeout
+	}
+	return time.Duration(c.BatchTimeout)
+}
+
+// UDPInputConfig represents the configuration for a UDP JSON input.
+type UDPInputConfig struct {
+	Enabled         bool     `toml:"enabled"`
+	Port            int      `toml:"port"`
+	Database        string   `toml:"database"`
+	RetentionPolicy string   `toml:"retention-policy"`
+	BatchSize       int      `toml:"batch-size"`
+	FlushInterval   Duration `toml:"flush-interval"`
+}
+
+// ConnectionString returns the connection string for the input in the form host:port.
+func (u *UDPInputConfig) ConnectionString(bindAddress string) string {
+	return net.JoinHostPort(bindAddress, strconv.Itoa(u.Port))
+}
+
 // maxInt is the largest integer representable by a word (architeture dependent).
 const maxInt = int64(^uint(0) >> 1)
@@ -6,7 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/influxdb/influxdb"
 	main "github.com/influxdb/influxdb/cmd/influxd"
+	"github.com/influxdb/influxdb/collectd"
 )
 
 // Ensure that megabyte sizes can be parsed.
@@ -64,8 +66,8 @@ func TestParseConfig(t *testing.T) {
 		t.Fatalf("data port mismatch: %v", c.Data.Port)
 	}
 
-	if len(c.Graphites) != 2 {
-		t.Fatalf("graphites  mismatch.  expected %v, got: %v", 2, len(c.Graphites))
+	if len(c.Graphites) != 3 {
+		t.Fatalf("graphites  mismatch.  expected %v, got: %v", 3, len(c.Graphites))
 	}
 
 	tcpGraphite := c.Graphites[0]
@@ -84,6 +86,13 @@ func TestParseConfig(t *testing.T) {
 		t.Fatalf("graphite tcp name-position mismatch: expected %v, got %v", "last", tcpGraphite.NamePosition)
 	case tcpGraphite.NameSeparatorString() != "-":
 		t.Fatalf("graphite tcp name-separator mismatch: expected %v, got %v", "-", tcpGraphite.NameSeparatorString())
+	case !reflect.DeepEqual(tcpGraphite.Templates, []string{"servers.* .host.measurement.field*", "measurement* dc=west"}):
+		t.Fatalf("graphite tcp templates mismatch: got %v", tcpGraphite.Templates)
+	}
+	if tags, err := tcpGraphite.DefaultTags(); err != nil {
+		t.Fatalf("graphite tcp tags error: %s", err)
+	} else if !reflect.DeepEqual(tags, map[string]string{"region": "us-east"}) {
+		t.Fatalf("graphite tcp tags mismatch: got %v", tags)
 	}
 
 	udpGraphite := c.Graphites[1]
@@ -98,6 +107,22 @@ func TestParseConfig(t *testing.T) {
 		t.Fatalf("graphite database mismatch: expected %v, got %v", "graphite_udp", udpGraphite.Database)
 	case strings.ToLower(udpGraphite.Protocol) != "udp":
 		t.Fatalf("graphite udp protocol mismatch: expected %v, got %v", "udp", strings.ToLower(udpGraphite.Protocol))
+	case udpGraphite.BatchSizeOrDefault() != 200:
+		t.Fatalf("graphite udp batch size mismatch: expected %v, got %v", 200, udpGraphite.BatchSizeOrDefault())
+	case udpGraphite.BatchTimeoutOrDefault() != 50*time.Millisecond:
+		t.Fatalf("graphite udp batch timeout mismatch: expected %v, got %v", 50*time.Millisecond, udpGraphite.BatchTimeoutOrDefault())
+	case tcpGraphite.BatchSizeOrDefault() != influxdb.DefaultPointBatchSize:
+		t.Fatalf("graphite tcp batch size mismatch: expected %v, got %v", influxdb.DefaultPointBatchSize, tcpGraphite.BatchSizeOrDefault())
+	case tcpGraphite.BatchTimeoutOrDefault() != influxdb.DefaultPointBatchTimeout:
+		t.Fatalf("graphite tcp batch timeout mismatch: expected %v, got %v", influxdb.DefaultPointBatchTimeout, tcpGraphite.BatchTimeoutOrDefault())
+	}
+
+	pickleGraphite := c.Graphites[2]
+	switch {
+	case strings.ToLower(pickleGraphite.Protocol) != "pickle":
+		t.Fatalf("graphite pickle protocol mismatch: expected %v, got %v", "pickle", strings.ToLower(pickleGraphite.Protocol))
+	case pickleGraphite.ConnectionString("192.168.0.4") != "192.168.0.4:2004":
+		t.Fatalf("graphite pickle connection string mismatch: expected %v, got %v", "192.168.0.4:2004", pickleGraphite.ConnectionString("192.168.0.4"))
 	}
 
 	switch {
@@ -111,6 +136,27 @@ func TestParseConfig(t *testing.T) {
 		t.Errorf("collectdabase mismatch: expected %v, got %v", "collectd_database", c.Collectd.Database)
 	case c.Collectd.TypesDB != "foo-db-type":
 		t.Errorf("collectd typesdb mismatch: expected %v, got %v", "foo-db-type", c.Collectd.TypesDB)
+	case c.Collectd.Mapping != collectd.MappingFields:
+		t.Errorf("collectd mapping mismatch: expected %v, got %v", collectd.MappingFields, c.Collectd.Mapping)
+	case c.Collectd.BatchSizeOrDefault() != 300:
+		t.Errorf("collectd batch size mismatch: expected %v, got %v", 300, c.Collectd.BatchSizeOrDefault())
+	case c.Collectd.BatchTimeoutOrDefault() != influxdb.DefaultPointBatchTimeout:
+		t.Errorf("collectd batch timeout mismatch: expected %v, got %v", influxdb.DefaultPointBatchTimeout, c.Collectd.BatchTimeoutOrDefault())
+	}
+
+	if c.HTTPAPI.ChunkSize != 500 {
+		t.Errorf("api chunk size mismatch: expected %v, got %v", 500, c.HTTPAPI.ChunkSize)
+	}
+
+	switch {
+	case c.OpenTSDB.Enabled != true:
+		t.Errorf("opentsdb enabled mismatch: expected: %v, got %v", true, c.OpenTSDB.Enabled)
+	case c.OpenTSDB.ConnectionString("") != "192.168.0.4:4242":
+		t.Errorf("opentsdb connection string mismatch: expected %v, got %v", "192.168.0.4:4242", c.OpenTSDB.ConnectionString(""))
+	case c.OpenTSDB.Database != "opentsdb_database":
+		t.Errorf("opentsdb database mismatch: expected %v, got %v", "opentsdb_database", c.OpenTSDB.Database)
+	case c.OpenTSDB.RetentionPolicy != "raw":
+		t.Errorf("opentsdb retention policy mismatch: expected %v, got %v", "raw", c.OpenTSDB.RetentionPolicy)
 	}
 
 	if c.Broker.Port != 8086 {
@@ -123,19 +169,36 @@ func TestParseConfig(t *testing.T) {
 
 	if c.Data.Dir != "/tmp/influxdb/development/db" {
 		t.Fatalf("data dir mismatch: %v", c.Data.Dir)
+	} else if time.Duration(c.Data.RetentionSweepPeriod) != 5*time.Minute {
+		t.Fatalf("retention sweep period mismatch: %v", c.Data.RetentionSweepPeriod)
 	}
 
 	if c.Cluster.Dir != "/tmp/influxdb/development/cluster" {
 		t.Fatalf("cluster dir mismatch: %v", c.Cluster.Dir)
 	}
 
-	// TODO: UDP Servers testing.
-	/*
-		c.Assert(config.UdpServers, HasLen, 1)
-		c.Assert(config.UdpServers[0].Enabled, Equals, true)
-		c.Assert(config.UdpServers[0].Port, Equals, 4444)
-		c.Assert(config.UdpServers[0].Database, Equals, "test")
-	*/
+	udps := c.UDPInputs()
+	if len(udps) != 2 {
+		t.Fatalf("udp input count mismatch: %d", len(udps))
+	}
+	switch {
+	case udps[0].Enabled != true:
+		t.Errorf("udp enabled mismatch: expected: %v, got %v", true, udps[0].Enabled)
+	case udps[0].Port != 4444:
+		t.Errorf("udp port mismatch: expected %v, got %v", 4444, udps[0].Port)
+	case udps[0].Database != "test":
+		t.Errorf("udp database mismatch: expected %v, got %v", "test", udps[0].Database)
+	case udps[0].RetentionPolicy != "raw":
+		t.Errorf("udp retention policy mismatch: expected %v, got %v", "raw", udps[0].RetentionPolicy)
+	case udps[0].BatchSize != 500:
+		t.Errorf("udp batch size mismatch: expected %v, got %v", 500, udps[0].BatchSize)
+	case time.Duration(udps[0].FlushInterval) != 100*time.Millisecond:
+		t.Errorf("udp flush interval mismatch: expected %v, got %v", 100*time.Millisecond, udps[0].FlushInterval)
+	case udps[1].Port != 5551:
+		t.Errorf("udp server port mismatch: expected %v, got %v", 5551, udps[1].Port)
+	case udps[1].Database != "db1":
+		t.Errorf("udp server database mismatch: expected %v, got %v", "db1", udps[1].Database)
+	}
 }
 
 // Testing configuration file.
@@ -173,6 +236,7 @@ ssl-cert = "../cert.pem"
 # and keep alive connections they don't use won't end up connection a million times.
 # However, if a request is taking longer than this to complete, could be a problem.
 read-timeout = "5s"
+chunk-size = 500
 
 [input_plugins]
 
@@ -180,6 +244,14 @@ read-timeout = "5s"
   enabled = true
   port = 4444
   database = "test"
+  retention-policy = "raw"
+  batch-size = 500
+  flush-interval = "100ms"
+
+  [[input_plugins.udp_servers]]
+  enabled = true
+  port = 5551
+  database = "db1"
 
 # Configure the Graphite servers
 [[graphite]]
@@ -190,6 +262,8 @@ port = 2003
 database = "graphite_tcp"  # store graphite data in this database
 name-position = "last"
 name-separator = "-"
+templates = ["servers.* .host.measurement.field*", "measurement* dc=west"]
+tags = ["region=us-east"]
 
 [[graphite]]
 protocol = "udP"
@@ -197,6 +271,13 @@ enabled = true
 address = "192.168.0.2"
 port = 2005
 database = "graphite_udp"  # store graphite data in this database
+batch-size = 200
+batch-timeout = "50ms"
+
+[[graphite]]
+protocol = "pickle"
+enabled = true
+database = "graphite_pickle"
 
 # Configure collectd server
 [collectd]
@@ -205,6 +286,15 @@ address = "192.168.0.3"
 port = 25827
 database = "collectd_database"
 typesdb = "foo-db-type"
+mapping = "fields"
+batch-size = 300
+
+# Configure OpenTSDB server
+[opentsdb]
+enabled = true
+address = "192.168.0.4"
+database = "opentsdb_database"
+retention-policy = "raw"
 
 # Broker configuration
 [broker]
@@ -219,6 +309,7 @@ dir  = "/tmp/influxdb/development/broker"
 
 [data]
 dir = "/tmp/influxdb/development/db"
+retention-sweep-period = "5m"
 
 [cluster]
 dir = "/tmp/influxdb/development/cluster"
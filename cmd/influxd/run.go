@@ -11,12 +11,14 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/influxdb/influxdb"
 	"github.com/influxdb/influxdb/collectd"
 	"github.com/influxdb/influxdb/graphite"
 	"github.com/influxdb/influxdb/httpd"
 	"github.com/influxdb/influxdb/messaging"
+	"github.com/influxdb/influxdb/opentsdb"
 )
 
 func Run(config *Config, join, version string, logWriter *os.File) *influxdb.Server {
@@ -58,6 +60,9 @@ func Run(config *Config, join, version string, logWriter *os.File) *influxdb.Ser
 	// Start the server handler. Attach to broker if listening on the same port.
 	if s != nil {
 		sh := httpd.NewHandler(s, config.Authentication.Enabled, version)
+		if config.HTTPAPI.ChunkSize > 0 {
+			sh.ChunkSize = config.HTTPAPI.ChunkSize
+		}
 		if h != nil && config.BrokerAddr() == config.DataAddr() {
 			h.serverHandler = sh
 		} else {
@@ -70,16 +75,67 @@ func Run(config *Config, join, version string, logWriter *os.File) *influxdb.Ser
 		}
 		log.Printf("data node #%d listening on %s", s.ID(), config.DataAddr())
 
+		// Start the retention policy enforcement sweeper.
+		if err := s.StartRetentionPolicyEnforcement(time.Duration(config.Data.RetentionSweepPeriod)); err != nil {
+			log.Printf("retention policy enforcement failed: %s", err.Error())
+		}
+
+		// Start the continuous query runner.
+		s.StartContinuousQueries(influxdb.DefaultContinuousQueryCheckInterval)
+
+		// Spin up any UDP JSON servers.
+		for _, c := range config.UDPInputs() {
+			if !c.Enabled {
+				continue
+			}
+
+			addr, err := net.ResolveUDPAddr("udp", c.ConnectionString(config.BindAddress))
+			if err != nil {
+				log.Printf("failed to resolve UDP address: %s", err)
+				continue
+			}
+
+			u := influxdb.NewUDPServer(s)
+			u.Addr = addr
+			u.Database = c.Database
+			u.RetentionPolicy = c.RetentionPolicy
+			if c.BatchSize > 0 {
+				u.BatchSize = c.BatchSize
+			}
+			if c.FlushInterval > 0 {
+				u.FlushInterval = time.Duration(c.FlushInterval)
+			}
+			if err := u.ListenAndServe(); err != nil {
+				log.Printf("failed to start UDP server: %s", err)
+			}
+		}
+
 		// Spin up the collectd server
 		if config.Collectd.Enabled {
 			c := config.Collectd
 			cs := collectd.NewServer(s, c.TypesDB)
 			cs.Database = c.Database
+			cs.BatchSize = c.BatchSizeOrDefault()
+			cs.BatchTimeout = c.BatchTimeoutOrDefault()
+			cs.Mapping = c.Mapping
 			err := collectd.ListenAndServe(cs, c.ConnectionString(config.BindAddress))
 			if err != nil {
 				log.Printf("failed to start collectd Server: %v\n", err.Error())
 			}
 		}
+		// Spin up the OpenTSDB server
+		if config.OpenTSDB.Enabled {
+			c := config.OpenTSDB
+			o := opentsdb.NewServer(s)
+			o.Database = c.Database
+			o.RetentionPolicy = c.RetentionPolicy
+			o.BatchSize = c.BatchSizeOrDefault()
+			o.BatchTimeout = c.BatchTimeoutOrDefault()
+			if err := o.ListenAndServe(c.ConnectionString(config.BindAddress)); err != nil {
+				log.Printf("failed to start OpenTSDB Server: %v\n", err.Error())
+			}
+		}
+
 		// Spin up any Graphite servers
 		for _, c := range config.Graphites {
 			if !c.Enabled {
@@ -90,11 +146,23 @@ func Run(config *Config, join, version string, logWriter *os.File) *influxdb.Ser
 			parser := graphite.NewParser()
 			parser.Separator = c.NameSeparatorString()
 			parser.LastEnabled = c.LastEnabled()
+			for _, t := range c.Templates {
+				if err := parser.AddTemplate(t); err != nil {
+					log.Fatalf("failed to configure Graphite template: %s", err)
+				}
+			}
+			tags, err := c.DefaultTags()
+			if err != nil {
+				log.Fatalf("failed to configure Graphite tags: %s", err)
+			}
+			parser.Tags = tags
 
 			// Start the relevant server.
 			if strings.ToLower(c.Protocol) == "tcp" {
 				g := graphite.NewTCPServer(parser, s)
 				g.Database = c.Database
+				g.BatchSize = c.BatchSizeOrDefault()
+				g.BatchTimeout = c.BatchTimeoutOrDefault()
 				err := g.ListenAndServe(c.ConnectionString(config.BindAddress))
 				if err != nil {
 					log.Printf("failed to start TCP Graphite Server: %v\n", err.Error())
@@ -102,10 +170,21 @@ func Run(config *Config, join, version string, logWriter *os.File) *influxdb.Ser
 			} else if strings.ToLower(c.Protocol) == "udp" {
 				g := graphite.NewUDPServer(parser, s)
 				g.Database = c.Database
+				g.BatchSize = c.BatchSizeOrDefault()
+				g.BatchTimeout = c.BatchTimeoutOrDefault()
 				err := g.ListenAndServe(c.ConnectionString(config.BindAddress))
 				if err != nil {
 					log.Printf("failed to start UDP Graphite Server: %v\n", err.Error())
 				}
+			} else if strings.ToLower(c.Protocol) == "pickle" {
+				g := graphite.NewPickleServer(parser, s)
+				g.Database = c.Database
+				g.BatchSize = c.BatchSizeOrDefault()
+				g.BatchTimeout = c.BatchTimeoutOrDefault()
+				err := g.ListenAndServe(c.ConnectionString(config.BindAddress))
+				if err != nil {
+					log.Printf("failed to start pickle Graphite Server: %v\n", err.Error())
+				}
 			} else {
 				log.Fatalf("unrecognized Graphite Server prototcol %s", c.Protocol)
 			}
@@ -12,8 +12,20 @@ import (
 	"github.com/kimor79/gollectd"
 )
 
-// DefaultPort for collectd is 25826
-const DefaultPort = 25826
+const (
+	// DefaultPort for collectd is 25826
+	DefaultPort = 25826
+)
+
+const (
+	// MappingValues maps each value to a separate measurement named
+	// "plugin_value" with a single field of the same name.
+	MappingValues = "values"
+
+	// MappingFields maps each packet to a single point in a measurement named
+	// "plugin" or "plugin_type" with a field for each data source.
+	MappingFields = "fields"
+)
 
 // SeriesWriter defines the interface for the destination of the data.
 type SeriesWriter interface {
@@ -24,19 +36,30 @@ type Server struct {
 	mu sync.Mutex
 	wg sync.WaitGroup
 
-	conn *net.UDPConn
+	conn    *net.UDPConn
+	batcher *influxdb.PointBatcher
 
 	writer      SeriesWriter
 	Database    string
 	typesdb     gollectd.Types
 	typesdbpath string
+
+	// Points are dropped while the batch queue is full.
+	BatchSize    int
+	BatchTimeout time.Duration
+
+	// Mapping determines how packets are mapped to points.
+	// Defaults to MappingValues.
+	Mapping string
 }
 
 func NewServer(w SeriesWriter, typesDBPath string) *Server {
 	s := Server{
-		writer:      w,
-		typesdbpath: typesDBPath,
-		typesdb:     make(gollectd.Types),
+		writer:       w,
+		typesdbpath:  typesDBPath,
+		typesdb:      make(gollectd.Types),
+		BatchSize:    influxdb.DefaultPointBatchSize,
+		BatchTimeout: influxdb.DefaultPointBatchTimeout,
 	}
 
 	return &s
@@ -47,6 +70,8 @@ func ListenAndServe(s *Server, iface string) error {
 		return errors.New("bind address required")
 	} else if s.Database == "" { // Make sure they have a database
 		return errors.New("database was not specified in config")
+	} else if s.Mapping != "" && s.Mapping != MappingValues && s.Mapping != MappingFields {
+		return fmt.Errorf("unknown mapping: %s", s.Mapping)
 	}
 
 	addr, err := net.ResolveUDPAddr("udp", iface)
@@ -65,6 +90,9 @@ func ListenAndServe(s *Server, iface string) error {
 	}
 	s.conn = conn
 
+	// Batch points before writing them to the database.
+	s.batcher = influxdb.StartPointBatcher(s.writer, s.Database, "", s.BatchSize, s.BatchTimeout, &s.wg)
+
 	s.wg.Add(1)
 	go s.serve(conn)
 
@@ -110,17 +138,29 @@ func (s *Server) handleMessage(buffer []byte) {
 	}
 
 	for _, packet := range *packets {
+		if s.Mapping == MappingFields {
+			// Skip packets without values since they have no fields.
+			if len(packet.Values) > 0 {
+				s.batcher.TryWrite(UnmarshalFields(&packet))
+			}
+			continue
+		}
+
 		points := Unmarshal(&packet)
 		for _, p := range points {
-			_, err := s.writer.WriteSeries(s.Database, "", []influxdb.Point{p})
-			if err != nil {
-				log.Printf("Collectd cannot write data: %s", err)
-				continue
-			}
+			s.batcher.TryWrite(p)
 		}
 	}
 }
 
+// Stats returns the counters of the server's point batcher.
+func (s *Server) Stats() influxdb.PointBatcherStats {
+	if s.batcher == nil {
+		return influxdb.PointBatcherStats{}
+	}
+	return s.batcher.Stats()
+}
+
 // Close shuts down the server's listeners.
 func (s *Server) Close() error {
 	// Notify other goroutines of shutdown.
@@ -132,6 +172,9 @@ func (s *Server) Close() error {
 	s.conn.Close()
 	s.conn = nil
 
+	// Write any queued points.
+	s.batcher.Stop()
+
 	// Wait for all goroutines to shutdown.
 	s.wg.Wait()
 	log.Printf("all waitgroups finished")
@@ -140,19 +183,7 @@ func (s *Server) Close() error {
 }
 
 func Unmarshal(data *gollectd.Packet) []influxdb.Point {
-	// Prefer high resolution timestamp.
-	var timestamp time.Time
-	if data.TimeHR > 0 {
-		// TimeHR is "near" nanosecond measurement, but not exactly nanasecond time
-		// Since we store time in microseconds, we round here (mostly so tests will work easier)
-		sec := data.TimeHR >> 30
-		// Shifting, masking, and dividing by 1 billion to get nanoseconds.
-		nsec := ((data.TimeHR & 0x3FFFFFFF) << 30) / 1000 / 1000 / 1000
-		timestamp = time.Unix(int64(sec), int64(nsec)).UTC().Round(time.Microsecond)
-	} else {
-		// If we don't have high resolution time, fall back to basic unix time
-		timestamp = time.Unix(int64(data.Time), 0).UTC()
-	}
+	timestamp := packetTime(data)
 
 	var points []influxdb.Point
 	for i := range data.Values {
@@ -185,3 +216,84 @@ func Unmarshal(data *gollectd.Packet) []influxdb.Point {
 	}
 	return points
 }
+
+// UnmarshalFields maps a packet to a single point with a field for each data
+// source. The measurement is the plugin name, followed by the type name if it
+// differs from the plugin. The "ds_type" tag is set to the data source type,
+// such as "gauge", "derive" or "counter", so that derive and counter values
+// can be converted to rates. If the data sources have different types then
+// each field's type is set in a "ds_type_<name>" tag instead. The packet must
+// have at least one value.
+func UnmarshalFields(data *gollectd.Packet) influxdb.Point {
+	name := data.Plugin
+	if data.Type != "" && data.Type != data.Plugin {
+		name = fmt.Sprintf("%s_%s", data.Plugin, data.Type)
+	}
+
+	tags := make(map[string]string)
+	if data.Hostname != "" {
+		tags["host"] = data.Hostname
+	}
+	if data.PluginInstance != "" {
+		tags["instance"] = data.PluginInstance
+	}
+	if data.TypeInstance != "" {
+		tags["type_instance"] = data.TypeInstance
+	}
+
+	values := make(map[string]interface{}, len(data.Values))
+	mixed := false
+	for _, v := range data.Values {
+		values[v.Name] = v.Value
+		if v.Type != data.Values[0].Type {
+			mixed = true
+		}
+	}
+
+	// Tag the data source type once or per field if the types differ.
+	if mixed {
+		for _, v := range data.Values {
+			tags["ds_type_"+v.Name] = dataSourceType(v.Type)
+		}
+	} else if len(data.Values) > 0 {
+		tags["ds_type"] = dataSourceType(data.Values[0].Type)
+	}
+
+	return influxdb.Point{
+		Name:      name,
+		Tags:      tags,
+		Timestamp: packetTime(data),
+		Values:    values,
+	}
+}
+
+// packetTime returns the time of a packet.
+func packetTime(data *gollectd.Packet) time.Time {
+	// Prefer high resolution timestamp.
+	if data.TimeHR > 0 {
+		// TimeHR is "near" nanosecond measurement, but not exactly nanasecond time
+		// Since we store time in microseconds, we round here (mostly so tests will work easier)
+		sec := data.TimeHR >> 30
+		// Shifting, masking, and dividing by 1 billion to get nanoseconds.
+		nsec := ((data.TimeHR & 0x3FFFFFFF) << 30) / 1000 / 1000 / 1000
+		return time.Unix(int64(sec), int64(nsec)).UTC().Round(time.Microsecond)
+	}
+
+	// If we don't have high resolution time, fall back to basic unix time
+	return time.Unix(int64(data.Time), 0).UTC()
+}
+
+// dataSourceType returns the name of a types.db data source type.
+func dataSourceType(t uint8) string {
+	switch t {
+	case gollectd.TypeCounter:
+		return "counter"
+	case gollectd.TypeGauge:
+		return "gauge"
+	case gollectd.TypeDerive:
+		return "derive"
+	case gollectd.TypeAbsolute:
+		return "absolute"
+	}
+	return "unknown"
+}
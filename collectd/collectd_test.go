@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"net"
+	"reflect"
 	"testing"
 	"time"
 
@@ -46,6 +47,21 @@ func (testServer) ResponseN(n int) ([]*serverResponse, error) {
 	}
 }
 
+func (testServer) PointsN(n int) ([]influxdb.Point, error) {
+	var a []influxdb.Point
+	for {
+		select {
+		case r := <-responses:
+			a = append(a, r.points...)
+			if len(a) >= n {
+				return a, nil
+			}
+		case <-time.After(2 * time.Second):
+			return a, fmt.Errorf("unexpected point count: expected: %d, actual: %d", n, len(a))
+		}
+	}
+}
+
 func TestServer_ListenAndServe_ErrBindAddressRequired(t *testing.T) {
 	var (
 		ts testServer
@@ -178,8 +194,10 @@ func TestServer_Serve_Success(t *testing.T) {
 		t.Fatalf("err does not match.  expected %v, got %v", nil, e)
 	}
 
-	if _, err := ts.ResponseN(33); err != nil {
+	if points, err := ts.PointsN(33); err != nil {
 		t.Fatal(err)
+	} else if len(points) != 33 {
+		t.Fatalf("unexpected point count: expected: %d, actual: %d", 33, len(points))
 	}
 }
 
@@ -291,6 +309,93 @@ func TestUnmarshal_Points(t *testing.T) {
 	}
 }
 
+func TestUnmarshalFields(t *testing.T) {
+	var tests = []struct {
+		name   string
+		packet gollectd.Packet
+		point  influxdb.Point
+	}{
+		{
+			name: "multi value",
+			packet: gollectd.Packet{
+				Hostname:       "server01",
+				Plugin:         "interface",
+				PluginInstance: "eth0",
+				Type:           "if_octets",
+				Time:           1414080767,
+				Values: []gollectd.Value{
+					{Name: "rx", Type: gollectd.TypeDerive, Value: 1},
+					{Name: "tx", Type: gollectd.TypeDerive, Value: 5},
+				},
+			},
+			point: influxdb.Point{
+				Name:      "interface_if_octets",
+				Tags:      map[string]string{"host": "server01", "instance": "eth0", "ds_type": "derive"},
+				Values:    map[string]interface{}{"rx": float64(1), "tx": float64(5)},
+				Timestamp: time.Unix(1414080767, 0).UTC(),
+			},
+		},
+		{
+			name: "type matches plugin",
+			packet: gollectd.Packet{
+				Plugin:       "load",
+				Type:         "load",
+				TypeInstance: "relative",
+				Time:         1414080767,
+				Values: []gollectd.Value{
+					{Name: "shortterm", Type: gollectd.TypeGauge, Value: 0.5},
+					{Name: "midterm", Type: gollectd.TypeGauge, Value: 0.25},
+					{Name: "longterm", Type: gollectd.TypeGauge, Value: 0.125},
+				},
+			},
+			point: influxdb.Point{
+				Name:      "load",
+				Tags:      map[string]string{"type_instance": "relative", "ds_type": "gauge"},
+				Values:    map[string]interface{}{"shortterm": 0.5, "midterm": 0.25, "longterm": 0.125},
+				Timestamp: time.Unix(1414080767, 0).UTC(),
+			},
+		},
+		{
+			name: "mixed data source types",
+			packet: gollectd.Packet{
+				Plugin: "disk",
+				Time:   1414080767,
+				Values: []gollectd.Value{
+					{Name: "read", Type: gollectd.TypeCounter, Value: 1},
+					{Name: "queue", Type: gollectd.TypeGauge, Value: 2},
+				},
+			},
+			point: influxdb.Point{
+				Name:      "disk",
+				Tags:      map[string]string{"ds_type_read": "counter", "ds_type_queue": "gauge"},
+				Values:    map[string]interface{}{"read": float64(1), "queue": float64(2)},
+				Timestamp: time.Unix(1414080767, 0).UTC(),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Logf("testing %q", test.name)
+		if p := collectd.UnmarshalFields(&test.packet); !reflect.DeepEqual(p, test.point) {
+			t.Errorf("point mismatch.\nexp=%#v\ngot=%#v", test.point, p)
+		}
+	}
+}
+
+func TestServer_ListenAndServe_ErrUnknownMapping(t *testing.T) {
+	var (
+		ts testServer
+		s  = collectd.NewServer(ts, "./collectd_test.conf")
+	)
+
+	s.Database = "counter"
+	s.Mapping = "foo"
+	e := collectd.ListenAndServe(s, "127.0.0.1:25831")
+	if e == nil || e.Error() != "unknown mapping: foo" {
+		t.Fatalf("unexpected error: %v", e)
+	}
+}
+
 func TestUnmarshal_Time(t *testing.T) {
 	// Its important to remember that collectd stores high resolution time
 	// as "near" nanoseconds (2^30) so we have to take that into account
@@ -23,6 +23,8 @@ type database struct {
 
 	defaultRetentionPolicy string
 
+	continuousQueries []*ContinuousQuery // continuous queries
+
 	// in memory indexing structures
 	measurements map[string]*Measurement // measurement name to object and index
 	series       map[uint32]*Series      // map series id to the Series object
@@ -71,6 +73,7 @@ func (db *database) MarshalJSON() ([]byte, error) {
 	for _, rp := range db.policies {
 		o.Policies = append(o.Policies, rp)
 	}
+	o.ContinuousQueries = db.continuousQueries
 	return json.Marshal(&o)
 }
 
@@ -92,6 +95,20 @@ func (db *database) UnmarshalJSON(data []byte) error {
 		db.policies[rp.Name] = rp
 	}
 
+	// Copy continuous queries.
+	db.continuousQueries = o.ContinuousQueries
+
+	return nil
+}
+
+// continuousQueryByName returns a continuous query by name.
+// Returns nil if the query does not exist.
+func (db *database) continuousQueryByName(name string) *ContinuousQuery {
+	for _, cq := range db.continuousQueries {
+		if cq.Name() == name {
+			return cq
+		}
+	}
 	return nil
 }
 
@@ -100,6 +117,7 @@ type databaseJSON struct {
 	Name                   string             `json:"name,omitempty"`
 	DefaultRetentionPolicy string             `json:"defaultRetentionPolicy,omitempty"`
 	Policies               []*RetentionPolicy `json:"policies,omitempty"`
+	ContinuousQueries      []*ContinuousQuery `json:"continuousQueries,omitempty"`
 }
 
 // Measurement represents a collection of time series in a database. It also contains in memory
@@ -212,25 +230,93 @@ func (m *Measurement) addSeries(s *Series) bool {
 	return true
 }
 
+// dropSeries removes a series from the measurementIndex.
+func (m *Measurement) dropSeries(seriesID uint32) {
+	s := m.seriesByID[seriesID]
+	if s == nil {
+		return
+	}
+	delete(m.seriesByID, seriesID)
+	delete(m.series, string(marshalTags(s.Tags)))
+	m.seriesIDs = m.seriesIDs.reject(seriesIDs{seriesID})
+
+	// remove this series id from the tag index on the measurement
+	for k, v := range s.Tags {
+		valueMap := m.seriesByTagKeyValue[k]
+		if ids := valueMap[v].reject(seriesIDs{seriesID}); len(ids) > 0 {
+			valueMap[v] = ids
+			continue
+		}
+
+		// drop the tag value, and the tag key if no values remain.
+		delete(valueMap, v)
+		if len(valueMap) == 0 {
+			delete(m.seriesByTagKeyValue, k)
+		}
+	}
+}
+
 // seriesByTags returns the Series that matches the given tagset.
 func (m *Measurement) seriesByTags(tags map[string]string) *Series {
 	return m.series[string(marshalTags(tags))]
 }
 
 // mapValues converts a map of values with string keys to field id keys.
-// Returns nil if any field doesn't exist.
-func (m *Measurement) mapValues(values map[string]interface{}) map[uint8]interface{} {
+// Returns nil if any field doesn't exist. Returns ErrFieldTypeConflict if a
+// value cannot be stored in its existing field.
+func (m *Measurement) mapValues(values map[string]interface{}) (map[uint8]interface{}, error) {
 	other := make(map[uint8]interface{}, len(values))
 	for k, v := range values {
-		// TODO: Cast value to original field type.
-
 		f := m.FieldByName(k)
 		if f == nil {
-			return nil
+			other = nil
+			continue
+		}
+
+		v, err := castFieldValue(v, f.Type)
+		if err != nil {
+			return nil, err
+		}
+		if other != nil {
+			other[f.ID] = v
 		}
-		other[f.ID] = v
 	}
-	return other
+	return other, nil
+}
+
+// castFieldValue converts v to the representation stored for a field of type typ.
+// Integers are widened when written to a float field. An unknown type accepts
+// any value. Returns ErrFieldTypeConflict if the value cannot be stored in the field.
+func castFieldValue(v interface{}, typ influxql.DataType) (interface{}, error) {
+	switch val := v.(type) {
+	case int:
+		v = int64(val)
+	case int32:
+		v = int64(val)
+	case json.Number:
+		if typ == influxql.Integer {
+			i, err := val.Int64()
+			if err != nil {
+				return nil, ErrFieldTypeConflict
+			}
+			return i, nil
+		}
+		f, err := val.Float64()
+		if err != nil {
+			return nil, ErrFieldTypeConflict
+		}
+		v = f
+	}
+
+	// Widen integers written to float fields.
+	if i, ok := v.(int64); ok && typ == influxql.Number {
+		return float64(i), nil
+	}
+
+	if typ != influxql.Unknown && influxql.InspectDataType(v) != typ {
+		return nil, ErrFieldTypeConflict
+	}
+	return v, nil
 }
 
 func (m *Measurement) seriesIDsAndFilters(stmt *influxql.SelectStatement) (seriesIDs, map[uint32]influxql.Expr) {
@@ -238,7 +324,14 @@ func (m *Measurement) seriesIDsAndFilters(stmt *influxql.SelectStatement) (serie
 	if stmt.Condition == nil {
 		return m.seriesIDs, nil
 	}
-	ids, _, _ := m.walkWhereForSeriesIds(stmt.Condition, seriesIdsToExpr)
+	ids, _, expr := m.walkWhereForSeriesIds(stmt.Condition, seriesIdsToExpr)
+
+	// If the condition is a single field expression then apply it to every series.
+	if expr != nil {
+		for _, id := range ids {
+			seriesIdsToExpr[id] = expr
+		}
+	}
 
 	// ids will be empty if all they had was a time in the where clause. so return all measurement series ids
 	if len(ids) == 0 && stmt.OnlyTimeDimensions() {
@@ -300,6 +393,18 @@ func (m *Measurement) idsForExpr(n *influxql.BinaryExpr) (seriesIDs, bool, influ
 		return m.seriesIDs, true, n
 	}
 
+	// match tag values against a regex
+	if re, ok := value.(*influxql.RegexLiteral); ok {
+		// series without the tag never match the regex
+		if _, ok := m.seriesByTagKeyValue[name.Val]; !ok {
+			if n.Op == influxql.NEQREGEX {
+				return m.seriesIDs, true, nil
+			}
+			return nil, true, nil
+		}
+		return m.seriesIDsByFilter(&TagFilter{Key: name.Val, Regex: re.Val, Not: n.Op == influxql.NEQREGEX}), true, nil
+	}
+
 	// tag values can only be strings so if it's not a string this is an empty set
 	str, ok := value.(*influxql.StringLiteral)
 	if !ok {
@@ -771,6 +876,9 @@ type RetentionPolicy struct {
 	// Length of time to keep data around
 	Duration time.Duration `json:"duration"`
 
+	// Length of time covered by each shard group.
+	ShardGroupDuration time.Duration `json:"shardGroupDuration"`
+
 	// The number of copies to make of each shard.
 	ReplicaN uint32 `json:"replicaN"`
 
@@ -780,10 +888,23 @@ type RetentionPolicy struct {
 // NewRetentionPolicy returns a new instance of RetentionPolicy with defaults set.
 func NewRetentionPolicy(name string) *RetentionPolicy {
 	return &RetentionPolicy{
-		Name:     name,
-		ReplicaN: DefaultReplicaN,
-		Duration: DefaultShardRetention,
+		Name:               name,
+		ReplicaN:           DefaultReplicaN,
+		Duration:           DefaultShardRetention,
+		ShardGroupDuration: shardGroupDuration(DefaultShardRetention),
+	}
+}
+
+// shardGroupDuration returns the default shard group duration for a retention duration.
+// Long or infinite retention periods use weekly groups, retention periods of
+// at least two days use daily groups and shorter periods use hourly groups.
+func shardGroupDuration(d time.Duration) time.Duration {
+	if d >= 180*24*time.Hour || d == 0 {
+		return 7 * 24 * time.Hour
+	} else if d >= 2*24*time.Hour {
+		return 1 * 24 * time.Hour
 	}
+	return 1 * time.Hour
 }
 
 // shardGroupByTimestamp returns the group in the policy that owns a timestamp.
@@ -797,11 +918,33 @@ func (rp *RetentionPolicy) shardGroupByTimestamp(timestamp time.Time) *ShardGrou
 	return nil
 }
 
+// shardGroupByID returns the group in the policy for the given ID.
+// Returns nil if group does not exist.
+func (rp *RetentionPolicy) shardGroupByID(shardID uint64) *ShardGroup {
+	for _, g := range rp.shardGroups {
+		if g.ID == shardID {
+			return g
+		}
+	}
+	return nil
+}
+
+// removeShardGroupByID removes the group with the given ID from the policy.
+func (rp *RetentionPolicy) removeShardGroupByID(shardID uint64) {
+	for i, g := range rp.shardGroups {
+		if g.ID == shardID {
+			rp.shardGroups = append(rp.shardGroups[:i], rp.shardGroups[i+1:]...)
+			return
+		}
+	}
+}
+
 // MarshalJSON encodes a retention policy to a JSON-encoded byte slice.
 func (rp *RetentionPolicy) MarshalJSON() ([]byte, error) {
 	var o retentionPolicyJSON
 	o.Name = rp.Name
 	o.Duration = rp.Duration
+	o.ShardGroupDuration = rp.ShardGroupDuration
 	o.ReplicaN = rp.ReplicaN
 	for _, g := range rp.shardGroups {
 		o.ShardGroups = append(o.ShardGroups, g)
@@ -821,18 +964,25 @@ func (rp *RetentionPolicy) UnmarshalJSON(data []byte) error {
 	rp.Name = o.Name
 	rp.ReplicaN = o.ReplicaN
 	rp.Duration = o.Duration
+	rp.ShardGroupDuration = o.ShardGroupDuration
 	rp.shardGroups = o.ShardGroups
 
+	// Policies saved before shard group durations existed use the default.
+	if rp.ShardGroupDuration == 0 {
+		rp.ShardGroupDuration = shardGroupDuration(rp.Duration)
+	}
+
 	return nil
 }
 
 // retentionPolicyJSON represents an intermediate struct for JSON marshaling.
 type retentionPolicyJSON struct {
-	Name        string        `json:"name"`
-	ReplicaN    uint32        `json:"replicaN,omitempty"`
-	SplitN      uint32        `json:"splitN,omitempty"`
-	Duration    time.Duration `json:"duration,omitempty"`
-	ShardGroups []*ShardGroup `json:"shardGroups,omitempty"`
+	Name               string        `json:"name"`
+	ReplicaN           uint32        `json:"replicaN,omitempty"`
+	SplitN             uint32        `json:"splitN,omitempty"`
+	Duration           time.Duration `json:"duration,omitempty"`
+	ShardGroupDuration time.Duration `json:"shardGroupDuration,omitempty"`
+	ShardGroups        []*ShardGroup `json:"shardGroups,omitempty"`
 }
 
 // TagFilter represents a tag filter when looking up other tags or measurements.
@@ -973,6 +1123,18 @@ func (db *database) addSeriesToIndex(measurementName string, s *Series) bool {
 	return idx.addSeries(s)
 }
 
+// dropSeries removes the series from the database index and from their measurements.
+func (db *database) dropSeries(seriesIDs ...uint32) {
+	for _, id := range seriesIDs {
+		s := db.series[id]
+		if s == nil {
+			continue
+		}
+		s.measurement.dropSeries(id)
+		delete(db.series, id)
+	}
+}
+
 // createMeasurementIfNotExists will either add a measurement object to the index or return the existing one.
 func (db *database) createMeasurementIfNotExists(name string) *Measurement {
 	idx := db.measurements[name]
@@ -1128,6 +1290,23 @@ func (db *database) measurementsByExpr(expr influxql.Expr) (Measurements, error)
 				Value: value.Val,
 			}
 			return db.measurementsByTagFilters([]*TagFilter{tf}), nil
+		case influxql.EQREGEX, influxql.NEQREGEX:
+			tag, ok := e.LHS.(*influxql.VarRef)
+			if !ok {
+				return nil, fmt.Errorf("left side of '=~' must be a tag name")
+			}
+
+			re, ok := e.RHS.(*influxql.RegexLiteral)
+			if !ok {
+				return nil, fmt.Errorf("right side of '=~' must be a regex")
+			}
+
+			tf := &TagFilter{
+				Not:   e.Op == influxql.NEQREGEX,
+				Key:   tag.Val,
+				Regex: re.Val,
+			}
+			return db.measurementsByTagFilters([]*TagFilter{tf}), nil
 		case influxql.OR, influxql.AND:
 			lhsIDs, err := db.measurementsByExpr(e.LHS)
 			if err != nil {
@@ -1170,7 +1349,14 @@ func (db *database) measurementsByTagFilters(filters []*TagFilter) Measurements
 		for _, f := range filters {
 			tagMatch = false
 			if tagVals, ok := m.seriesByTagKeyValue[f.Key]; ok {
-				if _, ok := tagVals[f.Value]; ok {
+				if f.Regex != nil {
+					for v := range tagVals {
+						if f.Regex.MatchString(v) {
+							tagMatch = true
+							break
+						}
+					}
+				} else if _, ok := tagVals[f.Value]; ok {
 					tagMatch = true
 				}
 			}
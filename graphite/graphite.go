@@ -14,6 +14,9 @@ const (
 	// DefaultGraphitePort represents the default Graphite (Carbon) plaintext port.
 	DefaultGraphitePort = 2003
 
+	// DefaultGraphitePicklePort represents the default Graphite (Carbon) pickle port.
+	DefaultGraphitePicklePort = 2004
+
 	// DefaultGraphiteNameSeparator represents the default Graphite field separator.
 	DefaultGraphiteNameSeparator = "."
 )
@@ -42,6 +45,11 @@ type SeriesWriter interface {
 type Parser struct {
 	Separator   string
 	LastEnabled bool
+
+	// Tags are added to every parsed point unless the point already has the tag.
+	Tags map[string]string
+
+	templates []*template
 }
 
 // NewParser returns a GraphiteParser instance.
@@ -57,26 +65,12 @@ func (p *Parser) Parse(line string) (influxdb.Point, error) {
 		return influxdb.Point{}, fmt.Errorf("received %q which doesn't have three fields", line)
 	}
 
-	// decode the name and tags
-	name, tags, err := p.DecodeNameAndTags(fields[0])
-	if err != nil {
-		return influxdb.Point{}, err
-	}
-
 	// Parse value.
 	v, err := strconv.ParseFloat(fields[1], 64)
 	if err != nil {
 		return influxdb.Point{}, err
 	}
 
-	values := make(map[string]interface{})
-	// Determine if value is a float or an int.
-	if i := int64(v); float64(i) == v {
-		values[name] = int64(v)
-	} else {
-		values[name] = v
-	}
-
 	// Parse timestamp.
 	unixTime, err := strconv.ParseInt(fields[2], 10, 64)
 	if err != nil {
@@ -85,6 +79,31 @@ func (p *Parser) Parse(line string) (influxdb.Point, error) {
 
 	timestamp := time.Unix(0, unixTime*int64(time.Millisecond))
 
+	return p.newPoint(fields[0], v, timestamp)
+}
+
+// newPoint returns a point for a metric path, value and timestamp.
+func (p *Parser) newPoint(path string, v float64, timestamp time.Time) (influxdb.Point, error) {
+	// decode the name, field and tags
+	name, field, tags, err := p.decodePath(path)
+	if err != nil {
+		return influxdb.Point{}, err
+	}
+	if field == "" {
+		field = name
+	}
+
+	// Add default tags.
+	for k, v := range p.Tags {
+		if _, ok := tags[k]; !ok {
+			tags[k] = v
+		}
+	}
+
+	// Values are always written as floats so that a metric's field type
+	// doesn't depend on whether its first value was a whole number.
+	values := map[string]interface{}{field: v}
+
 	point := influxdb.Point{
 		Name:      name,
 		Tags:      tags,
@@ -95,6 +114,58 @@ func (p *Parser) Parse(line string) (influxdb.Point, error) {
 	return point, nil
 }
 
+// AddTemplate adds a template for mapping metric paths to a measurement, tags and field.
+//
+// A template has the form "[filter] template [tags]". The template is a
+// dot-separated list of elements, one for each segment of the metric path:
+//
+//	measurement   the segment is part of the measurement name
+//	measurement*  the segment and all remaining segments are part of the measurement name
+//	field         the segment is part of the field name
+//	field*        the segment and all remaining segments are part of the field name
+//	(empty)       the segment is ignored
+//	<tag key>     the segment is the value of the named tag
+//
+// Multiple measurement or field segments are joined with the parser's separator.
+// If a template has no field elements then the measurement name is used as the field.
+//
+// The optional filter is a dot-separated path prefix where "*" matches any
+// segment. A metric uses the template with the longest matching filter or,
+// if no filter matches, the template without a filter. Metrics that do not
+// match any template are decoded by DecodeNameAndTags.
+//
+// The optional tags are a comma-separated list of key=value pairs that are
+// added to every point matching the template.
+func (p *Parser) AddTemplate(s string) error {
+	t, err := parseTemplate(s)
+	if err != nil {
+		return err
+	}
+	p.templates = append(p.templates, t)
+	return nil
+}
+
+// decodePath parses the name, field and tags of a metric path using the
+// parser's templates. The field is blank if the template does not specify one.
+func (p *Parser) decodePath(path string) (name, field string, tags map[string]string, err error) {
+	segments := strings.Split(path, p.Separator)
+
+	// Find the template with the most specific matching filter.
+	var match *template
+	for _, t := range p.templates {
+		if t.matches(segments) && (match == nil || len(t.filter) > len(match.filter)) {
+			match = t
+		}
+	}
+
+	// Fall back to alternating keys and values if there's no template.
+	if match == nil {
+		name, tags, err = p.DecodeNameAndTags(path)
+		return name, "", tags, err
+	}
+	return match.apply(segments, p.Separator)
+}
+
 // DecodeNameAndTags parses the name and tags of a single field of a Graphite datum.
 func (p *Parser) DecodeNameAndTags(field string) (string, map[string]string, error) {
 	var (
@@ -131,3 +202,107 @@ func (p *Parser) DecodeNameAndTags(field string) (string, map[string]string, err
 
 	return name, tags, nil
 }
+
+// template represents a mapping of metric path segments to a measurement, tags and field.
+type template struct {
+	filter []string          // path prefix; "*" matches any segment
+	parts  []string          // element for each path segment
+	tags   map[string]string // tags added to matching points
+}
+
+// parseTemplate parses a template in the form "[filter] template [tags]".
+func parseTemplate(s string) (*template, error) {
+	t := &template{tags: make(map[string]string)}
+
+	// Determine which fields are present. Tags always contain an equal sign.
+	var tmpl, tags string
+	fields := strings.Fields(s)
+	switch len(fields) {
+	case 1:
+		tmpl = fields[0]
+	case 2:
+		if strings.Contains(fields[1], "=") {
+			tmpl, tags = fields[0], fields[1]
+		} else {
+			t.filter, tmpl = strings.Split(fields[0], "."), fields[1]
+		}
+	case 3:
+		t.filter, tmpl, tags = strings.Split(fields[0], "."), fields[1], fields[2]
+	default:
+		return nil, fmt.Errorf("invalid template: %q", s)
+	}
+
+	// Validate the template elements. Wildcard elements must come last.
+	t.parts = strings.Split(tmpl, ".")
+	var hasMeasurement bool
+	for i, part := range t.parts {
+		switch part {
+		case "measurement*", "field*":
+			if i != len(t.parts)-1 {
+				return nil, fmt.Errorf("invalid template: %q: %s must be the last element", s, part)
+			}
+		}
+		if strings.HasPrefix(part, "measurement") {
+			hasMeasurement = true
+		}
+	}
+	if !hasMeasurement {
+		return nil, fmt.Errorf("invalid template: %q: no measurement specified", s)
+	}
+
+	// Parse the tags.
+	if tags != "" {
+		for _, kv := range strings.Split(tags, ",") {
+			a := strings.SplitN(kv, "=", 2)
+			if len(a) != 2 || a[0] == "" {
+				return nil, fmt.Errorf("invalid template tag: %q", kv)
+			}
+			t.tags[a[0]] = a[1]
+		}
+	}
+
+	return t, nil
+}
+
+// matches returns true if the path segments begin with the template's filter.
+func (t *template) matches(segments []string) bool {
+	if len(segments) < len(t.filter) {
+		return false
+	}
+	for i, f := range t.filter {
+		if f != "*" && f != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// apply maps path segments to a measurement name, field name and tags.
+func (t *template) apply(segments []string, separator string) (string, string, map[string]string, error) {
+	var measurement, field []string
+	tags := make(map[string]string, len(t.tags))
+	for k, v := range t.tags {
+		tags[k] = v
+	}
+
+	for i := 0; i < len(t.parts) && i < len(segments); i++ {
+		switch part := t.parts[i]; part {
+		case "":
+		case "measurement":
+			measurement = append(measurement, segments[i])
+		case "measurement*":
+			measurement = append(measurement, segments[i:]...)
+		case "field":
+			field = append(field, segments[i])
+		case "field*":
+			field = append(field, segments[i:]...)
+		default:
+			tags[part] = segments[i]
+		}
+	}
+
+	if len(measurement) == 0 {
+		return "", "", tags, fmt.Errorf("no measurement specified for metric. %q", strings.Join(segments, separator))
+	}
+	return strings.Join(measurement, separator), strings.Join(field, separator), tags, nil
+}
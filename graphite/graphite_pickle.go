@@ -0,0 +1,158 @@
+package graphite
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdb/influxdb"
+)
+
+// maxPickleSize is the largest pickled message accepted from a client.
+const maxPickleSize = 1 << 20
+
+// PickleServer processes Graphite data received over TCP connections
+// using the Carbon pickle protocol. Each message is a 4-byte big-endian
+// length followed by a pickled list of (path, (timestamp, value)) tuples.
+type PickleServer struct {
+	writer  SeriesWriter
+	parser  *Parser
+	batcher *influxdb.PointBatcher
+
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+
+	Database string
+
+	// Reading from connections blocks while the batch queue is full.
+	BatchSize    int
+	BatchTimeout time.Duration
+}
+
+// NewPickleServer returns a new instance of a PickleServer.
+func NewPickleServer(p *Parser, w SeriesWriter) *PickleServer {
+	return &PickleServer{
+		parser:       p,
+		writer:       w,
+		BatchSize:    influxdb.DefaultPointBatchSize,
+		BatchTimeout: influxdb.DefaultPointBatchTimeout,
+	}
+}
+
+// ListenAndServe instructs the PickleServer to start processing Graphite data
+// on the given interface. iface must be in the form host:port
+func (s *PickleServer) ListenAndServe(iface string) error {
+	if iface == "" { // Make sure we have an address
+		return ErrBindAddressRequired
+	} else if s.Database == "" { // Make sure they have a database
+		return ErrDatabaseNotSpecified
+	}
+
+	ln, err := net.Listen("tcp", iface)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.listener = ln
+	s.conns = make(map[net.Conn]struct{})
+	s.mu.Unlock()
+
+	s.batcher = influxdb.StartPointBatcher(s.writer, s.Database, "", s.BatchSize, s.BatchTimeout, &s.wg)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if strings.Contains(err.Error(), "closed network connection") {
+					return
+				}
+				log.Println("error accepting pickle connection", err.Error())
+				continue
+			}
+
+			s.mu.Lock()
+			if s.listener == nil {
+				s.mu.Unlock()
+				conn.Close()
+				return
+			}
+			s.conns[conn] = struct{}{}
+			s.wg.Add(1)
+			s.mu.Unlock()
+
+			go s.handleConnection(conn)
+		}
+	}()
+	return nil
+}
+
+// Close stops the listener and closes open connections. Queued points are
+// written before Close returns.
+func (s *PickleServer) Close() error {
+	s.mu.Lock()
+	if s.listener == nil {
+		s.mu.Unlock()
+		return ErrServerClosed
+	}
+	_ = s.listener.Close()
+	s.listener = nil
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+
+	// Write any queued points and wait for all goroutines to finish.
+	s.batcher.Stop()
+	s.wg.Wait()
+
+	return nil
+}
+
+// handleConnection services an individual pickle connection.
+func (s *PickleServer) handleConnection(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn)
+	for {
+		// Read the message length.
+		var n uint32
+		if err := binary.Read(reader, binary.BigEndian, &n); err != nil {
+			return
+		} else if n > maxPickleSize {
+			log.Printf("pickle message too large: %d bytes", n)
+			return
+		}
+
+		// Read the message.
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return
+		}
+
+		// Decode it.
+		points, err := s.parser.DecodePickle(buf)
+		if err != nil {
+			log.Printf("unable to decode pickle data: %s", err)
+			continue
+		}
+
+		// Queue the data to be written to the database.
+		for _, p := range points {
+			s.batcher.Write(p)
+		}
+	}
+}
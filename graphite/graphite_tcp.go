@@ -5,23 +5,37 @@ import (
 	"log"
 	"net"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/influxdb/influxdb"
 )
 
 // TCPServer processes Graphite data received over TCP connections.
 type TCPServer struct {
-	writer SeriesWriter
-	parser *Parser
+	writer  SeriesWriter
+	parser  *Parser
+	batcher *influxdb.PointBatcher
+
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	listener net.Listener
+	conns    map[net.Conn]struct{}
 
 	Database string
+
+	// Reading from connections blocks while the batch queue is full.
+	BatchSize    int
+	BatchTimeout time.Duration
 }
 
 // NewTCPServer returns a new instance of a TCPServer.
 func NewTCPServer(p *Parser, w SeriesWriter) *TCPServer {
 	return &TCPServer{
-		parser: p,
-		writer: w,
+		parser:       p,
+		writer:       w,
+		BatchSize:    influxdb.DefaultPointBatchSize,
+		BatchTimeout: influxdb.DefaultPointBatchTimeout,
 	}
 }
 
@@ -38,22 +52,72 @@ func (t *TCPServer) ListenAndServe(iface string) error {
 	if err != nil {
 		return err
 	}
+	t.mu.Lock()
+	t.listener = ln
+	t.conns = make(map[net.Conn]struct{})
+	t.mu.Unlock()
+
+	t.batcher = influxdb.StartPointBatcher(t.writer, t.Database, "", t.BatchSize, t.BatchTimeout, &t.wg)
+	t.wg.Add(1)
 	go func() {
+		defer t.wg.Done()
 		for {
 			conn, err := ln.Accept()
 			if err != nil {
+				if strings.Contains(err.Error(), "closed network connection") {
+					return
+				}
 				log.Println("error accepting TCP connection", err.Error())
 				continue
 			}
+
+			t.mu.Lock()
+			if t.listener == nil {
+				t.mu.Unlock()
+				conn.Close()
+				return
+			}
+			t.conns[conn] = struct{}{}
+			t.wg.Add(1)
+			t.mu.Unlock()
+
 			go t.handleConnection(conn)
 		}
 	}()
 	return nil
 }
 
+// Close stops the listener and closes open connections. Queued points are
+// written before Close returns.
+func (t *TCPServer) Close() error {
+	t.mu.Lock()
+	if t.listener == nil {
+		t.mu.Unlock()
+		return ErrServerClosed
+	}
+	_ = t.listener.Close()
+	t.listener = nil
+	for conn := range t.conns {
+		_ = conn.Close()
+	}
+	t.mu.Unlock()
+
+	// Write any queued points and wait for all goroutines to finish.
+	t.batcher.Stop()
+	t.wg.Wait()
+
+	return nil
+}
+
 // handleConnection services an individual TCP connection.
 func (t *TCPServer) handleConnection(conn net.Conn) {
-	defer conn.Close()
+	defer t.wg.Done()
+	defer func() {
+		t.mu.Lock()
+		delete(t.conns, conn)
+		t.mu.Unlock()
+		conn.Close()
+	}()
 
 	reader := bufio.NewReader(conn)
 	for {
@@ -73,7 +137,7 @@ func (t *TCPServer) handleConnection(conn net.Conn) {
 			continue
 		}
 
-		// Send the data to database
-		t.writer.WriteSeries(t.Database, "", []influxdb.Point{point})
+		// Queue the data to be written to the database.
+		t.batcher.Write(point)
 	}
 }
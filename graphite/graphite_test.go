@@ -1,10 +1,14 @@
 package graphite_test
 
 import (
+	"encoding/binary"
+	"net"
+	"reflect"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/influxdb/influxdb"
 	"github.com/influxdb/influxdb/graphite"
 )
 
@@ -61,8 +65,6 @@ func Test_DecodeMetric(t *testing.T) {
 		line                string
 		name                string
 		tags                map[string]string
-		isInt               bool
-		iv                  int64
 		fv                  float64
 		timestamp           time.Time
 		position, separator string
@@ -73,8 +75,7 @@ func Test_DecodeMetric(t *testing.T) {
 			line:      `cpu.foo.bar 50 ` + strTime,
 			name:      "cpu",
 			tags:      map[string]string{"foo": "bar"},
-			isInt:     true,
-			iv:        50,
+			fv:        50,
 			timestamp: testTime,
 		},
 		{
@@ -83,8 +84,7 @@ func Test_DecodeMetric(t *testing.T) {
 			line:      `cpu.foo.bar 50 ` + strTime,
 			name:      "cpu",
 			tags:      map[string]string{"foo": "bar"},
-			isInt:     true,
-			iv:        50,
+			fv:        50,
 			timestamp: testTime,
 		},
 		{
@@ -93,8 +93,7 @@ func Test_DecodeMetric(t *testing.T) {
 			line:      `foo.bar.cpu 50 ` + strTime,
 			name:      "cpu",
 			tags:      map[string]string{"foo": "bar"},
-			isInt:     true,
-			iv:        50,
+			fv:        50,
 			timestamp: testTime,
 		},
 		{
@@ -103,8 +102,7 @@ func Test_DecodeMetric(t *testing.T) {
 			line:      `cpu 50 ` + strTime,
 			name:      "cpu",
 			tags:      map[string]string{},
-			isInt:     true,
-			iv:        50,
+			fv:        50,
 			timestamp: testTime,
 		},
 		{
@@ -113,8 +111,7 @@ func Test_DecodeMetric(t *testing.T) {
 			line:      `cpu 50 ` + strTime,
 			name:      "cpu",
 			tags:      map[string]string{},
-			isInt:     true,
-			iv:        50,
+			fv:        50,
 			timestamp: testTime,
 		},
 		{
@@ -122,8 +119,7 @@ func Test_DecodeMetric(t *testing.T) {
 			line:      `cpu.foo.bar 50 ` + strTime,
 			name:      "cpu",
 			tags:      map[string]string{"foo": "bar"},
-			isInt:     true,
-			iv:        50,
+			fv:        50,
 			timestamp: testTime,
 		},
 		{
@@ -132,8 +128,7 @@ func Test_DecodeMetric(t *testing.T) {
 			line:      `cpu.foo.bar 50 ` + strTime,
 			name:      "cpu",
 			tags:      map[string]string{"foo": "bar"},
-			isInt:     true,
-			iv:        50,
+			fv:        50,
 			timestamp: testTime,
 		},
 		{
@@ -142,8 +137,7 @@ func Test_DecodeMetric(t *testing.T) {
 			line:      `cpu-foo-bar 50 ` + strTime,
 			name:      "cpu",
 			tags:      map[string]string{"foo": "bar"},
-			isInt:     true,
-			iv:        50,
+			fv:        50,
 			timestamp: testTime,
 		},
 		{
@@ -152,8 +146,7 @@ func Test_DecodeMetric(t *testing.T) {
 			line:      `cpuboofooboobar 50 ` + strTime,
 			name:      "cpu",
 			tags:      map[string]string{"foo": "bar"},
-			isInt:     true,
-			iv:        50,
+			fv:        50,
 			timestamp: testTime,
 		},
 
@@ -162,15 +155,13 @@ func Test_DecodeMetric(t *testing.T) {
 			line:      `cpu.foo.bar 50 ` + strTime,
 			name:      "cpu",
 			tags:      map[string]string{"foo": "bar"},
-			isInt:     true,
-			iv:        50,
+			fv:        50,
 			timestamp: testTime,
 		},
 		{
 			test:      "metric only with float value",
 			line:      `cpu 50.554 ` + strTime,
 			name:      "cpu",
-			isInt:     false,
 			fv:        50.554,
 			timestamp: testTime,
 		},
@@ -224,16 +215,8 @@ func Test_DecodeMetric(t *testing.T) {
 		if len(point.Tags) != len(test.tags) {
 			t.Fatalf("tags len mismatch.  expected %d, got %d", len(test.tags), len(point.Tags))
 		}
-		if test.isInt {
-			i := point.Values[point.Name].(int64)
-			if i != test.iv {
-				t.Fatalf("integerValue value mismatch.  expected %v, got %v", test.iv, point.Values[point.Name])
-			}
-		} else {
-			f := point.Values[point.Name].(float64)
-			if point.Values[point.Name] != f {
-				t.Fatalf("floatValue value mismatch.  expected %v, got %v", test.fv, f)
-			}
+		if f := point.Values[point.Name].(float64); f != test.fv {
+			t.Fatalf("floatValue value mismatch.  expected %v, got %v", test.fv, f)
 		}
 		if point.Timestamp.UnixNano()/1000000 != test.timestamp.UnixNano()/1000000 {
 			t.Fatalf("timestamp value mismatch.  expected %v, got %v", test.timestamp.UnixNano(), point.Timestamp.UnixNano())
@@ -241,6 +224,271 @@ func Test_DecodeMetric(t *testing.T) {
 	}
 }
 
+func Test_DecodeMetric_Templates(t *testing.T) {
+	var tests = []struct {
+		test      string
+		templates []string
+		tags      map[string]string
+		line      string
+		name      string
+		field     string
+		ptags     map[string]string
+		err       string
+	}{
+		{
+			test:      "measurement and tags",
+			templates: []string{"host.measurement"},
+			line:      `server01.cpu 50 1419972457825`,
+			name:      "cpu",
+			field:     "cpu",
+			ptags:     map[string]string{"host": "server01"},
+		},
+		{
+			test:      "measurement and field wildcard",
+			templates: []string{"host.measurement.field*"},
+			line:      `server01.cpu.load.shortterm 1.5 1419972457825`,
+			name:      "cpu",
+			field:     "load.shortterm",
+			ptags:     map[string]string{"host": "server01"},
+		},
+		{
+			test:      "measurement wildcard with ignored segment",
+			templates: []string{".host.measurement*"},
+			line:      `servers.server01.cpu.load 1 1419972457825`,
+			name:      "cpu.load",
+			field:     "cpu.load",
+			ptags:     map[string]string{"host": "server01"},
+		},
+		{
+			test:      "longest filter wins",
+			templates: []string{"measurement*", "servers.* .host.measurement", "servers.*.cpu .host.measurement.field region=us-east"},
+			line:      `servers.server01.cpu.idle 99 1419972457825`,
+			name:      "cpu",
+			field:     "idle",
+			ptags:     map[string]string{"host": "server01", "region": "us-east"},
+		},
+		{
+			test:      "default template",
+			templates: []string{"stats.* .measurement", "measurement*"},
+			line:      `requests.count 10 1419972457825`,
+			name:      "requests.count",
+			field:     "requests.count",
+			ptags:     map[string]string{},
+		},
+		{
+			test:      "no matching template",
+			templates: []string{"stats.* .measurement"},
+			line:      `cpu.host.server01 10 1419972457825`,
+			name:      "cpu",
+			field:     "cpu",
+			ptags:     map[string]string{"host": "server01"},
+		},
+		{
+			test:      "default tags",
+			templates: []string{"host.measurement region=us-west"},
+			tags:      map[string]string{"region": "us-east", "dc": "1", "host": "default"},
+			line:      `server01.cpu 50 1419972457825`,
+			name:      "cpu",
+			field:     "cpu",
+			ptags:     map[string]string{"host": "server01", "region": "us-west", "dc": "1"},
+		},
+		{
+			test:      "missing measurement",
+			templates: []string{"host.measurement"},
+			line:      `server01 50 1419972457825`,
+			err:       `no measurement specified for metric. "server01"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Logf("testing %q...", test.test)
+
+		p := graphite.NewParser()
+		p.Tags = test.tags
+		for _, s := range test.templates {
+			if err := p.AddTemplate(s); err != nil {
+				t.Fatalf("unexpected template error: %s", err)
+			}
+		}
+
+		point, err := p.Parse(test.line)
+		if errstr(err) != test.err {
+			t.Fatalf("err does not match.  expected %v, got %v", test.err, err)
+		}
+		if err != nil {
+			continue
+		}
+		if point.Name != test.name {
+			t.Fatalf("name parse failer.  expected %v, got %v", test.name, point.Name)
+		}
+		if _, ok := point.Values[test.field]; !ok || len(point.Values) != 1 {
+			t.Fatalf("field mismatch.  expected %v, got %v", test.field, point.Values)
+		}
+		if !reflect.DeepEqual(point.Tags, test.ptags) {
+			t.Fatalf("tags mismatch.  expected %v, got %v", test.ptags, point.Tags)
+		}
+	}
+}
+
+func TestParser_AddTemplate_Invalid(t *testing.T) {
+	var tests = []struct {
+		template string
+		err      string
+	}{
+		{template: "host.field", err: `invalid template: "host.field": no measurement specified`},
+		{template: "measurement*.host", err: `invalid template: "measurement*.host": measurement* must be the last element`},
+		{template: "measurement.field*.host", err: `invalid template: "measurement.field*.host": field* must be the last element`},
+		{template: "measurement region", err: `invalid template: "measurement region": no measurement specified`},
+		{template: "a b c d", err: `invalid template: "a b c d"`},
+		{template: "measurement =west", err: `invalid template tag: "=west"`},
+	}
+
+	for i, tt := range tests {
+		if err := graphite.NewParser().AddTemplate(tt.template); errstr(err) != tt.err {
+			t.Errorf("%d. %s: error mismatch: expected %v, got %v", i, tt.template, tt.err, err)
+		}
+	}
+}
+
+func TestParser_DecodePickle(t *testing.T) {
+	var tests = []struct {
+		test   string
+		data   string
+		points []influxdb.Point
+		err    string
+	}{
+		{
+			test: "protocol 0",
+			data: "(lp0\n(Vservers.host01.cpu\np1\n(I1419972457\nI50\ntp2\ntp3\na(Vservers.host01.load\np4\n(F1419972457.5\nF1.25\ntp5\ntp6\na.",
+			points: []influxdb.Point{
+				{Name: "cpu", Tags: map[string]string{"host": "host01"}, Values: map[string]interface{}{"cpu": float64(50)}, Timestamp: time.Unix(1419972457, 0)},
+				{Name: "load", Tags: map[string]string{"host": "host01"}, Values: map[string]interface{}{"load": 1.25}, Timestamp: time.Unix(1419972457, int64(500*time.Millisecond))},
+			},
+		},
+		{
+			test: "protocol 1",
+			data: "]q\x00((X\x12\x00\x00\x00servers.host01.cpuq\x01(Ji\x0f\xa3TK2tq\x02tq\x03(X\x13\x00\x00\x00servers.host01.loadq\x04(GA\xd5(\xc3\xda`\x00\x00G?\xf4\x00\x00\x00\x00\x00\x00tq\x05tq\x06e.",
+			points: []influxdb.Point{
+				{Name: "cpu", Tags: map[string]string{"host": "host01"}, Values: map[string]interface{}{"cpu": float64(50)}, Timestamp: time.Unix(1419972457, 0)},
+				{Name: "load", Tags: map[string]string{"host": "host01"}, Values: map[string]interface{}{"load": 1.25}, Timestamp: time.Unix(1419972457, int64(500*time.Millisecond))},
+			},
+		},
+		{
+			test: "protocol 2",
+			data: "\x80\x02]q\x00(X\x12\x00\x00\x00servers.host01.cpuq\x01Ji\x0f\xa3TK2\x86q\x02\x86q\x03X\x13\x00\x00\x00servers.host01.loadq\x04GA\xd5(\xc3\xda`\x00\x00G?\xf4\x00\x00\x00\x00\x00\x00\x86q\x05\x86q\x06e.",
+			points: []influxdb.Point{
+				{Name: "cpu", Tags: map[string]string{"host": "host01"}, Values: map[string]interface{}{"cpu": float64(50)}, Timestamp: time.Unix(1419972457, 0)},
+				{Name: "load", Tags: map[string]string{"host": "host01"}, Values: map[string]interface{}{"load": 1.25}, Timestamp: time.Unix(1419972457, int64(500*time.Millisecond))},
+			},
+		},
+		{
+			test: "quoted strings and longs",
+			data: "(lp0\n(S'servers.host01.cpu'\np1\n(L1419972457L\nS'3.5'\ntp2\ntp3\na.",
+			points: []influxdb.Point{
+				{Name: "cpu", Tags: map[string]string{"host": "host01"}, Values: map[string]interface{}{"cpu": 3.5}, Timestamp: time.Unix(1419972457, 0)},
+			},
+		},
+		{
+			test: "large long value",
+			data: "\x80\x02]q\x00X\x12\x00\x00\x00servers.host01.cpuq\x01Ji\x0f\xa3T\x8a\t\x00\x00\x00\x00\x00\x00\x00\x00@\x86q\x02\x86q\x03a.",
+			points: []influxdb.Point{
+				{Name: "cpu", Tags: map[string]string{"host": "host01"}, Values: map[string]interface{}{"cpu": float64(1 << 70)}, Timestamp: time.Unix(1419972457, 0)},
+			},
+		},
+		{
+			test: "not a list",
+			data: "\x80\x02K\x01.",
+			err:  "pickle: expected list of metrics, got int64",
+		},
+		{
+			test: "truncated",
+			data: "\x80\x02]q\x00X\x12\x00\x00\x00serv",
+			err:  "pickle: unexpected EOF",
+		},
+	}
+
+	for _, test := range tests {
+		t.Logf("testing %q...", test.test)
+
+		p := graphite.NewParser()
+		if err := p.AddTemplate("servers.* .host.measurement"); err != nil {
+			t.Fatal(err)
+		}
+
+		points, err := p.DecodePickle([]byte(test.data))
+		if errstr(err) != test.err {
+			t.Fatalf("err does not match.  expected %v, got %v", test.err, err)
+		}
+		if !reflect.DeepEqual(points, test.points) {
+			t.Fatalf("points mismatch.\nexp=%#v\ngot=%#v", test.points, points)
+		}
+	}
+}
+
+// Ensure the pickle server decodes and writes each message it receives.
+func TestPickleServer(t *testing.T) {
+	// Find a free port.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	w := &SeriesWriter{ch: make(chan []influxdb.Point, 1)}
+	s := graphite.NewPickleServer(graphite.NewParser(), w)
+	s.Database = "db"
+	if err := s.ListenAndServe(addr); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Send a length-prefixed protocol 2 pickle.
+	data := []byte("\x80\x02]q\x00X\x03\x00\x00\x00cpuq\x01Ji\x0f\xa3TK2\x86q\x02\x86q\x03a.")
+	if err := binary.Write(conn, binary.BigEndian, uint32(len(data))); err != nil {
+		t.Fatal(err)
+	} else if _, err := conn.Write(data); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case points := <-w.ch:
+		if len(points) != 1 || points[0].Name != "cpu" || points[0].Values["cpu"] != float64(50) {
+			t.Fatalf("unexpected points: %#v", points)
+		} else if w.database != "db" {
+			t.Fatalf("unexpected database: %s", w.database)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for points")
+	}
+
+	// Ensure the server closes its connections and can only be closed once.
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	} else if err := s.Close(); err != graphite.ErrServerClosed {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Fatal("expected connection to be closed")
+	}
+}
+
+// SeriesWriter is a test implementation of graphite.SeriesWriter.
+type SeriesWriter struct {
+	database string
+	ch       chan []influxdb.Point
+}
+
+func (w *SeriesWriter) WriteSeries(database, retentionPolicy string, points []influxdb.Point) (uint64, error) {
+	w.database = database
+	w.ch <- points
+	return 0, nil
+}
+
 // Test Helpers
 func errstr(err error) string {
 	if err != nil {
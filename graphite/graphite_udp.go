@@ -3,6 +3,8 @@ package graphite
 import (
 	"net"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/influxdb/influxdb"
 )
@@ -13,21 +15,40 @@ const (
 
 // UDPerver processes Graphite data received via UDP.
 type UDPServer struct {
-	writer SeriesWriter
-	parser *Parser
+	writer  SeriesWriter
+	parser  *Parser
+	batcher *influxdb.PointBatcher
+
+	mu   sync.Mutex
+	wg   sync.WaitGroup
+	conn *net.UDPConn
 
 	Database string
+
+	// Points are dropped while the batch queue is full.
+	BatchSize    int
+	BatchTimeout time.Duration
 }
 
 // NewUDPServer returns a new instance of a UDPServer
 func NewUDPServer(p *Parser, w SeriesWriter) *UDPServer {
 	u := UDPServer{
-		parser: p,
-		writer: w,
+		parser:       p,
+		writer:       w,
+		BatchSize:    influxdb.DefaultPointBatchSize,
+		BatchTimeout: influxdb.DefaultPointBatchTimeout,
 	}
 	return &u
 }
 
+// Stats returns the counters of the server's point batcher.
+func (u *UDPServer) Stats() influxdb.PointBatcherStats {
+	if u.batcher == nil {
+		return influxdb.PointBatcherStats{}
+	}
+	return u.batcher.Stats()
+}
+
 // ListenAndServer instructs the UDPServer to start processing Graphite data
 // on the given interface. iface must be in the form host:port.
 func (u *UDPServer) ListenAndServe(iface string) error {
@@ -47,8 +68,16 @@ func (u *UDPServer) ListenAndServe(iface string) error {
 		return err
 	}
 
+	u.mu.Lock()
+	u.conn = conn
+	u.mu.Unlock()
+
+	u.batcher = influxdb.StartPointBatcher(u.writer, u.Database, "", u.BatchSize, u.BatchTimeout, &u.wg)
+
 	buf := make([]byte, udpBufferSize)
+	u.wg.Add(1)
 	go func() {
+		defer u.wg.Done()
 		for {
 			n, _, err := conn.ReadFromUDP(buf)
 			if err != nil {
@@ -60,10 +89,28 @@ func (u *UDPServer) ListenAndServe(iface string) error {
 					continue
 				}
 
-				// Send the data to database
-				u.writer.WriteSeries(u.Database, "", []influxdb.Point{point})
+				// Queue the data to be written to the database.
+				u.batcher.TryWrite(point)
 			}
 		}
 	}()
 	return nil
 }
+
+// Close stops the listener. Queued points are written before Close returns.
+func (u *UDPServer) Close() error {
+	u.mu.Lock()
+	if u.conn == nil {
+		u.mu.Unlock()
+		return ErrServerClosed
+	}
+	_ = u.conn.Close()
+	u.conn = nil
+	u.mu.Unlock()
+
+	// Write any queued points and wait for all goroutines to finish.
+	u.batcher.Stop()
+	u.wg.Wait()
+
+	return nil
+}
@@ -0,0 +1,478 @@
+package graphite
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdb/influxdb"
+)
+
+// Pickle opcodes used by carbon and its relays. Only the subset of the
+// pickle protocol needed to represent lists of metric tuples is supported.
+const (
+	pickleMark            = '('
+	pickleStop            = '.'
+	pickleInt             = 'I'
+	pickleBinInt          = 'J'
+	pickleBinInt1         = 'K'
+	pickleBinInt2         = 'M'
+	pickleLong            = 'L'
+	pickleNone            = 'N'
+	pickleFloat           = 'F'
+	pickleBinFloat        = 'G'
+	pickleString          = 'S'
+	pickleBinString       = 'T'
+	pickleShortBinString  = 'U'
+	pickleUnicode         = 'V'
+	pickleBinUnicode      = 'X'
+	pickleAppend          = 'a'
+	pickleAppends         = 'e'
+	pickleGet             = 'g'
+	pickleBinGet          = 'h'
+	pickleLongBinGet      = 'j'
+	pickleList            = 'l'
+	pickleEmptyList       = ']'
+	picklePut             = 'p'
+	pickleBinPut          = 'q'
+	pickleLongBinPut      = 'r'
+	pickleTuple           = 't'
+	pickleEmptyTuple      = ')'
+	pickleProto           = '\x80'
+	pickleTuple1          = '\x85'
+	pickleTuple2          = '\x86'
+	pickleTuple3          = '\x87'
+	pickleNewTrue         = '\x88'
+	pickleNewFalse        = '\x89'
+	pickleLong1           = '\x8a'
+	pickleBinUnicode8     = '\x8d'
+	pickleShortBinUnicode = '\x8c'
+	pickleMemoize         = '\x94'
+	pickleFrame           = '\x95'
+	pickleBinBytes        = 'B'
+	pickleShortBinBytes   = 'C'
+)
+
+// pickleMarker is pushed onto the stack by the MARK opcode.
+type pickleMarker struct{}
+
+// unpickle decodes a single pickled value from b.
+// Lists and tuples are returned as []interface{}, integers as int64,
+// floats as float64 and strings, unicode and bytes as string.
+func unpickle(b []byte) (interface{}, error) {
+	r := bufio.NewReader(bytes.NewReader(b))
+	var stack []interface{}
+	memo := make(map[int]interface{})
+
+	// pop removes the top value from the stack.
+	pop := func() (interface{}, error) {
+		if len(stack) == 0 {
+			return nil, errors.New("pickle: stack underflow")
+		}
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v, nil
+	}
+
+	// popMark removes all values up to the last mark from the stack.
+	popMark := func() ([]interface{}, error) {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if _, ok := stack[i].(pickleMarker); ok {
+				items := append([]interface{}{}, stack[i+1:]...)
+				stack = stack[:i]
+				return items, nil
+			}
+		}
+		return nil, errors.New("pickle: mark not found")
+	}
+
+	// popTuple removes the last n values from the stack.
+	popTuple := func(n int) ([]interface{}, error) {
+		if len(stack) < n {
+			return nil, errors.New("pickle: stack underflow")
+		}
+		items := append([]interface{}{}, stack[len(stack)-n:]...)
+		stack = stack[:len(stack)-n]
+		return items, nil
+	}
+
+	// appendList appends items to the list at the top of the stack.
+	appendList := func(items ...interface{}) error {
+		if len(stack) == 0 {
+			return errors.New("pickle: stack underflow")
+		}
+		l, ok := stack[len(stack)-1].([]interface{})
+		if !ok {
+			return fmt.Errorf("pickle: cannot append to %T", stack[len(stack)-1])
+		}
+		stack[len(stack)-1] = append(l, items...)
+		return nil
+	}
+
+	for {
+		op, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("pickle: %s", err)
+		}
+
+		switch op {
+		case pickleStop:
+			return pop()
+		case pickleProto:
+			if _, err := r.ReadByte(); err != nil {
+				return nil, err
+			}
+		case pickleFrame:
+			if _, err := readPickleBytes(r, 8); err != nil {
+				return nil, err
+			}
+		case pickleMark:
+			stack = append(stack, pickleMarker{})
+		case pickleNone:
+			stack = append(stack, nil)
+		case pickleNewTrue:
+			stack = append(stack, true)
+		case pickleNewFalse:
+			stack = append(stack, false)
+
+		case pickleInt:
+			line, err := readPickleLine(r)
+			if err != nil {
+				return nil, err
+			}
+			switch line {
+			case "00":
+				stack = append(stack, false)
+			case "01":
+				stack = append(stack, true)
+			default:
+				i, err := strconv.ParseInt(line, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("pickle: invalid int: %s", line)
+				}
+				stack = append(stack, i)
+			}
+		case pickleLong:
+			line, err := readPickleLine(r)
+			if err != nil {
+				return nil, err
+			}
+			i, ok := new(big.Int).SetString(strings.TrimSuffix(line, "L"), 10)
+			if !ok {
+				return nil, fmt.Errorf("pickle: invalid long: %s", line)
+			}
+			stack = append(stack, bigIntValue(i))
+		case pickleBinInt:
+			buf, err := readPickleBytes(r, 4)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, int64(int32(binary.LittleEndian.Uint32(buf))))
+		case pickleBinInt1:
+			buf, err := readPickleBytes(r, 1)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, int64(buf[0]))
+		case pickleBinInt2:
+			buf, err := readPickleBytes(r, 2)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, int64(binary.LittleEndian.Uint16(buf)))
+		case pickleLong1:
+			n, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			buf, err := readPickleBytes(r, int(n))
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, bigIntValue(decodePickleLong(buf)))
+
+		case pickleFloat:
+			line, err := readPickleLine(r)
+			if err != nil {
+				return nil, err
+			}
+			f, err := strconv.ParseFloat(line, 64)
+			if err != nil {
+				return nil, fmt.Errorf("pickle: invalid float: %s", line)
+			}
+			stack = append(stack, f)
+		case pickleBinFloat:
+			buf, err := readPickleBytes(r, 8)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, math.Float64frombits(binary.BigEndian.Uint64(buf)))
+
+		case pickleString:
+			line, err := readPickleLine(r)
+			if err != nil {
+				return nil, err
+			}
+			s, err := unquotePickleString(line)
+			if err != nil {
+				return nil, fmt.Errorf("pickle: invalid string: %s", line)
+			}
+			stack = append(stack, s)
+		case pickleUnicode:
+			line, err := readPickleLine(r)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, line)
+		case pickleShortBinString, pickleShortBinBytes, pickleShortBinUnicode:
+			n, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			buf, err := readPickleBytes(r, int(n))
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, string(buf))
+		case pickleBinString, pickleBinUnicode, pickleBinBytes:
+			buf, err := readPickleBytes(r, 4)
+			if err != nil {
+				return nil, err
+			}
+			if buf, err = readPickleBytes(r, int(binary.LittleEndian.Uint32(buf))); err != nil {
+				return nil, err
+			}
+			stack = append(stack, string(buf))
+		case pickleBinUnicode8:
+			buf, err := readPickleBytes(r, 8)
+			if err != nil {
+				return nil, err
+			}
+			if buf, err = readPickleBytes(r, int(binary.LittleEndian.Uint64(buf))); err != nil {
+				return nil, err
+			}
+			stack = append(stack, string(buf))
+
+		case pickleEmptyList, pickleEmptyTuple:
+			stack = append(stack, []interface{}{})
+		case pickleList, pickleTuple:
+			items, err := popMark()
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, items)
+		case pickleTuple1, pickleTuple2, pickleTuple3:
+			items, err := popTuple(int(op-pickleTuple1) + 1)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, items)
+		case pickleAppend:
+			v, err := pop()
+			if err != nil {
+				return nil, err
+			}
+			if err := appendList(v); err != nil {
+				return nil, err
+			}
+		case pickleAppends:
+			items, err := popMark()
+			if err != nil {
+				return nil, err
+			}
+			if err := appendList(items...); err != nil {
+				return nil, err
+			}
+
+		case picklePut, pickleBinPut, pickleLongBinPut, pickleMemoize:
+			if len(stack) == 0 {
+				return nil, errors.New("pickle: stack underflow")
+			}
+			var key int
+			switch op {
+			case picklePut:
+				line, err := readPickleLine(r)
+				if err != nil {
+					return nil, err
+				}
+				if key, err = strconv.Atoi(line); err != nil {
+					return nil, fmt.Errorf("pickle: invalid memo key: %s", line)
+				}
+			case pickleBinPut:
+				buf, err := readPickleBytes(r, 1)
+				if err != nil {
+					return nil, err
+				}
+				key = int(buf[0])
+			case pickleLongBinPut:
+				buf, err := readPickleBytes(r, 4)
+				if err != nil {
+					return nil, err
+				}
+				key = int(binary.LittleEndian.Uint32(buf))
+			case pickleMemoize:
+				key = len(memo)
+			}
+			memo[key] = stack[len(stack)-1]
+		case pickleGet, pickleBinGet, pickleLongBinGet:
+			var key int
+			switch op {
+			case pickleGet:
+				line, err := readPickleLine(r)
+				if err != nil {
+					return nil, err
+				}
+				if key, err = strconv.Atoi(line); err != nil {
+					return nil, fmt.Errorf("pickle: invalid memo key: %s", line)
+				}
+			case pickleBinGet:
+				buf, err := readPickleBytes(r, 1)
+				if err != nil {
+					return nil, err
+				}
+				key = int(buf[0])
+			case pickleLongBinGet:
+				buf, err := readPickleBytes(r, 4)
+				if err != nil {
+					return nil, err
+				}
+				key = int(binary.LittleEndian.Uint32(buf))
+			}
+			v, ok := memo[key]
+			if !ok {
+				return nil, fmt.Errorf("pickle: memo key not found: %d", key)
+			}
+			stack = append(stack, v)
+
+		default:
+			return nil, fmt.Errorf("pickle: unsupported opcode: %#x", op)
+		}
+	}
+}
+
+// readPickleLine reads a newline terminated argument.
+func readPickleLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("pickle: %s", err)
+	}
+	return strings.TrimSuffix(line, "\n"), nil
+}
+
+// unquotePickleString decodes a string argument quoted by Python's repr().
+func unquotePickleString(s string) (string, error) {
+	if len(s) < 2 || s[0] != s[len(s)-1] || (s[0] != '\'' && s[0] != '"') {
+		return "", errors.New("unquoted string")
+	}
+
+	// Single quoted strings contain unescaped double quotes.
+	if s[0] == '\'' {
+		s = strings.Replace(s[1:len(s)-1], `\'`, `'`, -1)
+		s = `"` + strings.Replace(s, `"`, `\"`, -1) + `"`
+	}
+	return strconv.Unquote(s)
+}
+
+// readPickleBytes reads a fixed length argument.
+func readPickleBytes(r *bufio.Reader, n int) ([]byte, error) {
+	if n < 0 || n > maxPickleSize {
+		return nil, fmt.Errorf("pickle: invalid length: %d", n)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("pickle: %s", err)
+	}
+	return buf, nil
+}
+
+// decodePickleLong decodes a little-endian two's complement integer.
+func decodePickleLong(b []byte) *big.Int {
+	// Reverse into big-endian order.
+	be := make([]byte, len(b))
+	for i := range b {
+		be[len(b)-1-i] = b[i]
+	}
+
+	i := new(big.Int).SetBytes(be)
+	if len(b) > 0 && b[len(b)-1]&0x80 != 0 {
+		i.Sub(i, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+	return i
+}
+
+// bigIntValue returns i as an int64 if it fits, otherwise as a float64.
+func bigIntValue(i *big.Int) interface{} {
+	if i.BitLen() < 64 {
+		return i.Int64()
+	}
+	f, _ := new(big.Float).SetInt(i).Float64()
+	return f
+}
+
+// DecodePickle decodes a pickled list of (path, (timestamp, value)) tuples
+// into points. Timestamps are in seconds since the epoch.
+func (p *Parser) DecodePickle(b []byte) ([]influxdb.Point, error) {
+	v, err := unpickle(b)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("pickle: expected list of metrics, got %T", v)
+	}
+
+	points := make([]influxdb.Point, 0, len(metrics))
+	for _, m := range metrics {
+		// Each metric is a (path, (timestamp, value)) tuple.
+		tuple, ok := m.([]interface{})
+		if !ok || len(tuple) != 2 {
+			return nil, fmt.Errorf("pickle: invalid metric: %v", m)
+		}
+		path, ok := tuple[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("pickle: invalid metric path: %v", tuple[0])
+		}
+		datapoint, ok := tuple[1].([]interface{})
+		if !ok || len(datapoint) != 2 {
+			return nil, fmt.Errorf("pickle: invalid datapoint for %s: %v", path, tuple[1])
+		}
+		timestamp, err := pickleFloat64(datapoint[0])
+		if err != nil {
+			return nil, fmt.Errorf("pickle: invalid timestamp for %s: %s", path, err)
+		}
+		value, err := pickleFloat64(datapoint[1])
+		if err != nil {
+			return nil, fmt.Errorf("pickle: invalid value for %s: %s", path, err)
+		}
+
+		sec, frac := math.Modf(timestamp)
+		point, err := p.newPoint(path, value, time.Unix(int64(sec), int64(frac*float64(time.Second))))
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// pickleFloat64 converts a decoded numeric or string value to a float64.
+func pickleFloat64(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("unexpected type %T", v)
+}
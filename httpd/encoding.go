@@ -0,0 +1,426 @@
+package httpd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdb/influxdb"
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// resultsEncoder writes query results to a response in a specific format.
+type resultsEncoder interface {
+	// ContentType returns the media type of the encoded results.
+	ContentType() string
+
+	// Encode writes results to w. Encode may be called more than once
+	// on the same response when results are streamed.
+	Encode(w io.Writer, results influxdb.Results) error
+}
+
+// newResultsEncoder returns an encoder for the first supported media type in
+// the request's Accept header. JSON is used if no supported type is listed.
+func newResultsEncoder(r *http.Request, pretty bool) resultsEncoder {
+	for _, s := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediatype, _, err := mime.ParseMediaType(strings.TrimSpace(s))
+		if err != nil {
+			continue
+		}
+
+		switch mediatype {
+		case "application/json", "*/*":
+			return &jsonResultsEncoder{pretty: pretty}
+		case "text/csv":
+			return &csvResultsEncoder{}
+		case "application/x-msgpack":
+			return &msgpackResultsEncoder{}
+		}
+	}
+	return &jsonResultsEncoder{pretty: pretty}
+}
+
+// statementID returns the statement id of the result at index i.
+// Streamed results carry their statement id; otherwise the id is the
+// position of the result within the results.
+func statementID(i int, r *influxdb.Result) int {
+	if r.StatementID != 0 {
+		return r.StatementID
+	}
+	return i
+}
+
+// jsonResultsEncoder encodes results as JSON objects separated by newlines.
+type jsonResultsEncoder struct {
+	pretty bool
+}
+
+func (enc *jsonResultsEncoder) ContentType() string { return "application/json" }
+
+func (enc *jsonResultsEncoder) Encode(w io.Writer, results influxdb.Results) error {
+	var b []byte
+	if enc.pretty {
+		b, _ = json.MarshalIndent(results, "", "    ")
+	} else {
+		b, _ = json.Marshal(results)
+	}
+	_, err := w.Write(append(b, '\n'))
+	return err
+}
+
+// csvResultsEncoder encodes results as CSV records.
+//
+// Each record of a row begins with the statement id and the measurement
+// name, followed by one column per tag and then the row's columns. A
+// header record is written whenever the columns change, including across
+// calls to Encode. Errors are written as a header of "statement_id,error"
+// followed by the error message, or "error" alone for errors that do not
+// belong to a statement.
+type csvResultsEncoder struct {
+	header []string
+}
+
+func (enc *csvResultsEncoder) ContentType() string { return "text/csv" }
+
+func (enc *csvResultsEncoder) Encode(w io.Writer, results influxdb.Results) error {
+	cw := csv.NewWriter(w)
+
+	for i, res := range results.Results {
+		id := strconv.Itoa(statementID(i, res))
+
+		if res.Err != nil {
+			enc.writeHeader(cw, []string{"statement_id", "error"})
+			cw.Write([]string{id, res.Err.Error()})
+			continue
+		}
+
+		for _, row := range res.Rows {
+			// Tags are written in sorted order of their keys.
+			keys := make([]string, 0, len(row.Tags))
+			for k := range row.Tags {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			header := []string{"statement_id", "name"}
+			header = append(header, keys...)
+			enc.writeHeader(cw, append(header, row.Columns...))
+
+			for _, values := range row.Values {
+				record := []string{id, row.Name}
+				for _, k := range keys {
+					record = append(record, row.Tags[k])
+				}
+				for _, v := range values {
+					record = append(record, csvValue(v))
+				}
+				cw.Write(record)
+			}
+		}
+	}
+
+	if results.Err != nil {
+		enc.writeHeader(cw, []string{"error"})
+		cw.Write([]string{results.Err.Error()})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeHeader writes a header record if it differs from the previous one.
+func (enc *csvResultsEncoder) writeHeader(cw *csv.Writer, header []string) {
+	if stringsEqual(enc.header, header) {
+		return
+	}
+	cw.Write(header)
+	enc.header = header
+}
+
+// csvValue returns the string representation of a row value.
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// stringsEqual returns true if a and b contain the same strings in the same order.
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// msgpackResultsEncoder encodes results as MessagePack. The encoded
+// structure mirrors the JSON representation: a map with "results" and
+// "error" keys where each result is a map with "statement_id", "rows",
+// "partial" and "error" keys. Empty keys are omitted as they are in JSON.
+// Times are encoded as RFC3339 strings. Streamed results are written as
+// a sequence of MessagePack objects.
+type msgpackResultsEncoder struct{}
+
+func (enc *msgpackResultsEncoder) ContentType() string { return "application/x-msgpack" }
+
+func (enc *msgpackResultsEncoder) Encode(w io.Writer, results influxdb.Results) error {
+	var buf bytes.Buffer
+	e := msgpackEncoder{&buf}
+
+	e.writeMapHeader(countNonEmpty(len(results.Results) > 0, results.Err != nil))
+	if len(results.Results) > 0 {
+		e.writeString("results")
+		e.writeArrayHeader(len(results.Results))
+		for _, res := range results.Results {
+			e.writeResult(res)
+		}
+	}
+	if results.Err != nil {
+		e.writeString("error")
+		e.writeString(results.Err.Error())
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// msgpackEncoder writes MessagePack encoded values to a buffer.
+type msgpackEncoder struct {
+	buf *bytes.Buffer
+}
+
+// writeResult writes a statement result as a map.
+func (e msgpackEncoder) writeResult(r *influxdb.Result) {
+	e.writeMapHeader(countNonEmpty(r.StatementID != 0, len(r.Rows) > 0, r.Partial, r.Err != nil))
+	if r.StatementID != 0 {
+		e.writeString("statement_id")
+		e.writeInt(int64(r.StatementID))
+	}
+	if len(r.Rows) > 0 {
+		e.writeString("rows")
+		e.writeArrayHeader(len(r.Rows))
+		for _, row := range r.Rows {
+			e.writeRow(row)
+		}
+	}
+	if r.Partial {
+		e.writeString("partial")
+		e.writeValue(true)
+	}
+	if r.Err != nil {
+		e.writeString("error")
+		e.writeString(r.Err.Error())
+	}
+}
+
+// writeRow writes a row as a map.
+func (e msgpackEncoder) writeRow(row *influxql.Row) {
+	e.writeMapHeader(1 + countNonEmpty(row.Name != "", len(row.Tags) > 0, len(row.Values) > 0))
+	if row.Name != "" {
+		e.writeString("name")
+		e.writeString(row.Name)
+	}
+	if len(row.Tags) > 0 {
+		e.writeString("tags")
+		e.writeValue(row.Tags)
+	}
+	e.writeString("columns")
+	e.writeValue(row.Columns)
+	if len(row.Values) > 0 {
+		e.writeString("values")
+		e.writeArrayHeader(len(row.Values))
+		for _, values := range row.Values {
+			e.writeValue(values)
+		}
+	}
+}
+
+// writeValue writes a value. Values of unsupported types are written as
+// their string representation.
+func (e msgpackEncoder) writeValue(v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		e.buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			e.buf.WriteByte(0xc3)
+		} else {
+			e.buf.WriteByte(0xc2)
+		}
+	case int:
+		e.writeInt(int64(v))
+	case int32:
+		e.writeInt(int64(v))
+	case int64:
+		e.writeInt(v)
+	case uint32:
+		e.writeUint(uint64(v))
+	case uint64:
+		e.writeUint(v)
+	case float32:
+		e.writeFloat(float64(v))
+	case float64:
+		e.writeFloat(v)
+	case string:
+		e.writeString(v)
+	case time.Time:
+		e.writeString(v.UTC().Format(time.RFC3339Nano))
+	case []string:
+		e.writeArrayHeader(len(v))
+		for _, s := range v {
+			e.writeString(s)
+		}
+	case []interface{}:
+		e.writeArrayHeader(len(v))
+		for _, vv := range v {
+			e.writeValue(vv)
+		}
+	case map[string]string:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		e.writeMapHeader(len(keys))
+		for _, k := range keys {
+			e.writeString(k)
+			e.writeString(v[k])
+		}
+	default:
+		e.writeString(fmt.Sprintf("%v", v))
+	}
+}
+
+// writeInt writes a signed integer in its smallest encoding.
+func (e msgpackEncoder) writeInt(i int64) {
+	switch {
+	case i >= 0:
+		e.writeUint(uint64(i))
+	case i >= -32:
+		e.buf.WriteByte(byte(i))
+	case i >= math.MinInt8:
+		e.buf.Write([]byte{0xd0, byte(i)})
+	case i >= math.MinInt16:
+		e.writeUint16(0xd1, uint16(i))
+	case i >= math.MinInt32:
+		e.writeUint32(0xd2, uint32(i))
+	default:
+		e.writeUint64(0xd3, uint64(i))
+	}
+}
+
+// writeUint writes an unsigned integer in its smallest encoding.
+func (e msgpackEncoder) writeUint(u uint64) {
+	switch {
+	case u < 128:
+		e.buf.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		e.buf.Write([]byte{0xcc, byte(u)})
+	case u <= math.MaxUint16:
+		e.writeUint16(0xcd, uint16(u))
+	case u <= math.MaxUint32:
+		e.writeUint32(0xce, uint32(u))
+	default:
+		e.writeUint64(0xcf, u)
+	}
+}
+
+// writeFloat writes a 64-bit floating point number.
+func (e msgpackEncoder) writeFloat(f float64) {
+	e.writeUint64(0xcb, math.Float64bits(f))
+}
+
+// writeString writes a UTF-8 string.
+func (e msgpackEncoder) writeString(s string) {
+	switch n := len(s); {
+	case n < 32:
+		e.buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		e.buf.Write([]byte{0xd9, byte(n)})
+	case n <= math.MaxUint16:
+		e.writeUint16(0xda, uint16(n))
+	default:
+		e.writeUint32(0xdb, uint32(n))
+	}
+	e.buf.WriteString(s)
+}
+
+// writeArrayHeader writes the header of an array with n elements.
+func (e msgpackEncoder) writeArrayHeader(n int) {
+	switch {
+	case n < 16:
+		e.buf.WriteByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		e.writeUint16(0xdc, uint16(n))
+	default:
+		e.writeUint32(0xdd, uint32(n))
+	}
+}
+
+// writeMapHeader writes the header of a map with n key/value pairs.
+func (e msgpackEncoder) writeMapHeader(n int) {
+	switch {
+	case n < 16:
+		e.buf.WriteByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		e.writeUint16(0xde, uint16(n))
+	default:
+		e.writeUint32(0xdf, uint32(n))
+	}
+}
+
+func (e msgpackEncoder) writeUint16(code byte, v uint16) {
+	var b [3]byte
+	b[0] = code
+	binary.BigEndian.PutUint16(b[1:], v)
+	e.buf.Write(b[:])
+}
+
+func (e msgpackEncoder) writeUint32(code byte, v uint32) {
+	var b [5]byte
+	b[0] = code
+	binary.BigEndian.PutUint32(b[1:], v)
+	e.buf.Write(b[:])
+}
+
+func (e msgpackEncoder) writeUint64(code byte, v uint64) {
+	var b [9]byte
+	b[0] = code
+	binary.BigEndian.PutUint64(b[1:], v)
+	e.buf.Write(b[:])
+}
+
+// countNonEmpty returns the number of true values.
+func countNonEmpty(a ...bool) int {
+	var n int
+	for _, v := range a {
+		if v {
+			n++
+		}
+	}
+	return n
+}
@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"compress/gzip"
@@ -27,6 +29,10 @@ import (
 
 // TODO: Check HTTP response codes: 400, 401, 403, 409.
 
+// DefaultChunkSize is the default maximum number of points in each chunk of
+// a chunked query response.
+const DefaultChunkSize = 10000
+
 type route struct {
 	name        string
 	method      string
@@ -40,6 +46,9 @@ type Handler struct {
 	routes                []route
 	mux                   *pat.PatternServeMux
 	requireAuthentication bool
+
+	// The maximum number of points in each chunk of a chunked query response.
+	ChunkSize int
 }
 
 // NewHandler returns a new instance of Handler.
@@ -48,6 +57,7 @@ func NewHandler(s *influxdb.Server, requireAuthentication bool, version string)
 		server: s,
 		mux:    pat.New(),
 		requireAuthentication: requireAuthentication,
+		ChunkSize:             DefaultChunkSize,
 	}
 
 	weblog := log.New(os.Stderr, `[http] `, 0)
@@ -124,10 +134,19 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user *influ
 	db := q.Get("db")
 	pretty := q.Get("pretty") == "true"
 
+	// Determine the response format from the Accept header.
+	enc := newResultsEncoder(r, pretty)
+
 	// Parse query from query string.
 	query, err := p.ParseQuery()
 	if err != nil {
-		httpError(w, "error parsing query: "+err.Error(), pretty, http.StatusBadRequest)
+		httpResults(w, enc, influxdb.Results{Err: errors.New("error parsing query: " + err.Error())}, http.StatusBadRequest)
+		return
+	}
+
+	// Stream results to the client if requested.
+	if q.Get("chunked") == "true" {
+		h.serveQueryChunked(w, r, enc, query, db, user)
 		return
 	}
 
@@ -135,20 +154,96 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user *influ
 	results := h.server.ExecuteQuery(query, db, user)
 
 	// Send results to client.
-	httpResults(w, results, pretty)
+	code := http.StatusOK
+	if results.Error() != nil {
+		if isAuthorizationError(results.Error()) {
+			code = http.StatusUnauthorized
+		} else {
+			code = http.StatusInternalServerError
+		}
+	}
+	httpResults(w, enc, results, code)
+}
+
+// serveQueryChunked executes a query and writes each result to the client as
+// a separate object as soon as it is produced. Each object contains a
+// single result with its statement id. Results of select statements contain
+// at most chunk_size points, or the handler's ChunkSize if not specified.
+func (h *Handler) serveQueryChunked(w http.ResponseWriter, r *http.Request, enc resultsEncoder, query *influxql.Query, db string, user *influxdb.User) {
+	q := r.URL.Query()
+
+	// Determine the maximum number of points per chunk.
+	chunkSize := h.ChunkSize
+	if s := q.Get("chunk_size"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			httpResults(w, enc, influxdb.Results{Err: errors.New("invalid chunk_size: " + s)}, http.StatusBadRequest)
+			return
+		}
+		chunkSize = n
+	}
+
+	// Stop executing the query if the client goes away.
+	closing := make(chan struct{})
+	var once sync.Once
+	stop := func() { once.Do(func() { close(closing) }) }
+	if notifier, ok := w.(http.CloseNotifier); ok {
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-notifier.CloseNotify():
+				stop()
+			case <-done:
+			}
+		}()
+	}
+
+	// Execute the query.
+	ch, err := h.server.ExecuteQueryChunked(query, db, user, chunkSize, closing)
+	if err != nil {
+		if isAuthorizationError(err) {
+			httpResults(w, enc, influxdb.Results{Err: err}, http.StatusUnauthorized)
+		} else {
+			httpResults(w, enc, influxdb.Results{Err: err}, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Write each result and flush it to the client. The query is stopped if
+	// a write fails and the channel is drained until execution ends.
+	w.Header().Add("content-type", enc.ContentType())
+	w.WriteHeader(http.StatusOK)
+	var werr error
+	for res := range ch {
+		if werr != nil {
+			continue
+		}
+		if werr = enc.Encode(w, influxdb.Results{Results: []*influxdb.Result{res}}); werr != nil {
+			stop()
+			continue
+		}
+
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
 }
 
 // serveWrite receives incoming series data and writes it to the database.
 func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user *influxdb.User) {
+	// Line protocol bodies are handled separately.
+	if isLineProtocol(r) {
+		h.serveWriteLine(w, r, user)
+		return
+	}
+
 	var bp influxdb.BatchPoints
 
 	dec := json.NewDecoder(r.Body)
 
 	var writeError = func(result influxdb.Result, statusCode int) {
-		w.WriteHeader(statusCode)
-		w.Header().Add("content-type", "application/json")
-		_ = json.NewEncoder(w).Encode(&result)
-		return
+		writeResultError(w, result, statusCode)
 	}
 
 	if err := dec.Decode(&bp); err != nil {
@@ -187,6 +282,91 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user *influ
 	}
 }
 
+// writeLineBatchSize is the number of line protocol points sent in each write to the server.
+const writeLineBatchSize = 5000
+
+// serveWriteLine writes points from a line protocol body.
+// The database, retention policy and precision are passed as the "db", "rp"
+// and "precision" query parameters. The body is streamed so points are
+// written to the server in batches as they are read. Writes can be partial:
+// if a line is malformed then the points of the lines before it are written
+// and the error reports the line number and the number of points written.
+func (h *Handler) serveWriteLine(w http.ResponseWriter, r *http.Request, user *influxdb.User) {
+	q := r.URL.Query()
+	database, retentionPolicy := q.Get("db"), q.Get("rp")
+
+	if database == "" {
+		writeResultError(w, influxdb.Result{Err: fmt.Errorf("database is required")}, http.StatusInternalServerError)
+		return
+	}
+
+	if !h.server.DatabaseExists(database) {
+		writeResultError(w, influxdb.Result{Err: fmt.Errorf("database not found: %q", database)}, http.StatusNotFound)
+		return
+	}
+
+	if h.requireAuthentication && !user.Authorize(influxql.WritePrivilege, database) {
+		writeResultError(w, influxdb.Result{Err: fmt.Errorf("%q user is not authorized to write to database %q", user.Name, database)}, http.StatusUnauthorized)
+		return
+	}
+
+	// Read points and write them in batches.
+	lr := influxdb.NewLineReader(r.Body, q.Get("precision"))
+	points := make([]influxdb.Point, 0, writeLineBatchSize)
+	var n int
+	for {
+		p, err := lr.ReadPoint()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			// Write the points read before the malformed line.
+			if len(points) > 0 {
+				if _, err := h.server.WriteSeries(database, retentionPolicy, points); err != nil {
+					writeResultError(w, influxdb.Result{Err: err}, http.StatusInternalServerError)
+					return
+				}
+				n += len(points)
+			}
+			writeResultError(w, influxdb.Result{Err: fmt.Errorf("%s (%d points written)", err, n)}, http.StatusBadRequest)
+			return
+		}
+
+		if points = append(points, p); len(points) < writeLineBatchSize {
+			continue
+		}
+		if _, err := h.server.WriteSeries(database, retentionPolicy, points); err != nil {
+			writeResultError(w, influxdb.Result{Err: err}, http.StatusInternalServerError)
+			return
+		}
+		n += len(points)
+		points = points[:0]
+	}
+
+	if len(points) > 0 {
+		if _, err := h.server.WriteSeries(database, retentionPolicy, points); err != nil {
+			writeResultError(w, influxdb.Result{Err: err}, http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// isLineProtocol returns true if a write request body uses the line protocol.
+// This is selected by a "text/plain" content type or the "format=line" query parameter.
+func isLineProtocol(r *http.Request) bool {
+	if r.URL.Query().Get("format") == "line" {
+		return true
+	}
+	typ, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return typ == "text/plain"
+}
+
+// writeResultError writes an error result as JSON with the given status code.
+func writeResultError(w http.ResponseWriter, result influxdb.Result, statusCode int) {
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(&result)
+}
+
 // serveMetastore returns a copy of the metastore.
 func (h *Handler) serveMetastore(w http.ResponseWriter, r *http.Request) {
 	// Set headers.
@@ -311,23 +491,11 @@ func isAuthorizationError(err error) bool {
 	return ok
 }
 
-// httpResult writes a Results array to the client.
-func httpResults(w http.ResponseWriter, results influxdb.Results, pretty bool) {
-	if results.Error() != nil {
-		if isAuthorizationError(results.Error()) {
-			w.WriteHeader(http.StatusUnauthorized)
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-		}
-	}
-	w.Header().Add("content-type", "application/json")
-	var b []byte
-	if pretty {
-		b, _ = json.MarshalIndent(results, "", "    ")
-	} else {
-		b, _ = json.Marshal(results)
-	}
-	w.Write(b)
+// httpResults writes a Results array to the client using the given encoder.
+func httpResults(w http.ResponseWriter, enc resultsEncoder, results influxdb.Results, code int) {
+	w.Header().Add("content-type", enc.ContentType())
+	w.WriteHeader(code)
+	enc.Encode(w, results)
 }
 
 // httpError writes an error to the client in a standard format.
@@ -410,6 +578,24 @@ func (w gzipResponseWriter) Write(b []byte) (int, error) {
 	return w.Writer.Write(b)
 }
 
+// Flush writes any buffered compressed data to the client.
+func (w gzipResponseWriter) Flush() {
+	if gz, ok := w.Writer.(*gzip.Writer); ok {
+		gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// CloseNotify returns a channel that receives a value when the client goes away.
+func (w gzipResponseWriter) CloseNotify() <-chan bool {
+	if n, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return n.CloseNotify()
+	}
+	return nil
+}
+
 // determines if the client can accept compressed responses, and encodes accordingly
 func gzipFilter(inner http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -1042,6 +1042,151 @@ func TestHandler_serveWriteSeries(t *testing.T) {
 	}
 }
 
+func TestHandler_serveWriteSeries_lineProtocol(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	srvr.CreateRetentionPolicy("foo", influxdb.NewRetentionPolicy("bar"))
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	// Write points selecting the line protocol by content type.
+	status, body := MustHTTP("POST", s.URL+`/write`, map[string]string{"db": "foo", "rp": "bar", "precision": "s"}, map[string]string{"Content-Type": "text/plain; charset=utf-8"}, "cpu,host=server01 value=100 1257894000\ncpu,host=server02 value=50 1257894000\n")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", status, body)
+	}
+
+	// Write a point selecting the line protocol by query parameter.
+	status, body = MustHTTP("POST", s.URL+`/write`, map[string]string{"db": "foo", "rp": "bar", "precision": "s", "format": "line"}, nil, "cpu,host=server01 value=25 1257894010")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", status, body)
+	}
+	srvr.MustSync()
+
+	// Verify the points were written.
+	status, body = MustHTTP("GET", s.URL+`/query`, map[string]string{"db": "foo", "q": `SELECT sum(value) FROM "foo"."bar".cpu`}, nil, "")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", status, body)
+	} else if body != `{"results":[{"rows":[{"name":"cpu","columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",175]]}]}]}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	// Ensure parse errors are reported with their line number.
+	status, body = MustHTTP("POST", s.URL+`/write`, map[string]string{"db": "foo", "rp": "bar", "precision": "s", "format": "line"}, nil, "cpu value=1 1257894020\ncpu value=x")
+	if status != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `{"error":"line 2: invalid field \"value\": strconv.ParseFloat: parsing \"x\": invalid syntax (1 points written)"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+	srvr.MustSync()
+
+	// Ensure the points before the malformed line were written.
+	status, body = MustHTTP("GET", s.URL+`/query`, map[string]string{"db": "foo", "q": `SELECT sum(value) FROM "foo"."bar".cpu`}, nil, "")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", status, body)
+	} else if body != `{"results":[{"rows":[{"name":"cpu","columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",176]]}]}]}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestHandler_serveQuery_chunked(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	srvr.CreateRetentionPolicy("foo", influxdb.NewRetentionPolicy("bar"))
+	srvr.SetDefaultRetentionPolicy("foo", "bar")
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	status, body := MustHTTP("POST", s.URL+`/write`, map[string]string{"db": "foo", "precision": "s", "format": "line"}, nil, "cpu value=1 1257894000\ncpu value=2 1257894010\ncpu value=3 1257894020\n")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", status, body)
+	}
+	srvr.MustSync()
+
+	// Each chunk is written as a separate JSON object.
+	status, body = MustHTTP("GET", s.URL+`/query`, map[string]string{"db": "foo", "q": `SELECT value FROM cpu; SHOW DATABASES`, "chunked": "true", "chunk_size": "2"}, nil, "")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", status, body)
+	} else if body != `{"results":[{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2009-11-10T23:00:00Z",1],["2009-11-10T23:00:10Z",2]]}],"partial":true}]}
+{"results":[{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2009-11-10T23:00:20Z",3]]}]}]}
+{"results":[{"statement_id":1,"rows":[{"columns":["name"],"values":[["foo"]]}]}]}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	// Ensure the chunk size is validated.
+	status, body = MustHTTP("GET", s.URL+`/query`, map[string]string{"db": "foo", "q": `SELECT value FROM cpu`, "chunked": "true", "chunk_size": "0"}, nil, "")
+	if status != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `{"error":"invalid chunk_size: 0"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestHandler_serveQuery_CSV(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	srvr.CreateRetentionPolicy("foo", influxdb.NewRetentionPolicy("bar"))
+	srvr.SetDefaultRetentionPolicy("foo", "bar")
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	status, body := MustHTTP("POST", s.URL+`/write`, map[string]string{"db": "foo", "precision": "s", "format": "line"}, nil, "cpu,host=serverA,region=uswest value=1 1257894000\ncpu,host=serverB,region=uswest value=2.5 1257894010\n")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", status, body)
+	}
+	srvr.MustSync()
+
+	// Tags are written as columns and errors are written as records.
+	status, body = MustHTTP("GET", s.URL+`/query`, map[string]string{"db": "foo", "q": `SELECT value FROM cpu GROUP BY host, region; SHOW DATABASES; SELECT value FROM mem`}, map[string]string{"Accept": "text/csv"}, "")
+	if status != http.StatusInternalServerError {
+		t.Fatalf("unexpected status: %d: %s", status, body)
+	} else if body != `statement_id,name,host,region,time,value
+0,cpu,serverA,uswest,2009-11-10T23:00:00Z,1
+0,cpu,serverB,uswest,2009-11-10T23:00:10Z,2.5
+statement_id,name,name
+1,,foo
+statement_id,error
+2,measurement not found` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	// Ensure query errors are written as CSV.
+	status, body = MustHTTP("GET", s.URL+`/query`, map[string]string{"db": "foo", "q": `SELECT`}, map[string]string{"Accept": "text/csv"}, "")
+	if status != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", status)
+	} else if !strings.HasPrefix(body, "error\n\"error parsing query: ") {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestHandler_serveQuery_MessagePack(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	req, err := http.NewRequest("GET", s.URL+`/query?q=SHOW+DATABASES`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "application/x-msgpack, application/json;q=0.9")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+
+	// {"results":[{"rows":[{"columns":["name"],"values":[["foo"]]}]}]}
+	exp := []byte("\x81\xa7results\x91\x81\xa4rows\x91\x82\xa7columns\x91\xa4name\xa6values\x91\x91\xa3foo")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	} else if ct := resp.Header.Get("Content-Type"); ct != "application/x-msgpack" {
+		t.Fatalf("unexpected content type: %s", ct)
+	} else if !bytes.Equal(b, exp) {
+		t.Fatalf("unexpected body: %q", b)
+	}
+}
+
 func TestHandler_serveWriteSeries_noDatabaseExists(t *testing.T) {
 	srvr := OpenAuthenticatedServer(NewMessagingClient())
 	s := NewHTTPServer(srvr)
@@ -1700,10 +1845,10 @@ func MustHTTP(verb, path string, params, headers map[string]string, body string)
 		req.URL.RawQuery = q.Encode()
 	}
 
+	req.Header.Set("Content-Type", "application/json")
 	for k, v := range headers {
 		req.Header.Set(k, v)
 	}
-	req.Header.Set("Content-Type", "application/json")
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
@@ -1795,6 +1940,14 @@ func (s *Server) Restart() {
 	}
 }
 
+// MustSync waits until the server has applied every message published by its
+// client, such as the points of a write made through the handler.
+func (s *Server) MustSync() {
+	if err := s.Sync(s.Client().(*MessagingClient).Index()); err != nil {
+		panic("sync error: " + err.Error())
+	}
+}
+
 // OpenUninitializedServer returns a new, uninitialized, open test server instance.
 func OpenUninitializedServer(client influxdb.MessagingClient) *Server {
 	s := NewServer()
@@ -1843,6 +1996,13 @@ func (c *MessagingClient) Publish(m *messaging.Message) (uint64, error) {
 	return c.PublishFunc(m)
 }
 
+// Index returns the index of the last message published.
+func (c *MessagingClient) Index() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.index
+}
+
 // send sends the message through to the channel.
 // This is the default value of PublishFunc.
 func (c *MessagingClient) send(m *messaging.Message) (uint64, error) {
@@ -42,6 +42,21 @@ func (l *responseLogger) WriteHeader(s int) {
 	l.status = s
 }
 
+// Flush sends any buffered data to the client.
+func (l *responseLogger) Flush() {
+	if f, ok := l.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// CloseNotify returns a channel that receives a value when the client goes away.
+func (l *responseLogger) CloseNotify() <-chan bool {
+	if n, ok := l.w.(http.CloseNotifier); ok {
+		return n.CloseNotify()
+	}
+	return nil
+}
+
 func (l *responseLogger) Status() int {
 	return l.status
 }
@@ -20,6 +20,9 @@ var (
 	// ErrPathRequired is returned when opening a server without a path.
 	ErrPathRequired = errors.New("path required")
 
+	// ErrBindAddressRequired is returned when starting a listener without an address.
+	ErrBindAddressRequired = errors.New("bind address required")
+
 	// ErrUnableToJoin is returned when a server cannot join a cluster.
 	ErrUnableToJoin = errors.New("unable to join")
 
@@ -78,9 +81,16 @@ var (
 	// policy on a database but the default has not been set.
 	ErrDefaultRetentionPolicyNotFound = errors.New("default retention policy not found")
 
+	// ErrRetentionSweepPeriodRequired is returned when starting retention
+	// policy enforcement without a sweep period.
+	ErrRetentionSweepPeriodRequired = errors.New("retention sweep period required")
+
 	// ErrShardNotFound is returned writing to a non-existent shard.
 	ErrShardNotFound = errors.New("shard not found")
 
+	// ErrShardGroupNotFound is returned when deleting a non-existent shard group.
+	ErrShardGroupNotFound = errors.New("shard group not found")
+
 	// ErrReadAccessDenied is returned when a user attempts to read
 	// data that he or she does not have permission to read.
 	ErrReadAccessDenied = errors.New("read access denied")
@@ -103,6 +113,15 @@ var (
 	// ErrFieldOverflow is returned when too many fields are created on a measurement.
 	ErrFieldOverflow = errors.New("field overflow")
 
+	// ErrFieldTypeConflict is returned when a new field already exists with a different type.
+	ErrFieldTypeConflict = errors.New("field type conflict")
+
+	// ErrFieldTypeUnsupported is returned when a point contains a value of an unsupported type.
+	ErrFieldTypeUnsupported = errors.New("unsupported field type")
+
+	// ErrFieldValueTooLarge is returned when a string field value exceeds the maximum size.
+	ErrFieldValueTooLarge = errors.New("field value too large")
+
 	// ErrSeriesNotFound is returned when looking up a non-existent series by database, name and tags
 	ErrSeriesNotFound = errors.New("series not found")
 
@@ -116,6 +135,17 @@ var (
 	// ErrInvalidGrantRevoke is returned when a statement requests an invalid
 	// privilege for a user on the cluster or a database.
 	ErrInvalidGrantRevoke = errors.New("invalid privilege requested")
+
+	// ErrContinuousQueryExists is returned when creating a continuous query
+	// with a name that is already used on the database.
+	ErrContinuousQueryExists = errors.New("continuous query already exists")
+
+	// ErrContinuousQueryNotFound is returned when dropping a non-existent continuous query.
+	ErrContinuousQueryNotFound = errors.New("continuous query not found")
+
+	// ErrContinuousQueryIntervalRequired is returned when a continuous query
+	// does not group by a time interval.
+	ErrContinuousQueryIntervalRequired = errors.New("continuous query requires a GROUP BY time() interval")
 )
 
 // BatchPoints is used to send batched data in a single write.
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -15,8 +16,10 @@ type DataType string
 const (
 	// Unknown primitive data type.
 	Unknown = DataType("")
-	// Number means the data type is an int or float.
+	// Number means the data type is a float.
 	Number = DataType("number")
+	// Integer means the data type is an int.
+	Integer = DataType("integer")
 	// Boolean means the data type is a boolean.
 	Boolean = DataType("boolean")
 	// String means the data type is a string of text.
@@ -32,6 +35,8 @@ func InspectDataType(v interface{}) DataType {
 	switch v.(type) {
 	case float64:
 		return Number
+	case int, int32, int64:
+		return Integer
 	case bool:
 		return Boolean
 	case string:
@@ -65,6 +70,7 @@ func (*DropDatabaseStatement) node()          {}
 func (*DropRetentionPolicyStatement) node()   {}
 func (*DropSeriesStatement) node()            {}
 func (*DropUserStatement) node()              {}
+func (*ExplainStatement) node()               {}
 func (*GrantStatement) node()                 {}
 func (*ShowContinuousQueriesStatement) node() {}
 func (*ShowDatabasesStatement) node()         {}
@@ -93,6 +99,7 @@ func (*nilLiteral) node()      {}
 func (*Merge) node()           {}
 func (*NumberLiteral) node()   {}
 func (*ParenExpr) node()       {}
+func (*RegexLiteral) node()    {}
 func (*SortField) node()       {}
 func (SortFields) node()       {}
 func (*StringLiteral) node()   {}
@@ -153,6 +160,7 @@ func (*DropDatabaseStatement) stmt()          {}
 func (*DropRetentionPolicyStatement) stmt()   {}
 func (*DropSeriesStatement) stmt()            {}
 func (*DropUserStatement) stmt()              {}
+func (*ExplainStatement) stmt()               {}
 func (*GrantStatement) stmt()                 {}
 func (*ShowContinuousQueriesStatement) stmt() {}
 func (*ShowDatabasesStatement) stmt()         {}
@@ -179,6 +187,7 @@ func (*DurationLiteral) expr() {}
 func (*nilLiteral) expr()      {}
 func (*NumberLiteral) expr()   {}
 func (*ParenExpr) expr()       {}
+func (*RegexLiteral) expr()    {}
 func (*StringLiteral) expr()   {}
 func (*TimeLiteral) expr()     {}
 func (*VarRef) expr()          {}
@@ -206,9 +215,15 @@ type SortField struct {
 // String returns a string representation of a sort field
 func (field *SortField) String() string {
 	var buf bytes.Buffer
-	_, _ = buf.WriteString(field.Name)
-	_, _ = buf.WriteString(" ")
-	_, _ = buf.WriteString(strconv.FormatBool(field.Ascending))
+	if field.Name != "" {
+		_, _ = buf.WriteString(field.Name)
+		_, _ = buf.WriteString(" ")
+	}
+	if field.Ascending {
+		_, _ = buf.WriteString("ASC")
+	} else {
+		_, _ = buf.WriteString("DESC")
+	}
 	return buf.String()
 }
 
@@ -446,6 +461,9 @@ type CreateRetentionPolicyStatement struct {
 	// Replication factor for data written to this policy.
 	Replication int
 
+	// Duration of time covered by each shard group. Zero uses the default.
+	ShardGroupDuration time.Duration
+
 	// Should this policy be set as default for the database?
 	Default bool
 }
@@ -461,6 +479,10 @@ func (s *CreateRetentionPolicyStatement) String() string {
 	_, _ = buf.WriteString(FormatDuration(s.Duration))
 	_, _ = buf.WriteString(" REPLICATION ")
 	_, _ = buf.WriteString(strconv.Itoa(s.Replication))
+	if s.ShardGroupDuration > 0 {
+		_, _ = buf.WriteString(" SHARD DURATION ")
+		_, _ = buf.WriteString(FormatDuration(s.ShardGroupDuration))
+	}
 	if s.Default {
 		_, _ = buf.WriteString(" DEFAULT")
 	}
@@ -486,6 +508,9 @@ type AlterRetentionPolicyStatement struct {
 	// Replication factor for data written to this policy.
 	Replication *int
 
+	// Duration of time covered by each shard group.
+	ShardGroupDuration *time.Duration
+
 	// Should this policy be set as defalut for the database?
 	Default bool
 }
@@ -508,6 +533,11 @@ func (s *AlterRetentionPolicyStatement) String() string {
 		_, _ = buf.WriteString(strconv.Itoa(*s.Replication))
 	}
 
+	if s.ShardGroupDuration != nil {
+		_, _ = buf.WriteString(" SHARD DURATION ")
+		_, _ = buf.WriteString(FormatDuration(*s.ShardGroupDuration))
+	}
+
 	if s.Default {
 		_, _ = buf.WriteString(" DEFAULT")
 	}
@@ -546,8 +576,34 @@ type SelectStatement struct {
 
 	// Returns rows starting at an offset from the first row.
 	Offset int
+
+	// The strategy used to fill intervals without points and the value
+	// used by NumberFill.
+	Fill      FillOption
+	FillValue float64
 }
 
+// FillOption represents a strategy for filling intervals of a
+// "GROUP BY time()" query that have no points.
+type FillOption int
+
+const (
+	// NoFill omits intervals without points. This is the default.
+	NoFill FillOption = iota
+
+	// NullFill returns null for intervals without points.
+	NullFill
+
+	// NumberFill returns a fixed number for intervals without points.
+	NumberFill
+
+	// PreviousFill returns the value of the previous interval.
+	PreviousFill
+
+	// LinearFill interpolates between the surrounding intervals.
+	LinearFill
+)
+
 // Clone returns a deep copy of the statement.
 func (s *SelectStatement) Clone() *SelectStatement {
 	other := &SelectStatement{
@@ -557,6 +613,9 @@ func (s *SelectStatement) Clone() *SelectStatement {
 		SortFields: make(SortFields, len(s.SortFields)),
 		Condition:  CloneExpr(s.Condition),
 		Limit:      s.Limit,
+		Offset:     s.Offset,
+		Fill:       s.Fill,
+		FillValue:  s.FillValue,
 	}
 	if s.Target != nil {
 		other.Target = &Target{Measurement: s.Target.Measurement, Database: s.Target.Database}
@@ -581,7 +640,7 @@ func cloneSource(s Source) Source {
 
 	switch s := s.(type) {
 	case *Measurement:
-		return &Measurement{Name: s.Name}
+		return &Measurement{Name: s.Name, Regex: s.Regex}
 	case *Join:
 		other := &Join{Measurements: make(Measurements, len(s.Measurements))}
 		for i, m := range s.Measurements {
@@ -619,6 +678,16 @@ func (s *SelectStatement) String() string {
 		_, _ = buf.WriteString(" GROUP BY ")
 		_, _ = buf.WriteString(s.Dimensions.String())
 	}
+	switch s.Fill {
+	case NullFill:
+		_, _ = buf.WriteString(" fill(null)")
+	case NumberFill:
+		_, _ = fmt.Fprintf(&buf, " fill(%s)", strconv.FormatFloat(s.FillValue, 'f', -1, 64))
+	case PreviousFill:
+		_, _ = buf.WriteString(" fill(previous)")
+	case LinearFill:
+		_, _ = buf.WriteString(" fill(linear)")
+	}
 	if len(s.SortFields) > 0 {
 		_, _ = buf.WriteString(" ORDER BY ")
 		_, _ = buf.WriteString(s.SortFields.String())
@@ -655,6 +724,39 @@ func (s *SelectStatement) Aggregated() bool {
 	return v
 }
 
+// HasWildcard returns true if any of the fields is a wildcard.
+func (s *SelectStatement) HasWildcard() bool {
+	for _, f := range s.Fields {
+		if _, ok := f.Expr.(*Wildcard); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RewriteWildcards returns a copy of the statement with wildcard fields
+// replaced by a variable reference to each of the given field names.
+func (s *SelectStatement) RewriteWildcards(names []string) *SelectStatement {
+	other := s.Clone()
+	fields := make(Fields, 0, len(other.Fields)+len(names))
+	for _, f := range other.Fields {
+		if _, ok := f.Expr.(*Wildcard); !ok {
+			fields = append(fields, f)
+			continue
+		}
+		for _, name := range names {
+			fields = append(fields, &Field{Expr: &VarRef{Val: name}})
+		}
+	}
+	other.Fields = fields
+	return other
+}
+
+// TimeAscending returns true if the results are ordered by ascending time.
+func (s *SelectStatement) TimeAscending() bool {
+	return len(s.SortFields) == 0 || s.SortFields[0].Ascending
+}
+
 // OnlyTimeDimensions returns true if the statement has a where clause with only time constraints
 func (s *SelectStatement) OnlyTimeDimensions() bool {
 	return s.walkForTime(s.Condition)
@@ -719,6 +821,7 @@ func (s *SelectStatement) Substatement(ref *VarRef) (*SelectStatement, error) {
 		Fields:     Fields{{Expr: ref}},
 		Dimensions: s.Dimensions,
 		Limit:      s.Limit,
+		Offset:     s.Offset,
 		SortFields: s.SortFields,
 	}
 
@@ -732,29 +835,56 @@ func (s *SelectStatement) Substatement(ref *VarRef) (*SelectStatement, error) {
 	// Find the matching source.
 	name := MatchSource(s.Source, ref.Val)
 	if name == "" {
+		// Unprefixed fields of a merge are read from all of its measurements.
+		if _, ok := s.Source.(*Merge); ok {
+			other.Source = s.Source
+			other.Condition = s.Condition
+			return other, nil
+		}
 		return nil, fmt.Errorf("field source not found: %s", ref.Val)
 	}
 	other.Source = &Measurement{Name: name}
 
 	// Filter out conditions.
 	if s.Condition != nil {
-		other.Condition = filterExprBySource(name, s.Condition)
+		other.Condition = filterExprBySource(s.Source, name, s.Condition)
 	}
 
 	return other, nil
 }
 
-// filters an expression to exclude expressions unrelated to a source.
-func filterExprBySource(name string, expr Expr) Expr {
+// StripSourcePrefix returns a copy of the statement with the measurement
+// name removed from variable references prefixed by it. For example,
+// "cpu.value" becomes "value" for a statement selecting from "cpu".
+func (s *SelectStatement) StripSourcePrefix() *SelectStatement {
+	other := s.Clone()
+
+	m, ok := other.Source.(*Measurement)
+	if !ok {
+		return other
+	}
+	prefix := m.Name + "."
+
+	WalkFunc(other, func(n Node) {
+		if ref, ok := n.(*VarRef); ok && strings.HasPrefix(ref.Val, prefix) {
+			ref.Val = lastIdent(ref.Val)
+		}
+	})
+	return other
+}
+
+// filters an expression to exclude expressions related to other sources.
+// Variable references without a source prefix, such as time, are kept.
+func filterExprBySource(src Source, name string, expr Expr) Expr {
 	switch expr := expr.(type) {
 	case *VarRef:
-		if !strings.HasPrefix(expr.Val, name) {
+		if other := MatchSource(src, expr.Val); other != "" && other != name {
 			return nil
 		}
 
 	case *BinaryExpr:
-		lhs := filterExprBySource(name, expr.LHS)
-		rhs := filterExprBySource(name, expr.RHS)
+		lhs := filterExprBySource(src, name, expr.LHS)
+		rhs := filterExprBySource(src, name, expr.RHS)
 
 		// If an expr is logical then return either LHS/RHS or both.
 		// If an expr is arithmetic or comparative then require both sides.
@@ -774,7 +904,7 @@ func filterExprBySource(name string, expr Expr) Expr {
 		return &BinaryExpr{Op: expr.Op, LHS: lhs, RHS: rhs}
 
 	case *ParenExpr:
-		exp := filterExprBySource(name, expr.Expr)
+		exp := filterExprBySource(src, name, expr.Expr)
 		if exp == nil {
 			return nil
 		}
@@ -788,18 +918,18 @@ func filterExprBySource(name string, expr Expr) Expr {
 func MatchSource(src Source, name string) string {
 	switch src := src.(type) {
 	case *Measurement:
-		if strings.HasPrefix(name, src.Name) {
+		if strings.HasPrefix(name, src.Name+".") {
 			return src.Name
 		}
 	case *Join:
 		for _, m := range src.Measurements {
-			if strings.HasPrefix(name, m.Name) {
+			if strings.HasPrefix(name, m.Name+".") {
 				return m.Name
 			}
 		}
 	case *Merge:
 		for _, m := range src.Measurements {
-			if strings.HasPrefix(name, m.Name) {
+			if strings.HasPrefix(name, m.Name+".") {
 				return m.Name
 			}
 		}
@@ -807,6 +937,27 @@ func MatchSource(src Source, name string) string {
 	return ""
 }
 
+// SourceName returns the name used for rows selected from a source.
+// Joins and merges are named after the measurements they combine.
+func SourceName(src Source) string {
+	var names []string
+	switch src := src.(type) {
+	case *Measurement:
+		return lastIdent(src.Name)
+	case *Join:
+		for _, m := range src.Measurements {
+			names = append(names, lastIdent(m.Name))
+		}
+		return fmt.Sprintf("join(%s)", strings.Join(names, ","))
+	case *Merge:
+		for _, m := range src.Measurements {
+			names = append(names, lastIdent(m.Name))
+		}
+		return fmt.Sprintf("merge(%s)", strings.Join(names, ","))
+	}
+	return ""
+}
+
 // Target represents a target (destination) policy, measurment, and DB.
 type Target struct {
 	// Measurement to write into.
@@ -842,13 +993,13 @@ type DeleteStatement struct {
 // String returns a string representation of the delete statement.
 func (s *DeleteStatement) String() string {
 	var buf bytes.Buffer
-	_, _ = buf.WriteString("DELETE ")
+	_, _ = buf.WriteString("DELETE FROM ")
 	_, _ = buf.WriteString(s.Source.String())
 	if s.Condition != nil {
 		_, _ = buf.WriteString(" WHERE ")
 		_, _ = buf.WriteString(s.Condition.String())
 	}
-	return s.String()
+	return buf.String()
 }
 
 // RequiredPrivileges returns the privilege required to execute a DeleteStatement.
@@ -856,6 +1007,33 @@ func (s *DeleteStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: WritePrivilege}}
 }
 
+// ExplainStatement represents a command for returning the execution plan
+// of a select statement.
+type ExplainStatement struct {
+	// Statement being explained.
+	Statement *SelectStatement
+
+	// Executes the statement and reports the points read and the time
+	// spent by each stage.
+	Analyze bool
+}
+
+// String returns a string representation of the explain statement.
+func (s *ExplainStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("EXPLAIN ")
+	if s.Analyze {
+		_, _ = buf.WriteString("ANALYZE ")
+	}
+	_, _ = buf.WriteString(s.Statement.String())
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute an ExplainStatement.
+func (s *ExplainStatement) RequiredPrivileges() ExecutionPrivileges {
+	return s.Statement.RequiredPrivileges()
+}
+
 // ShowSeriesStatement represents a command for listing series in the database.
 type ShowSeriesStatement struct {
 	// Measurement(s) the series are listed for.
@@ -906,11 +1084,28 @@ func (s *ShowSeriesStatement) RequiredPrivileges() ExecutionPrivileges {
 
 // DropSeriesStatement represents a command for removing a series from the database.
 type DropSeriesStatement struct {
-	Name string
+	// Measurement(s) the series are dropped from.
+	Source Source
+
+	// An expression evaluated on a series' tags.
+	Condition Expr
 }
 
 // String returns a string representation of the drop series statement.
-func (s *DropSeriesStatement) String() string { return fmt.Sprintf("DROP SERIES %s", s.Name) }
+func (s *DropSeriesStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("DROP SERIES")
+
+	if s.Source != nil {
+		_, _ = buf.WriteString(" FROM ")
+		_, _ = buf.WriteString(s.Source.String())
+	}
+	if s.Condition != nil {
+		_, _ = buf.WriteString(" WHERE ")
+		_, _ = buf.WriteString(s.Condition.String())
+	}
+	return buf.String()
+}
 
 // RequiredPrivileges returns the privilige reqired to execute a DropSeriesStatement.
 func (s DropSeriesStatement) RequiredPrivileges() ExecutionPrivileges {
@@ -1341,12 +1536,19 @@ func (a Measurements) String() string {
 }
 
 // Measurement represents a single measurement used as a datasource.
+// If Regex is set then the source is every measurement matching it.
 type Measurement struct {
-	Name string
+	Name  string
+	Regex *RegexLiteral
 }
 
 // String returns a string representation of the measurement.
-func (m *Measurement) String() string { return m.Name }
+func (m *Measurement) String() string {
+	if m.Regex != nil {
+		return m.Regex.String()
+	}
+	return m.Name
+}
 
 // Join represents two datasources joined together.
 type Join struct {
@@ -1439,6 +1641,19 @@ type StringLiteral struct {
 // String returns a string representation of the literal.
 func (l *StringLiteral) String() string { return QuoteString(l.Val) }
 
+// RegexLiteral represents a regular expression literal.
+type RegexLiteral struct {
+	Val *regexp.Regexp
+}
+
+// String returns a string representation of the literal.
+func (l *RegexLiteral) String() string {
+	if l.Val == nil {
+		return ""
+	}
+	return "/" + strings.Replace(l.Val.String(), "/", `\/`, -1) + "/"
+}
+
 // TimeLiteral represents a point-in-time literal.
 type TimeLiteral struct {
 	Val time.Time
@@ -1512,6 +1727,8 @@ func CloneExpr(expr Expr) Expr {
 		return &NumberLiteral{Val: expr.Val}
 	case *ParenExpr:
 		return &ParenExpr{Expr: CloneExpr(expr.Expr)}
+	case *RegexLiteral:
+		return &RegexLiteral{Val: expr.Val}
 	case *StringLiteral:
 		return &StringLiteral{Val: expr.Val}
 	case *TimeLiteral:
@@ -1620,10 +1837,21 @@ func Walk(v Visitor, node Node) {
 		Walk(v, n.Source)
 		Walk(v, n.Condition)
 
+	case *ExplainStatement:
+		Walk(v, n.Statement)
+
 	case *ShowSeriesStatement:
 		Walk(v, n.Source)
 		Walk(v, n.Condition)
 
+	case *DropSeriesStatement:
+		Walk(v, n.Source)
+		Walk(v, n.Condition)
+
+	case *DeleteStatement:
+		Walk(v, n.Source)
+		Walk(v, n.Condition)
+
 	case *ShowTagKeysStatement:
 		Walk(v, n.Source)
 		Walk(v, n.Condition)
@@ -1650,6 +1878,16 @@ func Walk(v Visitor, node Node) {
 	case *Dimension:
 		Walk(v, n.Expr)
 
+	case *Join:
+		for _, m := range n.Measurements {
+			Walk(v, m)
+		}
+
+	case *Merge:
+		for _, m := range n.Measurements {
+			Walk(v, m)
+		}
+
 	case *BinaryExpr:
 		Walk(v, n.LHS)
 		Walk(v, n.RHS)
@@ -1753,6 +1991,8 @@ func Eval(expr Expr, m map[string]interface{}) interface{} {
 		return expr.Val
 	case *ParenExpr:
 		return Eval(expr.Expr, m)
+	case *RegexLiteral:
+		return expr.Val
 	case *StringLiteral:
 		return expr.Val
 	case *VarRef:
@@ -1766,6 +2006,14 @@ func evalBinaryExpr(expr *BinaryExpr, m map[string]interface{}) interface{} {
 	lhs := Eval(expr.LHS, m)
 	rhs := Eval(expr.RHS, m)
 
+	// Compare integers as floats.
+	if v, ok := lhs.(int64); ok {
+		lhs = float64(v)
+	}
+	if v, ok := rhs.(int64); ok {
+		rhs = float64(v)
+	}
+
 	// Evaluate if both sides are simple types.
 	switch lhs := lhs.(type) {
 	case bool:
@@ -1775,6 +2023,10 @@ func evalBinaryExpr(expr *BinaryExpr, m map[string]interface{}) interface{} {
 			return lhs && rhs
 		case OR:
 			return lhs || rhs
+		case EQ:
+			return lhs == rhs
+		case NEQ:
+			return lhs != rhs
 		}
 	case float64:
 		rhs, _ := rhs.(float64)
@@ -1804,12 +2056,19 @@ func evalBinaryExpr(expr *BinaryExpr, m map[string]interface{}) interface{} {
 			return lhs / rhs
 		}
 	case string:
-		rhs, _ := rhs.(string)
 		switch expr.Op {
 		case EQ:
+			rhs, _ := rhs.(string)
 			return lhs == rhs
 		case NEQ:
+			rhs, _ := rhs.(string)
 			return lhs != rhs
+		case EQREGEX:
+			rhs, ok := rhs.(*regexp.Regexp)
+			return ok && rhs.MatchString(lhs)
+		case NEQREGEX:
+			rhs, ok := rhs.(*regexp.Regexp)
+			return ok && !rhs.MatchString(lhs)
 		}
 	}
 	return nil
@@ -2113,12 +2372,13 @@ type Valuer interface {
 	Value(key string) (interface{}, bool)
 }
 
-// nowValuer returns only the value for "now()".
-type nowValuer struct {
+// NowValuer returns only the value for "now()".
+type NowValuer struct {
 	Now time.Time
 }
 
-func (v *nowValuer) Value(key string) (interface{}, bool) {
+// Value returns the current time for the "now()" key.
+func (v *NowValuer) Value(key string) (interface{}, bool) {
 	if key == "now()" {
 		return v.Now, true
 	}
@@ -15,6 +15,9 @@ func TestInspectDataType(t *testing.T) {
 		typ influxql.DataType
 	}{
 		{float64(100), influxql.Number},
+		{int64(100), influxql.Integer},
+		{true, influxql.Boolean},
+		{"foo", influxql.String},
 	} {
 		if typ := influxql.InspectDataType(tt.v); tt.typ != typ {
 			t.Errorf("%d. %v (%s): unexpected type: %s", i, tt.v, tt.typ, typ)
@@ -72,6 +75,20 @@ func TestSelectStatement_Substatement(t *testing.T) {
 			expr: &influxql.VarRef{Val: "bb.value"},
 			sub:  `SELECT bb.value FROM bb WHERE ((bb.host = 'serverb' OR bb.host = 'serverc')) AND 1.000 = 2.000`,
 		},
+
+		// 6. Join with unprefixed time condition
+		{
+			stmt: `SELECT sum(aa.value) + sum(bb.value) FROM join(aa, bb) WHERE aa.host = 'servera' AND time > now() - 1h`,
+			expr: &influxql.VarRef{Val: "bb.value"},
+			sub:  `SELECT bb.value FROM bb WHERE time > now() - 1h`,
+		},
+
+		// 7. Merge with unprefixed field
+		{
+			stmt: `SELECT value FROM merge(aa, bb) WHERE host = 'servera'`,
+			expr: &influxql.VarRef{Val: "value"},
+			sub:  `SELECT value FROM merge(aa, bb) WHERE host = 'servera'`,
+		},
 	}
 
 	for i, tt := range tests {
@@ -217,6 +234,7 @@ func TestEval(t *testing.T) {
 		{in: `1 + 2`, out: float64(3)},
 		{in: `(foo*2) + ( (4/2) + (3 * 5) - 0.5 )`, out: float64(26.5), data: map[string]interface{}{"foo": float64(5)}},
 		{in: `foo / 2`, out: float64(2), data: map[string]interface{}{"foo": float64(4)}},
+		{in: `foo > 2`, out: true, data: map[string]interface{}{"foo": int64(4)}},
 		{in: `4 = 4`, out: true},
 		{in: `4 <> 4`, out: false},
 		{in: `6 > 4`, out: true},
@@ -228,11 +246,18 @@ func TestEval(t *testing.T) {
 		// Boolean literals.
 		{in: `true AND false`, out: false},
 		{in: `true OR false`, out: true},
+		{in: `foo = true`, out: true, data: map[string]interface{}{"foo": true}},
+		{in: `foo <> true`, out: false, data: map[string]interface{}{"foo": true}},
 
 		// String literals.
 		{in: `'foo' = 'bar'`, out: false},
 		{in: `'foo' = 'foo'`, out: true},
 
+		// Regular expressions.
+		{in: `foo =~ /^b.r$/`, out: true, data: map[string]interface{}{"foo": "bar"}},
+		{in: `foo !~ /^b.r$/`, out: false, data: map[string]interface{}{"foo": "bar"}},
+		{in: `foo =~ /^b.r$/`, out: nil, data: map[string]interface{}{"foo": float64(1)}},
+
 		// Variable references.
 		{in: `foo`, out: "bar", data: map[string]interface{}{"foo": "bar"}},
 		{in: `foo = 'bar'`, out: true, data: map[string]interface{}{"foo": "bar"}},
@@ -12,19 +12,43 @@ for a list of columns followed by a list of series to select from.
 
 	SELECT value FROM cpu_load
 
+Multiple fields are returned as columns of the same row. A wildcard selects
+every field of the measurement:
+
+	SELECT * FROM cpu_load
+
 You can also add a a conditional expression to limit the results of the query:
 
 	SELECT value FROM cpu_load WHERE host = 'influxdb.com'
 
+Tags and fields can be matched against regular expressions with the =~ and !~
+operators. A regular expression in the FROM clause selects from every
+measurement whose name matches it:
+
+	SELECT value FROM /^cpu/ WHERE host =~ /^server[0-9]+$/
+
 Two or more series can be combined into a single query and executed together:
 
 	SELECT cpu0.value + cpu1.value
 	FROM cpu_load AS cpu0 INNER JOIN cpu_load cpu1 ON cpu0.host = cpu1.host
 
+Intervals of a query grouped by time that have no points are omitted. They
+can instead be filled with null, a number, the previous value or a value
+interpolated between the surrounding intervals:
+
+	SELECT mean(value) FROM cpu_load WHERE time > now() - 1h
+	GROUP BY time(5m) fill(previous)
+
 Limits and ordering can be set on selection queries as well:
 
 	SELECT value FROM cpu_load LIMIT 100 ORDER DESC;
 
+The execution plan of a query is returned by prefixing it with EXPLAIN.
+EXPLAIN ANALYZE also executes the query and reports the points scanned and
+the time spent by each stage:
+
+	EXPLAIN ANALYZE SELECT mean(value) FROM cpu_load GROUP BY time(1m)
+
 
 Removing data
 
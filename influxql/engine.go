@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"hash/fnv"
 	"math"
+	"reflect"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -29,7 +32,7 @@ type Tx interface {
 	//
 	// The statement must adhere to the following rules:
 	//   1. It can only have a single VarRef field.
-	//   2. It can only have a single source measurement.
+	//   2. It can only have a single source measurement or a merge of measurements.
 	CreateIterators(*SelectStatement) ([]Iterator, error)
 }
 
@@ -42,30 +45,65 @@ type Iterator interface {
 	Next() (key int64, value interface{})
 }
 
+// MeasurementIterator represents an iterator over the values of a single
+// measurement. Raw queries of a merge use the measurement to keep values of
+// different measurements with the same timestamp and tags apart.
+type MeasurementIterator interface {
+	Iterator
+
+	// Measurement returns the name of the measurement the iterator reads.
+	Measurement() string
+}
+
+// ExplainedIterator represents an iterator that can describe the data it
+// reads. Iterators that don't implement it are only counted in query plans.
+type ExplainedIterator interface {
+	Iterator
+
+	// Explain returns a description of the iterator's data.
+	Explain() IteratorExplanation
+}
+
+// IteratorExplanation describes the data read by an iterator.
+type IteratorExplanation struct {
+	ShardID uint64 // shard the iterator reads from
+	SeriesN int    // number of series read
+	PointN  int    // number of points scanned, including filtered points
+}
+
+// DefaultMaxFillN is the default maximum number of intervals filled per series.
+const DefaultMaxFillN = 100000
+
 // Planner represents an object for creating execution plans.
 type Planner struct {
 	DB DB
 
 	// Returns the current time. Defaults to time.Now().
 	Now func() time.Time
+
+	// Maximum number of intervals a series can be filled across.
+	// Defaults to DefaultMaxFillN.
+	MaxFillN int
 }
 
 // NewPlanner returns a new instance of Planner.
 func NewPlanner(db DB) *Planner {
 	return &Planner{
-		DB:  db,
-		Now: time.Now,
+		DB:       db,
+		Now:      time.Now,
+		MaxFillN: DefaultMaxFillN,
 	}
 }
 
 // Plan creates an execution plan for the given SelectStatement and returns an Executor.
 func (p *Planner) Plan(stmt *SelectStatement) (*Executor, error) {
+	start := time.Now()
 	now := p.Now()
 
 	// Clone the statement to be planned.
 	// Replace instances of "now()" with the current time.
 	stmt = stmt.Clone()
-	stmt.Condition = Reduce(stmt.Condition, &nowValuer{Now: now})
+	stmt.Condition = Reduce(stmt.Condition, &NowValuer{Now: now})
 
 	// Begin an unopened transaction.
 	tx, err := p.DB.Begin()
@@ -73,6 +111,18 @@ func (p *Planner) Plan(stmt *SelectStatement) (*Executor, error) {
 		return nil, err
 	}
 
+	// Results can only be ordered by time.
+	for _, f := range stmt.SortFields {
+		if f.Name != "" && strings.ToLower(f.Name) != "time" {
+			return nil, fmt.Errorf("only ORDER BY time supported: %s", f.Name)
+		}
+	}
+
+	// Select each field of a join from the measurements it references.
+	if j, ok := stmt.Source.(*Join); ok {
+		stmt.Fields = expandJoinFields(j, stmt.Fields)
+	}
+
 	// Create the executor.
 	e := newExecutor(tx, stmt)
 
@@ -84,6 +134,27 @@ func (p *Planner) Plan(stmt *SelectStatement) (*Executor, error) {
 	e.interval = interval
 	e.tags = tags
 
+	// Raw values of a merge are returned with the measurement they were read from.
+	if _, ok := stmt.Source.(*Merge); ok && !stmt.Aggregated() && interval == 0 {
+		e.merged = true
+	}
+
+	// Determine the time range that intervals are filled across.
+	// Queries with only a lower bound are filled up to the current time.
+	e.tmin, e.tmax = TimeRange(stmt.Condition)
+	if !e.tmin.IsZero() && e.tmax.IsZero() {
+		e.tmax = now
+	}
+
+	// Reject queries that fill more intervals than allowed. Unbounded
+	// queries are checked once their first and last values are known.
+	e.maxFillN = p.MaxFillN
+	if interval > 0 && stmt.Fill != NoFill && !e.tmin.IsZero() {
+		if n := fillN(e.tmin.UnixNano(), e.tmax.UnixNano(), interval.Nanoseconds()); n > int64(e.maxFillN) {
+			return nil, fmt.Errorf("too many intervals to fill: %d, max %d", n, e.maxFillN)
+		}
+	}
+
 	// Generate a processor for each field.
 	e.processors = make([]Processor, len(stmt.Fields))
 	for i, f := range stmt.Fields {
@@ -93,10 +164,62 @@ func (p *Planner) Plan(stmt *SelectStatement) (*Executor, error) {
 		}
 		e.processors[i] = p
 	}
+	e.planElapsed = time.Since(start)
 
 	return e, nil
 }
 
+// expandJoinFields returns the fields of a join named after the measurement
+// they are selected from. Fields that don't reference a measurement, such as
+// "value" in "SELECT value FROM join(cpu, mem)", are selected from every
+// measurement in the join.
+func expandJoinFields(j *Join, fields Fields) Fields {
+	var a Fields
+	for _, f := range fields {
+		// Find the measurements referenced by the field.
+		var names []string
+		WalkFunc(f.Expr, func(n Node) {
+			if ref, ok := n.(*VarRef); ok {
+				if name := MatchSource(j, ref.Val); name != "" && !stringsContain(names, name) {
+					names = append(names, name)
+				}
+			}
+		})
+
+		switch len(names) {
+		case 0:
+			for _, m := range j.Measurements {
+				expr := CloneExpr(f.Expr)
+				WalkFunc(expr, func(n Node) {
+					if ref, ok := n.(*VarRef); ok {
+						ref.Val = m.Name + "." + QuoteIdent([]string{ref.Val})
+					}
+				})
+				a = append(a, &Field{Expr: expr, Alias: lastIdent(m.Name) + "." + f.Name()})
+			}
+		case 1:
+			other := &Field{Expr: f.Expr, Alias: f.Alias}
+			if other.Alias == "" && f.Name() != "" {
+				other.Alias = lastIdent(names[0]) + "." + lastIdent(f.Name())
+			}
+			a = append(a, other)
+		default:
+			a = append(a, f)
+		}
+	}
+	return a
+}
+
+// stringsContain returns true if a contains s.
+func stringsContain(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *Planner) planField(e *Executor, f *Field) (Processor, error) {
 	return p.planExpr(e, f.Expr)
 }
@@ -132,7 +255,11 @@ func (p *Planner) planRawQuery(e *Executor, v *VarRef) (Processor, error) {
 	if err != nil {
 		return nil, err
 	}
+	return p.planRawSubstatement(e, stmt)
+}
 
+// planRawSubstatement generates a processor for the raw values of a substatement.
+func (p *Planner) planRawSubstatement(e *Executor, stmt *SelectStatement) (Processor, error) {
 	// Retrieve a list of iterators for the substatement.
 	itrs, err := e.tx.CreateIterators(stmt)
 	if err != nil {
@@ -140,19 +267,28 @@ func (p *Planner) planRawQuery(e *Executor, v *VarRef) (Processor, error) {
 	}
 
 	// Create mapper and reducer.
+	fn := MapRawQuery
+	if e.merged {
+		fn = MapRawMergeQuery
+	}
 	mappers := make([]*Mapper, len(itrs))
 	for i, itr := range itrs {
-		mappers[i] = NewMapper(MapRawQuery, itr, e.interval)
+		mappers[i] = NewMapper(fn, itr, e.interval)
 	}
 	r := NewReducer(ReduceRawQuery, mappers)
-	r.name = lastIdent(stmt.Source.(*Measurement).Name)
+	r.name = SourceName(e.stmt.Source)
 
 	return r, nil
-
 }
 
 // planCall generates a processor for a function call.
 func (p *Planner) planCall(e *Executor, c *Call) (Processor, error) {
+	// Transformations operate on the output of another processor.
+	switch strings.ToLower(c.Name) {
+	case "derivative", "non_negative_derivative", "difference":
+		return p.planTransform(e, c)
+	}
+
 	// Ensure there is a single argument.
 	if c.Name == "percentile" {
 		if len(c.Args) != 2 {
@@ -174,6 +310,9 @@ func (p *Planner) planCall(e *Executor, c *Call) (Processor, error) {
 		return nil, err
 	}
 
+	// Limits apply to the aggregated values so they can't be pushed down to the iterators.
+	stmt.Limit, stmt.Offset = 0, 0
+
 	// Retrieve a list of iterators for the substatement.
 	itrs, err := e.tx.CreateIterators(stmt)
 	if err != nil {
@@ -190,6 +329,20 @@ func (p *Planner) planCall(e *Executor, c *Call) (Processor, error) {
 		mapFn, reduceFn = MapSum, ReduceSum
 	case "mean":
 		mapFn, reduceFn = MapMean, ReduceMean
+	case "min":
+		mapFn, reduceFn = MapMin, ReduceMin
+	case "max":
+		mapFn, reduceFn = MapMax, ReduceMax
+	case "first":
+		mapFn, reduceFn = MapFirst, ReduceFirst
+	case "last":
+		mapFn, reduceFn = MapLast, ReduceLast
+	case "spread":
+		mapFn, reduceFn = MapSpread, ReduceSpread
+	case "stddev":
+		mapFn, reduceFn = MapEcho, ReduceStddev
+	case "median":
+		mapFn, reduceFn = MapEcho, ReduceMedian
 	case "percentile":
 		lit, ok := c.Args[1].(*NumberLiteral)
 		if !ok {
@@ -206,11 +359,72 @@ func (p *Planner) planCall(e *Executor, c *Call) (Processor, error) {
 		mappers[i] = NewMapper(mapFn, itr, e.interval)
 	}
 	r := NewReducer(reduceFn, mappers)
-	r.name = lastIdent(stmt.Source.(*Measurement).Name)
+	r.name = SourceName(e.stmt.Source)
 
 	return r, nil
 }
 
+// planTransform generates a processor for a transformation function call.
+// The argument can be either a field or an aggregate function call.
+func (p *Planner) planTransform(e *Executor, c *Call) (Processor, error) {
+	name := strings.ToLower(c.Name)
+
+	// Determine the transformation function.
+	var fn transformFunc
+	switch name {
+	case "derivative", "non_negative_derivative":
+		if len(c.Args) < 1 || len(c.Args) > 2 {
+			return nil, fmt.Errorf("expected one or two arguments for %s()", c.Name)
+		}
+
+		// Rates are per second unless a unit is specified.
+		unit := 1 * time.Second
+		if len(c.Args) == 2 {
+			lit, ok := c.Args[1].(*DurationLiteral)
+			if !ok || lit.Val <= 0 {
+				return nil, fmt.Errorf("expected duration argument in %s()", c.Name)
+			}
+			unit = lit.Val
+		}
+		fn = transformDerivative(unit, name == "non_negative_derivative")
+
+	case "difference":
+		if len(c.Args) != 1 {
+			return nil, fmt.Errorf("expected one argument for %s()", c.Name)
+		}
+		fn = transformDifference
+	}
+
+	// Plan the input processor.
+	var input Processor
+	switch arg := c.Args[0].(type) {
+	case *VarRef:
+		// Convert the statement to a simplified substatement for the single field.
+		stmt, err := e.stmt.Substatement(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		// Each value depends on the previous value so limits can't be pushed down to the iterators.
+		stmt.Limit, stmt.Offset = 0, 0
+
+		if input, err = p.planRawSubstatement(e, stmt); err != nil {
+			return nil, err
+		}
+	case *Call:
+		var err error
+		if input, err = p.planCall(e, arg); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("expected field or function argument in %s()", c.Name)
+	}
+
+	tp := newTransformProcessor(input, fn)
+	tp.name = name
+	return tp, nil
+}
+
 // planBinaryExpr generates a processor for a binary expression.
 // A binary expression represents a join operator between two processors.
 func (p *Planner) planBinaryExpr(e *Executor, expr *BinaryExpr) (Processor, error) {
@@ -238,13 +452,32 @@ type Executor struct {
 	processors []Processor      // per-field processors
 	interval   time.Duration    // group by interval
 	tags       []string         // dimensional tag keys
+	merged     bool             // true if raw values of a merge are returned by measurement
+	tmin, tmax time.Time        // time range of the query
+	maxFillN   int              // maximum number of intervals filled
+
+	once    sync.Once
+	closing chan struct{}
+
+	// ChunkSize is the maximum number of values in the rows sent by raw
+	// queries ordered by ascending time. Rows are sent as soon as they are
+	// full so a series may be split across several rows. Rows hold all of
+	// their series' values if zero.
+	ChunkSize int
+
+	// Execution statistics reported by Explain.
+	planElapsed    time.Duration
+	executeStart   time.Time
+	executeElapsed time.Duration
+	rowN           int
 }
 
 // newExecutor returns an executor associated with a transaction and statement.
 func newExecutor(tx Tx, stmt *SelectStatement) *Executor {
 	return &Executor{
-		tx:   tx,
-		stmt: stmt,
+		tx:      tx,
+		stmt:    stmt,
+		closing: make(chan struct{}),
 	}
 }
 
@@ -256,52 +489,248 @@ func (e *Executor) Execute() (<-chan *Row, error) {
 	}
 
 	// Initialize processors.
+	e.executeStart = time.Now()
 	for _, p := range e.processors {
 		p.Process()
 	}
 
 	// Create output channel and stream data in a separate goroutine.
 	out := make(chan *Row, 0)
-	go e.execute(out)
+	if e.ChunkSize > 0 && e.interval == 0 && e.stmt.TimeAscending() {
+		go e.executeStream(out)
+	} else {
+		go e.execute(out)
+	}
 
 	return out, nil
 }
 
-// execute runs in a separate separate goroutine and streams data from processors.
-func (e *Executor) execute(out chan *Row) {
-	// Ensure the transaction closes after execution.
-	defer e.tx.Close()
+// Close stops a running execution. Rows which have not been sent are
+// discarded and the row channel is closed.
+func (e *Executor) Close() {
+	e.once.Do(func() { close(e.closing) })
+}
 
-	// TODO: Support multi-value rows.
+// executeStream runs in a separate goroutine and sends rows of at most
+// ChunkSize values as soon as all processors have emitted their values.
+// Offset and limit are applied to each series as its values are sent.
+func (e *Executor) executeStream(out chan *Row) {
+	defer close(out)
 
-	// Initialize map of rows by encoded tagset.
 	rows := make(map[string]*Row)
+	lookup := make(map[string]map[valueKey][]interface{})
+	counts := make(map[string]int) // values passed to limitStream by tagset
+
+	// send sends a row with the given values of a series.
+	send := func(row *Row, tagset string, values [][]interface{}) bool {
+		n := counts[tagset]
+		counts[tagset] += len(values)
+		if values = e.limitStream(values, n); len(values) == 0 {
+			return true
+		}
+		for _, v := range values {
+			delete(lookup[tagset], e.valueKey(v))
+			v[0] = time.Unix(0, v[0].(int64)).UTC().Format(time.RFC3339Nano)
+		}
+
+		other := *row
+		other.Values = values
+		select {
+		case out <- &other:
+			e.rowN++
+			return true
+		case <-e.closing:
+			return false
+		}
+	}
+
+	ok := e.readProcessors(func(i int, m map[Key]interface{}, watermark int64) bool {
+		for k, v := range m {
+			tagset := e.rowTagset(k.Values)
+			values := e.createRowValuesIfNotExists(rows, lookup, e.processors[0].Name(), k.Timestamp, k.Values)
+			values[e.valueIndex(i)] = v
+
+			// Send full rows once no processor can add to their values.
+			row := rows[tagset]
+			for len(row.Values) >= e.ChunkSize && row.Values[e.ChunkSize-1][0].(int64) < watermark {
+				values := row.Values[:e.ChunkSize:e.ChunkSize]
+				row.Values = row.Values[e.ChunkSize:]
+				if !send(row, tagset, values) {
+					return false
+				}
+			}
+		}
+		return true
+	})
+	if !ok {
+		e.cancel()
+		return
+	}
+
+	// Send the remaining values of each series.
+	a := make(Rows, 0, len(rows))
+	tagsets := make(map[*Row]string, len(rows))
+	for tagset, row := range rows {
+		a = append(a, row)
+		tagsets[row] = tagset
+	}
+	sort.Sort(a)
+	for _, row := range a {
+		if !send(row, tagsets[row], row.Values) {
+			e.cancel()
+			return
+		}
+	}
+
+	e.tx.Close()
+	e.executeElapsed = time.Since(e.executeStart)
+}
 
-	// Combine values from each processor.
-loop:
+// readProcessors merges the output of the processors by timestamp. It keeps
+// one buffered set of values per processor, blocking on each processor in
+// turn to refill it, and calls fn with the buffered set that has the lowest
+// timestamp. The watermark passed to fn is the lowest timestamp that any
+// processor can still emit. Returns false if the execution is closed or fn
+// returns false.
+//
+// Reading the processors in lockstep is what keeps the buffers of a shared
+// shardReader bounded. Every point the reader decodes is buffered for each
+// field's iterator until that iterator returns it. A processor is only read
+// again once its values are the oldest, so no field runs far ahead of the
+// others and leaves the rest of the points it read buffered.
+func (e *Executor) readProcessors(fn func(i int, m map[Key]interface{}, watermark int64) bool) bool {
+	heads := make([]map[Key]interface{}, len(e.processors))
+	closed := make([]bool, len(e.processors))
 	for {
-		// Retrieve values from processors and write them to the approprite
-		// row based on their tagset.
+		select {
+		case <-e.closing:
+			return false
+		default:
+		}
+
+		// Read the next values from each processor that has none buffered.
 		for i, p := range e.processors {
-			// Retrieve data from the processor.
-			m, ok := <-p.C()
-			if !ok {
-				break loop
+			if heads[i] == nil && !closed[i] {
+				m, ok := <-p.C()
+				heads[i], closed[i] = m, !ok
 			}
+		}
 
-			// Set values on returned row.
-			for k, v := range m {
-				// Lookup row values and populate data.
-				values := e.createRowValuesIfNotExists(rows, e.processors[0].Name(), k.Timestamp, k.Values)
-				values[i+1] = v
+		// Pass on the buffered values with the lowest timestamp.
+		min, watermark := -1, int64(0)
+		for i, m := range heads {
+			if m == nil {
+				continue
+			}
+			if t := minTimestamp(m); min == -1 || t < watermark {
+				min, watermark = i, t
 			}
 		}
+		if min == -1 {
+			return true
+		} else if !fn(min, heads[min], watermark) {
+			return false
+		}
+		heads[min] = nil
+	}
+}
+
+// cancel closes the transaction and discards the remaining output of the
+// processors so that their goroutines exit.
+func (e *Executor) cancel() {
+	e.tx.Close()
+	for _, p := range e.processors {
+		for range p.C() {
+		}
+	}
+}
+
+// limitStream applies the statement's offset and limit to values of a series
+// ordered by ascending time. n is the number of values the series had before them.
+func (e *Executor) limitStream(values [][]interface{}, n int) [][]interface{} {
+	if i := e.stmt.Offset - n; i >= len(values) {
+		return nil
+	} else if i > 0 {
+		values, n = values[i:], n+i
+	}
+	if e.stmt.Limit > 0 {
+		if i := e.stmt.Offset + e.stmt.Limit - n; i <= 0 {
+			return nil
+		} else if i < len(values) {
+			values = values[:i]
+		}
+	}
+	return values
+}
+
+// minTimestamp returns the lowest timestamp of a set of processor values.
+// Returns math.MinInt64 if there are no values.
+func minTimestamp(m map[Key]interface{}) int64 {
+	min := int64(math.MaxInt64)
+	for k := range m {
+		if k.Timestamp < min {
+			min = k.Timestamp
+		}
+	}
+	if len(m) == 0 {
+		return math.MinInt64
+	}
+	return min
+}
+
+// execute runs in a separate separate goroutine and streams data from processors.
+func (e *Executor) execute(out chan *Row) {
+	// Ensure the transaction closes after execution.
+	defer e.tx.Close()
+
+	// Initialize map of rows by encoded tagset and the lookup of
+	// row values by tagset and timestamp.
+	rows := make(map[string]*Row)
+	lookup := make(map[string]map[valueKey][]interface{})
+
+	// Combine values from each processor. Values from different processors
+	// are aligned on their timestamp. Processors are merged in time order so
+	// that fields sharing a shard reader don't buffer each other's points.
+	ok := e.readProcessors(func(i int, m map[Key]interface{}, watermark int64) bool {
+		// Set values on returned row.
+		for k, v := range m {
+			// Lookup row values and populate data.
+			values := e.createRowValuesIfNotExists(rows, lookup, e.processors[0].Name(), k.Timestamp, k.Values)
+			values[e.valueIndex(i)] = v
+		}
+		return true
+	})
+	if !ok {
+		e.cancel()
+		close(out)
+		return
 	}
 
 	// Normalize rows and values.
 	// Convert all times to timestamps
+	var err error
 	a := make(Rows, 0, len(rows))
 	for _, row := range rows {
+		// Processors emit values in time order but values that are only
+		// emitted by later processors are appended to the end of the row.
+		// Values of a merge with the same timestamp keep the order they were emitted in.
+		sort.Stable(valuesByTime(row.Values))
+
+		// Fill or omit intervals without values.
+		if e.interval > 0 {
+			if e.stmt.Fill == NoFill {
+				row.Values = omitEmptyValues(row.Values)
+			} else if row.Values, err = e.fillValues(row.Values); err != nil {
+				e.sendError(out, err)
+				return
+			}
+		}
+
+		// Apply ordering, offset & limit to each row series.
+		if row.Values = e.limitValues(row.Values); len(row.Values) == 0 {
+			continue
+		}
+
 		for _, values := range row.Values {
 			t := time.Unix(0, values[0].(int64))
 			values[0] = t.UTC().Format(time.RFC3339Nano)
@@ -309,20 +738,189 @@ loop:
 		a = append(a, row)
 	}
 	sort.Sort(a)
+	e.executeElapsed, e.rowN = time.Since(e.executeStart), len(a)
 
-	// Send rows to the channel.
+	// Send rows to the channel until the execution is closed.
 	for _, row := range a {
-		out <- row
+		select {
+		case out <- row:
+		case <-e.closing:
+			close(out)
+			return
+		}
 	}
 
 	// Mark the end of the output channel.
 	close(out)
 }
 
+// sendError sends a row with an error unless the execution is closed and
+// marks the end of the output channel.
+func (e *Executor) sendError(out chan *Row, err error) {
+	select {
+	case out <- &Row{Err: err}:
+	case <-e.closing:
+	}
+	close(out)
+}
+
+// limitValues orders a row's values by time and applies the statement's offset and limit.
+// Values are expected to be in ascending time order.
+func (e *Executor) limitValues(values [][]interface{}) [][]interface{} {
+	if !e.stmt.TimeAscending() {
+		for i, j := 0, len(values)-1; i < j; i, j = i+1, j-1 {
+			values[i], values[j] = values[j], values[i]
+		}
+	}
+
+	if e.stmt.Offset > 0 {
+		if e.stmt.Offset >= len(values) {
+			return nil
+		}
+		values = values[e.stmt.Offset:]
+	}
+	if e.stmt.Limit > 0 && len(values) > e.stmt.Limit {
+		values = values[:e.stmt.Limit]
+	}
+	return values
+}
+
+// fillValues returns a row's values with a value set for every interval
+// between the query's time range bounds. Values of intervals without points,
+// including intervals the processors emitted as null, are filled using the
+// statement's fill option. Values are expected to be in ascending time order.
+// Unbounded queries are only filled between the first and last intervals
+// that have values. Returns an error if more intervals than allowed would be filled.
+func (e *Executor) fillValues(values [][]interface{}) ([][]interface{}, error) {
+	if len(values) == 0 {
+		return values, nil
+	}
+
+	// Align the time range to the interval.
+	interval := e.interval.Nanoseconds()
+	tmin, tmax := values[0][0].(int64), values[len(values)-1][0].(int64)
+	if !e.tmin.IsZero() {
+		tmin = e.tmin.UnixNano() - (e.tmin.UnixNano() % interval)
+	}
+	if !e.tmax.IsZero() {
+		tmax = e.tmax.UnixNano()
+	}
+	if n := fillN(tmin, tmax, interval); n > int64(e.maxFillN) {
+		return nil, fmt.Errorf("too many intervals to fill: %d, max %d", n, e.maxFillN)
+	}
+
+	// Add an empty value set for every missing interval.
+	a := make([][]interface{}, 0, len(values))
+	i := 0
+	for t := tmin; t <= tmax; t += interval {
+		for ; i < len(values) && values[i][0].(int64) <= t; i++ {
+			a = append(a, values[i])
+		}
+		if len(a) == 0 || a[len(a)-1][0].(int64) != t {
+			empty := make([]interface{}, len(e.processors)+1)
+			empty[0] = t
+			a = append(a, empty)
+		}
+	}
+	a = append(a, values[i:]...)
+
+	// Fill the null values of each column. Linear fill interpolates between
+	// the previous and next values set, so the index of the next value set
+	// is found for every value by walking the column backwards first.
+	var next []int
+	if e.stmt.Fill == LinearFill {
+		next = make([]int, len(a))
+	}
+	for j := 1; j < len(e.processors)+1; j++ {
+		if next != nil {
+			n := -1
+			for i := len(a) - 1; i >= 0; i-- {
+				next[i] = n
+				if a[i][j] != nil {
+					n = i
+				}
+			}
+		}
+
+		prev := -1
+		for i := range a {
+			if a[i][j] != nil {
+				prev = i
+				continue
+			}
+
+			switch e.stmt.Fill {
+			case NumberFill:
+				a[i][j] = e.stmt.FillValue
+			case PreviousFill:
+				if prev >= 0 {
+					a[i][j] = a[prev][j]
+				}
+			case LinearFill:
+				if n := next[i]; prev >= 0 && n >= 0 {
+					a[i][j] = interpolate(a[i][0].(int64), a[prev][0].(int64), a[prev][j], a[n][0].(int64), a[n][j])
+				}
+			}
+		}
+	}
+
+	return a, nil
+}
+
+// fillN returns the number of intervals between two timestamps once the
+// lower bound is aligned to the interval.
+func fillN(tmin, tmax, interval int64) int64 {
+	tmin -= tmin % interval
+	if tmax < tmin {
+		return 0
+	}
+	return (tmax-tmin)/interval + 1
+}
+
+// omitEmptyValues returns a row's values without the value sets that only
+// have null values.
+func omitEmptyValues(values [][]interface{}) [][]interface{} {
+	a := values[:0]
+	for _, v := range values {
+		for _, vv := range v[1:] {
+			if vv != nil {
+				a = append(a, v)
+				break
+			}
+		}
+	}
+	return a
+}
+
+// interpolate returns the value at time t on the line between two numeric
+// values. Returns nil if either value is not numeric.
+func interpolate(t, t0 int64, v0 interface{}, t1 int64, v1 interface{}) interface{} {
+	f0, ok0 := float64Value(v0)
+	f1, ok1 := float64Value(v1)
+	if !ok0 || !ok1 {
+		return nil
+	}
+	return f0 + (f1-f0)*float64(t-t0)/float64(t1-t0)
+}
+
+// valuesByTime sorts row values by their timestamp.
+type valuesByTime [][]interface{}
+
+func (a valuesByTime) Len() int           { return len(a) }
+func (a valuesByTime) Less(i, j int) bool { return a[i][0].(int64) < a[j][0].(int64) }
+func (a valuesByTime) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
 // creates a new value set if one does not already exist for a given tagset + timestamp.
-func (e *Executor) createRowValuesIfNotExists(rows map[string]*Row, name string, timestamp int64, tagset string) []interface{} {
+func (e *Executor) createRowValuesIfNotExists(rows map[string]*Row, lookup map[string]map[valueKey][]interface{}, name string, timestamp int64, tagset string) []interface{} {
 	// TODO: Add "name" to lookup key.
 
+	// Separate the measurement of a merge's raw values from the row's tagset.
+	key := valueKey{timestamp: timestamp}
+	if e.merged {
+		key.measurement = lastString(tagset)
+		tagset = e.rowTagset(tagset)
+	}
+
 	// Find row by tagset.
 	var row *Row
 	if row = rows[tagset]; row == nil {
@@ -335,8 +933,11 @@ func (e *Executor) createRowValuesIfNotExists(rows map[string]*Row, name string,
 		}
 
 		// Create column names.
-		row.Columns = make([]string, 1, len(e.stmt.Fields)+1)
+		row.Columns = make([]string, 1, len(e.stmt.Fields)+2)
 		row.Columns[0] = "time"
+		if e.merged {
+			row.Columns = append(row.Columns, "measurement")
+		}
 		for i, f := range e.stmt.Fields {
 			name := f.Name()
 			if name == "" {
@@ -349,14 +950,220 @@ func (e *Executor) createRowValuesIfNotExists(rows map[string]*Row, name string,
 		rows[tagset] = row
 	}
 
-	// If no values exist or last value doesn't match the timestamp then create new.
-	if len(row.Values) == 0 || row.Values[len(row.Values)-1][0] != timestamp {
-		values := make([]interface{}, len(e.processors)+1)
+	// Find the value set for the timestamp or create a new one.
+	m := lookup[tagset]
+	if m == nil {
+		m = make(map[valueKey][]interface{})
+		lookup[tagset] = m
+	}
+	values := m[key]
+	if values == nil {
+		values = make([]interface{}, len(row.Columns))
 		values[0] = timestamp
+		if e.merged {
+			values[1] = key.measurement
+		}
 		row.Values = append(row.Values, values)
+		m[key] = values
 	}
 
-	return row.Values[len(row.Values)-1]
+	return values
+}
+
+// valueKey identifies a set of row values by their timestamp and, for the
+// raw values of a merge, by the measurement they were read from.
+type valueKey struct {
+	timestamp   int64
+	measurement string
+}
+
+// valueKey returns the key of a set of row values.
+func (e *Executor) valueKey(values []interface{}) valueKey {
+	key := valueKey{timestamp: values[0].(int64)}
+	if e.merged {
+		key.measurement = values[1].(string)
+	}
+	return key
+}
+
+// valueIndex returns the index of processor i's value in a set of row values.
+func (e *Executor) valueIndex(i int) int {
+	if e.merged {
+		return i + 2
+	}
+	return i + 1
+}
+
+// rowTagset returns the tagset of the row that values with key tags belong to.
+// The raw values of a merge carry their measurement after the row's tagset.
+func (e *Executor) rowTagset(tags string) string {
+	if !e.merged {
+		return tags
+	}
+	return tags[:len(tags)-2-len(lastString(tags))]
+}
+
+// lastString returns the last string of an encoded tagset.
+func lastString(tags string) string {
+	a := UnmarshalStrings([]byte(tags))
+	if len(a) == 0 {
+		return ""
+	}
+	return a[len(a)-1]
+}
+
+// Explain returns the execution plan as a row with one line of text per
+// value. The plan lists the source, time range, tag sets and shards read
+// followed by the processors of each field. If analyze is true then the
+// executor must have been executed and the plan also reports the points
+// scanned and the time spent by each stage.
+func (e *Executor) Explain(analyze bool) *Row {
+	var lines []string
+	add := func(depth int, format string, a ...interface{}) {
+		lines = append(lines, strings.Repeat("  ", depth)+fmt.Sprintf(format, a...))
+	}
+
+	// Collect the tag sets and shards read by all iterators.
+	var tagsets, shards []string
+	for _, p := range e.processors {
+		walkMappers(p, func(m *Mapper) {
+			if tagset := e.formatTagset(m.itr.Tags()); !stringsContain(tagsets, tagset) {
+				tagsets = append(tagsets, tagset)
+			}
+			if itr, ok := m.itr.(ExplainedIterator); ok {
+				if id := fmt.Sprint(itr.Explain().ShardID); !stringsContain(shards, id) {
+					shards = append(shards, id)
+				}
+			}
+		})
+	}
+	sort.Strings(tagsets)
+	sort.Strings(shards)
+	if len(shards) == 0 {
+		shards = []string{"none"}
+	}
+
+	// Describe the query.
+	tmin, tmax := TimeRange(e.stmt.Condition)
+	add(0, "statement: %s", e.stmt)
+	add(0, "source: %s", e.stmt.Source)
+	add(0, "time range: %s to %s", formatTimeBound(tmin, "unbounded"), formatTimeBound(tmax, "now"))
+	if e.interval > 0 {
+		add(0, "interval: %s", e.interval)
+	}
+	add(0, "tag sets: %s", strings.Join(tagsets, ", "))
+	add(0, "shards: %s", strings.Join(shards, ", "))
+	if analyze {
+		add(0, "planning: elapsed=%s", e.planElapsed)
+		add(0, "execution: rows=%d, elapsed=%s", e.rowN, e.executeElapsed)
+	}
+
+	// Describe the processors of each field.
+	for i, f := range e.stmt.Fields {
+		add(0, "field: %s", f)
+		e.explainProcessor(add, 1, e.processors[i], analyze)
+	}
+
+	row := &Row{Columns: []string{"plan"}}
+	for _, line := range lines {
+		row.Values = append(row.Values, []interface{}{line})
+	}
+	return row
+}
+
+// explainProcessor adds a line for a processor and its inputs.
+func (e *Executor) explainProcessor(add func(int, string, ...interface{}), depth int, p Processor, analyze bool) {
+	switch p := p.(type) {
+	case *Reducer:
+		if analyze {
+			add(depth, "reduce: %s (elapsed=%s)", funcName(p.fn), p.elapsed)
+		} else {
+			add(depth, "reduce: %s", funcName(p.fn))
+		}
+
+		// Mappers are listed in order of their tags and shards.
+		var descs []string
+		for _, m := range p.mappers {
+			desc := fmt.Sprintf("map: %s (tags=%s", funcName(m.fn), e.formatTagset(m.itr.Tags()))
+			if itr, ok := m.itr.(ExplainedIterator); ok {
+				x := itr.Explain()
+				desc += fmt.Sprintf(", shard=%d, series=%d", x.ShardID, x.SeriesN)
+				if analyze {
+					desc += fmt.Sprintf(", points=%d", x.PointN)
+				}
+			}
+			if analyze {
+				desc += fmt.Sprintf(", elapsed=%s", m.elapsed)
+			}
+			descs = append(descs, desc+")")
+		}
+		sort.Strings(descs)
+		for _, desc := range descs {
+			add(depth+1, "%s", desc)
+		}
+	case *transformProcessor:
+		add(depth, "transform: %s", p.name)
+		e.explainProcessor(add, depth+1, p.input, analyze)
+	case *binaryExprEvaluator:
+		add(depth, "expr: %s", p.op)
+		e.explainProcessor(add, depth+1, p.lhs, analyze)
+		e.explainProcessor(add, depth+1, p.rhs, analyze)
+	case *literalProcessor:
+		add(depth, "literal: %v", p.val)
+	}
+}
+
+// formatTagset returns an encoded tagset as a list of key/value pairs.
+func (e *Executor) formatTagset(tagset string) string {
+	if len(e.tags) == 0 {
+		return "*"
+	}
+	var a []string
+	for i, v := range UnmarshalStrings([]byte(tagset)) {
+		a = append(a, e.tags[i]+"="+v)
+	}
+	return strings.Join(a, ",")
+}
+
+// walkMappers calls fn for every mapper of a processor and its inputs.
+func walkMappers(p Processor, fn func(*Mapper)) {
+	switch p := p.(type) {
+	case *Reducer:
+		for _, m := range p.mappers {
+			fn(m)
+		}
+	case *transformProcessor:
+		walkMappers(p.input, fn)
+	case *binaryExprEvaluator:
+		walkMappers(p.lhs, fn)
+		walkMappers(p.rhs, fn)
+	}
+}
+
+// formatTimeBound returns a time formatted for a query plan or the given
+// text if the time is unset.
+func formatTimeBound(t time.Time, unset string) string {
+	if t.IsZero() {
+		return unset
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// funcName returns the name of a function without its package.
+// Functions returned by other functions are named by their parent.
+// Returns an empty string if the function can't be found.
+func funcName(fn interface{}) string {
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[:i]
+	}
+	return name
 }
 
 // Mapper represents an object for processing iterators.
@@ -364,6 +1171,8 @@ type Mapper struct {
 	fn       MapFunc  // map function
 	itr      Iterator // iterators
 	interval int64    // grouping interval
+
+	elapsed time.Duration // time spent mapping
 }
 
 // NewMapper returns a new instance of Mapper with a given function and interval.
@@ -387,6 +1196,9 @@ func (m *Mapper) run(e *Emitter) {
 	// Close emitter when we're done.
 	defer func() { _ = e.Close() }()
 
+	start := time.Now()
+	defer func() { m.elapsed = time.Since(start) }()
+
 	// Wrap iterator with buffer.
 	bufItr := &bufIterator{itr: m.itr}
 
@@ -432,6 +1244,14 @@ type bufIterator struct {
 // Tags returns the encoded dimensional values for the iterator.
 func (i *bufIterator) Tags() string { return i.itr.Tags() }
 
+// Measurement returns the measurement of the underlying iterator, if known.
+func (i *bufIterator) Measurement() string {
+	if itr, ok := i.itr.(MeasurementIterator); ok {
+		return itr.Measurement()
+	}
+	return ""
+}
+
 // Next returns the next key/value pair from the iterator.
 func (i *bufIterator) Next() (key int64, value interface{}) {
 	// Read the key/value pair off the buffer or underlying iterator.
@@ -477,11 +1297,25 @@ func MapCount(itr Iterator, e *Emitter, tmin int64) {
 func MapSum(itr Iterator, e *Emitter, tmin int64) {
 	n := float64(0)
 	for k, v := itr.Next(); k != 0; k, v = itr.Next() {
-		n += v.(float64)
+		if f, ok := float64Value(v); ok {
+			n += f
+		}
 	}
 	e.Emit(Key{tmin, itr.Tags()}, n)
 }
 
+// float64Value returns v as a float64 if it holds a numeric value.
+func float64Value(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
 // Processor represents an object for joining reducer output.
 type Processor interface {
 	Process()
@@ -493,8 +1327,9 @@ type Processor interface {
 // Implements processor.
 type Reducer struct {
 	name    string
-	fn      ReduceFunc // reduce function
-	mappers []*Mapper  // child mappers
+	fn      ReduceFunc    // reduce function
+	mappers []*Mapper     // child mappers
+	elapsed time.Duration // time spent reducing
 
 	c <-chan map[Key]interface{}
 }
@@ -533,6 +1368,9 @@ func (r *Reducer) run(e *Emitter, inputs []<-chan map[Key]interface{}) {
 	// Close emitter when we're done.
 	defer func() { _ = e.Close() }()
 
+	start := time.Now()
+	defer func() { r.elapsed = time.Since(start) }()
+
 	// Buffer all the inputs.
 	bufInputs := make([]*bufInput, len(inputs))
 	for i, input := range inputs {
@@ -641,8 +1479,12 @@ func MapMean(itr Iterator, e *Emitter, tmin int64) {
 	out := &meanMapOutput{}
 
 	for k, v := itr.Next(); k != 0; k, v = itr.Next() {
+		f, ok := float64Value(v)
+		if !ok {
+			continue
+		}
 		out.Count++
-		out.Sum += v.(float64)
+		out.Sum += f
 	}
 	e.Emit(Key{tmin, itr.Tags()}, out)
 }
@@ -660,6 +1502,10 @@ func ReduceMean(key Key, values []interface{}, e *Emitter) {
 		out.Count += val.Count
 		out.Sum += val.Sum
 	}
+	if out.Count == 0 {
+		e.Emit(key, nil)
+		return
+	}
 	e.Emit(key, out.Sum/float64(out.Count))
 }
 
@@ -681,7 +1527,9 @@ func ReducePercentile(percentile float64) ReduceFunc {
 		for _, v := range values {
 			vals := v.([]interface{})
 			for _, v := range vals {
-				allValues = append(allValues, v.(float64))
+				if f, ok := float64Value(v); ok {
+					allValues = append(allValues, f)
+				}
 			}
 		}
 
@@ -691,18 +1539,246 @@ func ReducePercentile(percentile float64) ReduceFunc {
 
 		if index < 0 || index >= len(allValues) {
 			e.Emit(key, 0.0)
+			return
 		}
 
 		e.Emit(key, allValues[index])
 	}
 }
 
+// MapMin computes the minimum of values in an iterator.
+// Nothing is emitted if the iterator has no numeric values.
+func MapMin(itr Iterator, e *Emitter, tmin int64) {
+	var min float64
+	var found bool
+	for k, v := itr.Next(); k != 0; k, v = itr.Next() {
+		if f, ok := float64Value(v); ok && (!found || f < min) {
+			min, found = f, true
+		}
+	}
+	if found {
+		e.Emit(Key{tmin, itr.Tags()}, min)
+	}
+}
+
+// ReduceMin computes the minimum of values for each key.
+func ReduceMin(key Key, values []interface{}, e *Emitter) {
+	min := values[0].(float64)
+	for _, v := range values[1:] {
+		if f := v.(float64); f < min {
+			min = f
+		}
+	}
+	e.Emit(key, min)
+}
+
+// MapMax computes the maximum of values in an iterator.
+// Nothing is emitted if the iterator has no numeric values.
+func MapMax(itr Iterator, e *Emitter, tmin int64) {
+	var max float64
+	var found bool
+	for k, v := itr.Next(); k != 0; k, v = itr.Next() {
+		if f, ok := float64Value(v); ok && (!found || f > max) {
+			max, found = f, true
+		}
+	}
+	if found {
+		e.Emit(Key{tmin, itr.Tags()}, max)
+	}
+}
+
+// ReduceMax computes the maximum of values for each key.
+func ReduceMax(key Key, values []interface{}, e *Emitter) {
+	max := values[0].(float64)
+	for _, v := range values[1:] {
+		if f := v.(float64); f > max {
+			max = f
+		}
+	}
+	e.Emit(key, max)
+}
+
+type spreadMapOutput struct {
+	Min, Max float64
+}
+
+// MapSpread computes the minimum and maximum of values in an iterator.
+// Nothing is emitted if the iterator has no numeric values.
+func MapSpread(itr Iterator, e *Emitter, tmin int64) {
+	var out *spreadMapOutput
+	for k, v := itr.Next(); k != 0; k, v = itr.Next() {
+		f, ok := float64Value(v)
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = &spreadMapOutput{Min: f, Max: f}
+		}
+		out.Min = math.Min(out.Min, f)
+		out.Max = math.Max(out.Max, f)
+	}
+	if out != nil {
+		e.Emit(Key{tmin, itr.Tags()}, out)
+	}
+}
+
+// ReduceSpread computes the difference between the minimum and maximum of values for each key.
+func ReduceSpread(key Key, values []interface{}, e *Emitter) {
+	out := *values[0].(*spreadMapOutput)
+	for _, v := range values[1:] {
+		val := v.(*spreadMapOutput)
+		out.Min = math.Min(out.Min, val.Min)
+		out.Max = math.Max(out.Max, val.Max)
+	}
+	e.Emit(key, out.Max-out.Min)
+}
+
+type firstLastMapOutput struct {
+	Time int64
+	Val  interface{}
+}
+
+// MapFirst finds the value with the earliest timestamp in an iterator.
+// Values sharing a timestamp are ordered by lessValue.
+func MapFirst(itr Iterator, e *Emitter, tmin int64) {
+	var out *firstLastMapOutput
+	for k, v := itr.Next(); k != 0; k, v = itr.Next() {
+		if out == nil || k < out.Time || (k == out.Time && lessValue(v, out.Val)) {
+			out = &firstLastMapOutput{Time: k, Val: v}
+		}
+	}
+	if out != nil {
+		e.Emit(Key{tmin, itr.Tags()}, out)
+	}
+}
+
+// ReduceFirst computes the value with the earliest timestamp for each key.
+func ReduceFirst(key Key, values []interface{}, e *Emitter) {
+	out := values[0].(*firstLastMapOutput)
+	for _, v := range values[1:] {
+		val := v.(*firstLastMapOutput)
+		if val.Time < out.Time || (val.Time == out.Time && lessValue(val.Val, out.Val)) {
+			out = val
+		}
+	}
+	e.Emit(key, out.Val)
+}
+
+// MapLast finds the value with the latest timestamp in an iterator.
+// Values sharing a timestamp are ordered by lessValue.
+func MapLast(itr Iterator, e *Emitter, tmin int64) {
+	var out *firstLastMapOutput
+	for k, v := itr.Next(); k != 0; k, v = itr.Next() {
+		if out == nil || k > out.Time || (k == out.Time && lessValue(out.Val, v)) {
+			out = &firstLastMapOutput{Time: k, Val: v}
+		}
+	}
+	if out != nil {
+		e.Emit(Key{tmin, itr.Tags()}, out)
+	}
+}
+
+// ReduceLast computes the value with the latest timestamp for each key.
+func ReduceLast(key Key, values []interface{}, e *Emitter) {
+	out := values[0].(*firstLastMapOutput)
+	for _, v := range values[1:] {
+		val := v.(*firstLastMapOutput)
+		if val.Time > out.Time || (val.Time == out.Time && lessValue(out.Val, val.Val)) {
+			out = val
+		}
+	}
+	e.Emit(key, out.Val)
+}
+
+// lessValue returns true if a sorts before b. Numbers are compared by value
+// and all other values are compared by their string representation.
+// This is used to deterministically break ties between points sharing a timestamp.
+func lessValue(a, b interface{}) bool {
+	fa, aok := float64Value(a)
+	fb, bok := float64Value(b)
+	if aok && bok {
+		return fa < fb
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+// ReduceStddev computes the sample standard deviation of values for each key.
+// A nil value is emitted if there are fewer than two values.
+func ReduceStddev(key Key, values []interface{}, e *Emitter) {
+	data := float64Values(values)
+	if len(data) == 0 {
+		return
+	} else if len(data) < 2 {
+		e.Emit(key, nil)
+		return
+	}
+
+	// Compute the mean.
+	var mean float64
+	for _, f := range data {
+		mean += f
+	}
+	mean /= float64(len(data))
+
+	// Compute the variance from the squared differences to the mean.
+	var variance float64
+	for _, f := range data {
+		variance += (f - mean) * (f - mean)
+	}
+	variance /= float64(len(data) - 1)
+
+	e.Emit(key, math.Sqrt(variance))
+}
+
+// ReduceMedian computes the median of values for each key.
+func ReduceMedian(key Key, values []interface{}, e *Emitter) {
+	data := float64Values(values)
+	if len(data) == 0 {
+		return
+	}
+	sort.Float64s(data)
+
+	// Average the two middle values if there's an even number of values.
+	if n := len(data); n%2 == 0 {
+		e.Emit(key, (data[n/2-1]+data[n/2])/2)
+	} else {
+		e.Emit(key, data[n/2])
+	}
+}
+
+// float64Values returns the numeric values from a set of MapEcho outputs.
+func float64Values(values []interface{}) []float64 {
+	var a []float64
+	for _, v := range values {
+		for _, v := range v.([]interface{}) {
+			if f, ok := float64Value(v); ok {
+				a = append(a, f)
+			}
+		}
+	}
+	return a
+}
+
 func MapRawQuery(itr Iterator, e *Emitter, tmin int64) {
 	for k, v := itr.Next(); k != 0; k, v = itr.Next() {
 		e.Emit(Key{k, itr.Tags()}, v)
 	}
 }
 
+// MapRawMergeQuery emits the raw values of an iterator of a merge. The name
+// of the iterator's measurement is appended to its tags so that values of
+// different measurements with the same timestamp aren't combined.
+func MapRawMergeQuery(itr Iterator, e *Emitter, tmin int64) {
+	var name string
+	if m, ok := itr.(MeasurementIterator); ok {
+		name = m.Measurement()
+	}
+	tags := itr.Tags() + string(MarshalStrings([]string{name}))
+	for k, v := itr.Next(); k != 0; k, v = itr.Next() {
+		e.Emit(Key{k, tags}, v)
+	}
+}
+
 type rawQueryMapOutput struct {
 	timestamp int64
 	value     interface{}
@@ -785,25 +1861,120 @@ func (e *binaryExprEvaluator) run() {
 
 // eval evaluates two values using the evaluator's operation.
 func (e *binaryExprEvaluator) eval(lhs, rhs interface{}) interface{} {
+	l, _ := float64Value(lhs)
+	r, _ := float64Value(rhs)
+
 	switch e.op {
 	case ADD:
-		return lhs.(float64) + rhs.(float64)
+		return l + r
 	case SUB:
-		return lhs.(float64) - rhs.(float64)
+		return l - r
 	case MUL:
-		return lhs.(float64) * rhs.(float64)
+		return l * r
 	case DIV:
-		rhs := rhs.(float64)
-		if rhs == 0 {
+		if r == 0 {
 			return float64(0)
 		}
-		return lhs.(float64) / rhs
+		return l / r
 	default:
 		// TODO: Validate operation & data types.
 		panic("invalid operation: " + e.op.String())
 	}
 }
 
+// transformPoint represents a single timestamped value passed to a transformFunc.
+type transformPoint struct {
+	Time  int64
+	Value float64
+}
+
+// transformFunc computes a value from the previous and current point of a series.
+// Returns false if no value should be emitted for the current point.
+type transformFunc func(prev, curr *transformPoint) (interface{}, bool)
+
+// transformDerivative returns a transformFunc that computes the rate of change per unit.
+// Negative rates are dropped if nonNegative is set.
+func transformDerivative(unit time.Duration, nonNegative bool) transformFunc {
+	return func(prev, curr *transformPoint) (interface{}, bool) {
+		elapsed := curr.Time - prev.Time
+		if elapsed <= 0 {
+			return nil, false
+		}
+
+		v := (curr.Value - prev.Value) / (float64(elapsed) / float64(unit))
+		if nonNegative && v < 0 {
+			return nil, false
+		}
+		return v, true
+	}
+}
+
+// transformDifference computes the difference between the current and previous values.
+func transformDifference(prev, curr *transformPoint) (interface{}, bool) {
+	return curr.Value - prev.Value, true
+}
+
+// transformProcessor represents a processor that computes values from
+// consecutive points of each series emitted by an input processor.
+type transformProcessor struct {
+	name  string        // transformation function name
+	input Processor     // input processor
+	fn    transformFunc // transformation function
+
+	c chan map[Key]interface{}
+}
+
+// newTransformProcessor returns a new instance of transformProcessor.
+func newTransformProcessor(input Processor, fn transformFunc) *transformProcessor {
+	return &transformProcessor{
+		input: input,
+		fn:    fn,
+		c:     make(chan map[Key]interface{}, 0),
+	}
+}
+
+// Process begins streaming values from the input processor.
+func (p *transformProcessor) Process() {
+	p.input.Process()
+	go p.run()
+}
+
+// C returns the streaming data channel.
+func (p *transformProcessor) C() <-chan map[Key]interface{} { return p.c }
+
+// Name returns the source name.
+func (p *transformProcessor) Name() string { return p.input.Name() }
+
+// run reads the input processor and transforms each value against the previous
+// value with the same tagset. The input is ordered by time across all of its
+// iterators so values carry over between shard groups.
+func (p *transformProcessor) run() {
+	prev := make(map[string]*transformPoint)
+	for m := range p.input.C() {
+		// An empty map is still sent when nothing is emitted so that
+		// the output stays in step with other processors.
+		out := make(map[Key]interface{})
+		for k, v := range m {
+			f, ok := float64Value(v)
+			if !ok || math.IsNaN(f) {
+				continue
+			}
+
+			curr := &transformPoint{Time: k.Timestamp, Value: f}
+			if last := prev[k.Values]; last != nil {
+				if value, ok := p.fn(last, curr); ok {
+					out[k] = value
+				}
+			}
+			prev[k.Values] = curr
+		}
+		p.c <- out
+	}
+
+	// Mark the channel as complete.
+	close(p.c)
+}
+
 // literalProcessor represents a processor that continually sends a literal value.
 type literalProcessor struct {
 	val  interface{}
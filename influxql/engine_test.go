@@ -138,6 +138,52 @@ func TestPlanner_Plan_Mean(t *testing.T) {
 	}
 }
 
+// Ensure the planner can plan and execute selector and statistical aggregates across iterators.
+func TestPlanner_Plan_Aggregates(t *testing.T) {
+	for i, tt := range []struct {
+		name string
+		exp  string
+	}{
+		{name: "min", exp: `[["2000-01-01T00:00:00Z",50],["2000-01-01T00:01:00Z",50]]`},
+		{name: "max", exp: `[["2000-01-01T00:00:00Z",100],["2000-01-01T00:01:00Z",70]]`},
+		{name: "first", exp: `[["2000-01-01T00:00:00Z",80],["2000-01-01T00:01:00Z",70]]`},
+		{name: "last", exp: `[["2000-01-01T00:00:00Z",80],["2000-01-01T00:01:00Z",50]]`},
+		{name: "spread", exp: `[["2000-01-01T00:00:00Z",50],["2000-01-01T00:01:00Z",20]]`},
+		{name: "stddev", exp: `[["2000-01-01T00:00:00Z",16.73320053068151],["2000-01-01T00:01:00Z",10]]`},
+		{name: "median", exp: `[["2000-01-01T00:00:00Z",80],["2000-01-01T00:01:00Z",60]]`},
+	} {
+		tx := NewTx()
+		tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
+			return []influxql.Iterator{
+				NewIterator(nil, []Point{
+					{"2000-01-01T00:00:00Z", float64(100)},
+					{"2000-01-01T00:00:10Z", float64(90)},
+					{"2000-01-01T00:00:20Z", float64(50)},
+				}),
+				NewIterator(nil, []Point{
+					{"2000-01-01T00:00:00Z", float64(80)},
+					{"2000-01-01T00:00:10Z", float64(80)},
+					{"2000-01-01T00:00:20Z", float64(80)},
+				}),
+				NewIterator(nil, []Point{
+					{"2000-01-01T00:01:30Z", float64(70)},
+					{"2000-01-01T00:01:40Z", float64(60)},
+					{"2000-01-01T00:01:50Z", float64(50)},
+				})}, nil
+		}
+
+		// Expected resultset.
+		exp := minify(`[{"name":"cpu","columns":["time","` + tt.name + `"],"values":` + tt.exp + `}]`)
+
+		// Execute and compare.
+		rs := MustPlanAndExecute(NewDB(tx), `2000-01-01T12:00:00Z`,
+			`SELECT `+tt.name+`(value) FROM cpu WHERE time >= '2000-01-01' GROUP BY time(1m)`)
+		if act := minify(jsonify(rs)); exp != act {
+			t.Errorf("%d. %s: unexpected resultset: %s", i, tt.name, act)
+		}
+	}
+}
+
 // Ensure the planner can plan and execute a percentile query
 func TestPlanner_Plan_Percentile(t *testing.T) {
 	tx := NewTx()
@@ -217,6 +263,244 @@ func TestPlanner_Plan_RawData(t *testing.T) {
 	}
 }
 
+// Ensure the planner can order, offset and limit raw data.
+func TestPlanner_Plan_RawData_OrderByLimitOffset(t *testing.T) {
+	tx := NewTx()
+	tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
+		return []influxql.Iterator{
+			NewIterator(nil, []Point{
+				{"2000-01-01T00:00:00Z", float64(100)},
+				{"2000-01-01T00:00:20Z", float64(80)},
+			}),
+			NewIterator(nil, []Point{
+				{"2000-01-01T00:00:10Z", float64(90)},
+				{"2000-01-01T00:00:30Z", float64(70)},
+			})}, nil
+	}
+
+	for i, tt := range []struct {
+		q   string
+		exp string
+	}{
+		{
+			q:   `SELECT value FROM cpu ORDER BY time DESC LIMIT 1`,
+			exp: `[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:30Z",70]]}]`,
+		},
+		{
+			q:   `SELECT value FROM cpu LIMIT 2 OFFSET 1`,
+			exp: `[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:10Z",90],["2000-01-01T00:00:20Z",80]]}]`,
+		},
+		{
+			q:   `SELECT value FROM cpu ORDER BY time DESC OFFSET 3`,
+			exp: `[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00Z",100]]}]`,
+		},
+		{
+			q:   `SELECT value FROM cpu LIMIT 1 OFFSET 4`,
+			exp: `null`,
+		},
+	} {
+		rs := MustPlanAndExecute(NewDB(tx), `2000-01-01T12:00:00Z`, tt.q)
+		if act := minify(jsonify(rs)); tt.exp != act {
+			t.Errorf("%d. %s: unexpected resultset: %s", i, tt.q, act)
+		}
+	}
+}
+
+// Ensure the executor streams raw data in rows of at most the chunk size.
+func TestExecutor_Execute_ChunkSize(t *testing.T) {
+	tx := NewTx()
+	tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
+		return []influxql.Iterator{
+			NewIterator([]string{"serverA"}, []Point{
+				{"2000-01-01T00:00:00Z", float64(100)},
+				{"2000-01-01T00:00:20Z", float64(80)},
+				{"2000-01-01T00:00:40Z", float64(60)},
+			}),
+			NewIterator([]string{"serverB"}, []Point{
+				{"2000-01-01T00:00:10Z", float64(90)},
+			})}, nil
+	}
+
+	p := influxql.NewPlanner(NewDB(tx))
+	e, err := p.Plan(MustParseSelectStatement(`SELECT value FROM cpu GROUP BY host`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.ChunkSize = 2
+	ch, err := e.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rs []*influxql.Row
+	for row := range ch {
+		rs = append(rs, row)
+	}
+
+	exp := minify(`[
+		{"name":"cpu","tags":{"host":"serverA"},"columns":["time","value"],"values":[["2000-01-01T00:00:00Z",100],["2000-01-01T00:00:20Z",80]]},
+		{"name":"cpu","tags":{"host":"serverA"},"columns":["time","value"],"values":[["2000-01-01T00:00:40Z",60]]},
+		{"name":"cpu","tags":{"host":"serverB"},"columns":["time","value"],"values":[["2000-01-01T00:00:10Z",90]]}
+	]`)
+	if act := minify(jsonify(rs)); exp != act {
+		t.Fatalf("unexpected resultset: %s", act)
+	}
+}
+
+// Ensure a closed execution stops sending rows and closes its transaction.
+func TestExecutor_Close(t *testing.T) {
+	var closed bool
+	tx := NewTx()
+	tx.CloseFunc = func() error { closed = true; return nil }
+	tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
+		return []influxql.Iterator{
+			NewIterator(nil, []Point{
+				{"2000-01-01T00:00:00Z", float64(100)},
+				{"2000-01-01T00:00:10Z", float64(90)},
+				{"2000-01-01T00:00:20Z", float64(80)},
+			})}, nil
+	}
+
+	p := influxql.NewPlanner(NewDB(tx))
+	e, err := p.Plan(MustParseSelectStatement(`SELECT value FROM cpu`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.ChunkSize = 1
+	ch, err := e.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Read the first row and close the execution.
+	<-ch
+	e.Close()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			if _, ok := <-ch; ok {
+				t.Fatal("expected row channel to be closed")
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for execution to stop")
+	}
+	if !closed {
+		t.Fatal("expected transaction to be closed")
+	}
+}
+
+// Ensure the planner can compute derivatives and differences across iterators.
+func TestPlanner_Plan_Transforms(t *testing.T) {
+	tx := NewTx()
+	tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
+		return []influxql.Iterator{
+			NewIterator(nil, []Point{
+				{"2000-01-01T00:00:00Z", float64(10)},
+				{"2000-01-01T00:00:10Z", float64(20)},
+				{"2000-01-01T00:00:20Z", float64(40)},
+			}),
+			NewIterator(nil, []Point{
+				{"2000-01-01T00:00:30Z", float64(30)},
+				{"2000-01-01T00:00:40Z", float64(50)},
+			})}, nil
+	}
+
+	for i, tt := range []struct {
+		q   string
+		exp string
+	}{
+		{
+			q:   `SELECT derivative(value) FROM cpu`,
+			exp: `[{"name":"cpu","columns":["time","derivative"],"values":[["2000-01-01T00:00:10Z",1],["2000-01-01T00:00:20Z",2],["2000-01-01T00:00:30Z",-1],["2000-01-01T00:00:40Z",2]]}]`,
+		},
+		{
+			q:   `SELECT derivative(value, 10s) FROM cpu`,
+			exp: `[{"name":"cpu","columns":["time","derivative"],"values":[["2000-01-01T00:00:10Z",10],["2000-01-01T00:00:20Z",20],["2000-01-01T00:00:30Z",-10],["2000-01-01T00:00:40Z",20]]}]`,
+		},
+		{
+			q:   `SELECT non_negative_derivative(value) FROM cpu`,
+			exp: `[{"name":"cpu","columns":["time","non_negative_derivative"],"values":[["2000-01-01T00:00:10Z",1],["2000-01-01T00:00:20Z",2],["2000-01-01T00:00:40Z",2]]}]`,
+		},
+		{
+			q:   `SELECT difference(value) FROM cpu`,
+			exp: `[{"name":"cpu","columns":["time","difference"],"values":[["2000-01-01T00:00:10Z",10],["2000-01-01T00:00:20Z",20],["2000-01-01T00:00:30Z",-10],["2000-01-01T00:00:40Z",20]]}]`,
+		},
+		{
+			q:   `SELECT derivative(mean(value), 20s) FROM cpu WHERE time >= '2000-01-01' GROUP BY time(20s)`,
+			exp: `[{"name":"cpu","columns":["time","derivative"],"values":[["2000-01-01T00:00:20Z",20],["2000-01-01T00:00:40Z",15]]}]`,
+		},
+		{
+			q:   `SELECT difference(max(value)) FROM cpu WHERE time >= '2000-01-01' GROUP BY time(20s)`,
+			exp: `[{"name":"cpu","columns":["time","difference"],"values":[["2000-01-01T00:00:20Z",20],["2000-01-01T00:00:40Z",10]]}]`,
+		},
+	} {
+		rs := MustPlanAndExecute(NewDB(tx), `2000-01-01T12:00:00Z`, tt.q)
+		if act := minify(jsonify(rs)); tt.exp != act {
+			t.Errorf("%d. %s: unexpected resultset: %s", i, tt.q, act)
+		}
+	}
+}
+
+// Ensure the planner returns an error for an invalid derivative unit.
+func TestPlanner_Plan_ErrDerivativeUnit(t *testing.T) {
+	p := influxql.NewPlanner(NewDB(NewTx()))
+	if _, err := p.Plan(MustParseSelectStatement(`SELECT derivative(value, 'x') FROM cpu`)); err == nil || err.Error() != "expected duration argument in derivative()" {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure the planner returns an error when ordering by a field other than time.
+func TestPlanner_Plan_ErrOrderByField(t *testing.T) {
+	p := influxql.NewPlanner(NewDB(NewTx()))
+	if _, err := p.Plan(MustParseSelectStatement(`SELECT value FROM cpu ORDER BY value DESC`)); err == nil || err.Error() != "only ORDER BY time supported: value" {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure the planner returns an error when a query fills too many intervals.
+func TestPlanner_Plan_ErrMaxFillN(t *testing.T) {
+	tx := NewTx()
+	tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) { return nil, nil }
+
+	p := influxql.NewPlanner(NewDB(tx))
+	p.MaxFillN = 10
+	if _, err := p.Plan(MustParseSelectStatement(`SELECT mean(value) FROM cpu WHERE time >= '2000-01-01 00:00:00' AND time < '2000-01-01 00:00:11' GROUP BY time(1s) fill(null)`)); err == nil || err.Error() != "too many intervals to fill: 11, max 10" {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := p.Plan(MustParseSelectStatement(`SELECT mean(value) FROM cpu WHERE time >= '2000-01-01 00:00:00' AND time < '2000-01-01 00:00:11' GROUP BY time(1s) fill(none)`)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure the executor returns an error when an unbounded query fills too many intervals.
+func TestExecutor_Execute_ErrMaxFillN(t *testing.T) {
+	tx := NewTx()
+	tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
+		return []influxql.Iterator{
+			NewIterator(nil, []Point{
+				{"2000-01-01T00:00:00Z", float64(10)},
+				{"2000-01-01T00:00:10Z", float64(20)},
+			}),
+		}, nil
+	}
+
+	p := influxql.NewPlanner(NewDB(tx))
+	p.MaxFillN = 10
+	e, err := p.Plan(MustParseSelectStatement(`SELECT mean(value) FROM cpu GROUP BY time(1s) fill(null)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch, err := e.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row := <-ch; row == nil || row.Err == nil || row.Err.Error() != "too many intervals to fill: 11, max 10" {
+		t.Fatalf("unexpected row: %#v", row)
+	} else if _, ok := <-ch; ok {
+		t.Fatal("expected closed channel")
+	}
+}
+
 // Ensure the planner can plan and execute a count query grouped by hour.
 func TestPlanner_Plan_GroupByInterval(t *testing.T) {
 	tx := NewTx()
@@ -312,15 +596,133 @@ func TestPlanner_Plan_GroupByIntervalAndTag(t *testing.T) {
 	}
 }
 
+// Ensure the planner fills intervals without points.
+func TestPlanner_Plan_GroupByInterval_Fill(t *testing.T) {
+	tx := NewTx()
+	tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
+		return []influxql.Iterator{
+			NewIterator([]string{"servera"}, []Point{
+				{"2000-01-01T09:00:00Z", float64(10)},
+				{"2000-01-01T10:00:00Z", float64(40)},
+			}),
+			NewIterator([]string{"serverb"}, []Point{
+				{"2000-01-01T10:30:00Z", float64(5)},
+			})}, nil
+	}
+
+	var tests = []struct {
+		fill string
+		exp  string
+	}{
+		{
+			fill: `none`,
+			exp:  `[{"name":"cpu","tags":{"host":"servera"},"columns":["time","mean"],"values":[["2000-01-01T09:00:00Z",10],["2000-01-01T10:00:00Z",40]]},{"name":"cpu","tags":{"host":"serverb"},"columns":["time","mean"],"values":[["2000-01-01T10:30:00Z",5]]}]`,
+		},
+		{
+			fill: `null`,
+			exp:  `[{"name":"cpu","tags":{"host":"servera"},"columns":["time","mean"],"values":[["2000-01-01T08:30:00Z",null],["2000-01-01T09:00:00Z",10],["2000-01-01T09:30:00Z",null],["2000-01-01T10:00:00Z",40],["2000-01-01T10:30:00Z",null]]},{"name":"cpu","tags":{"host":"serverb"},"columns":["time","mean"],"values":[["2000-01-01T08:30:00Z",null],["2000-01-01T09:00:00Z",null],["2000-01-01T09:30:00Z",null],["2000-01-01T10:00:00Z",null],["2000-01-01T10:30:00Z",5]]}]`,
+		},
+		{
+			fill: `-1`,
+			exp:  `[{"name":"cpu","tags":{"host":"servera"},"columns":["time","mean"],"values":[["2000-01-01T08:30:00Z",-1],["2000-01-01T09:00:00Z",10],["2000-01-01T09:30:00Z",-1],["2000-01-01T10:00:00Z",40],["2000-01-01T10:30:00Z",-1]]},{"name":"cpu","tags":{"host":"serverb"},"columns":["time","mean"],"values":[["2000-01-01T08:30:00Z",-1],["2000-01-01T09:00:00Z",-1],["2000-01-01T09:30:00Z",-1],["2000-01-01T10:00:00Z",-1],["2000-01-01T10:30:00Z",5]]}]`,
+		},
+		{
+			fill: `previous`,
+			exp:  `[{"name":"cpu","tags":{"host":"servera"},"columns":["time","mean"],"values":[["2000-01-01T08:30:00Z",null],["2000-01-01T09:00:00Z",10],["2000-01-01T09:30:00Z",10],["2000-01-01T10:00:00Z",40],["2000-01-01T10:30:00Z",40]]},{"name":"cpu","tags":{"host":"serverb"},"columns":["time","mean"],"values":[["2000-01-01T08:30:00Z",null],["2000-01-01T09:00:00Z",null],["2000-01-01T09:30:00Z",null],["2000-01-01T10:00:00Z",null],["2000-01-01T10:30:00Z",5]]}]`,
+		},
+		{
+			fill: `linear`,
+			exp:  `[{"name":"cpu","tags":{"host":"servera"},"columns":["time","mean"],"values":[["2000-01-01T08:30:00Z",null],["2000-01-01T09:00:00Z",10],["2000-01-01T09:30:00Z",25],["2000-01-01T10:00:00Z",40],["2000-01-01T10:30:00Z",null]]},{"name":"cpu","tags":{"host":"serverb"},"columns":["time","mean"],"values":[["2000-01-01T08:30:00Z",null],["2000-01-01T09:00:00Z",null],["2000-01-01T09:30:00Z",null],["2000-01-01T10:00:00Z",null],["2000-01-01T10:30:00Z",5]]}]`,
+		},
+	}
+
+	for i, tt := range tests {
+		rs := MustPlanAndExecute(NewDB(tx), "2000-01-01T12:00:00Z", `
+			SELECT mean(value)
+			FROM cpu
+			WHERE time >= '2000-01-01 08:30:00' AND time < '2000-01-01 11:00:00'
+			GROUP BY time(30m), host fill(`+tt.fill+`)`)
+		if act := jsonify(rs); tt.exp != act {
+			t.Errorf("%d. fill(%s): unexpected resultset:\n\nexp=%s\n\ngot=%s\n\n", i, tt.fill, tt.exp, act)
+		}
+	}
+}
+
+// Ensure the planner interpolates across several intervals without points.
+func TestPlanner_Plan_GroupByInterval_LinearFill(t *testing.T) {
+	tx := NewTx()
+	tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
+		return []influxql.Iterator{
+			NewIterator(nil, []Point{
+				{"2000-01-01T09:00:00Z", float64(10)},
+				{"2000-01-01T10:30:00Z", float64(40)},
+				{"2000-01-01T11:00:00Z", float64(20)},
+			})}, nil
+	}
+
+	rs := MustPlanAndExecute(NewDB(tx), "2000-01-01T12:00:00Z", `
+		SELECT mean(value)
+		FROM cpu
+		WHERE time >= '2000-01-01 08:30:00' AND time < '2000-01-01 12:00:00'
+		GROUP BY time(30m) fill(linear)`)
+	if act, exp := jsonify(rs), `[{"name":"cpu","columns":["time","mean"],"values":[["2000-01-01T08:30:00Z",null],["2000-01-01T09:00:00Z",10],["2000-01-01T09:30:00Z",20],["2000-01-01T10:00:00Z",30],["2000-01-01T10:30:00Z",40],["2000-01-01T11:00:00Z",20],["2000-01-01T11:30:00Z",null]]}]`; exp != act {
+		t.Fatalf("unexpected resultset:\n\nexp=%s\n\ngot=%s\n\n", exp, act)
+	}
+}
+
+// Ensure the executor can explain the processors of each field.
+func TestExecutor_Explain(t *testing.T) {
+	tx := NewTx()
+	tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
+		return []influxql.Iterator{
+			NewIterator([]string{"servera"}, []Point{{"2000-01-01T00:00:00Z", float64(10)}}),
+			NewIterator([]string{"serverb"}, []Point{{"2000-01-01T00:00:00Z", float64(20)}}),
+		}, nil
+	}
+
+	p := influxql.NewPlanner(NewDB(tx))
+	p.Now = func() time.Time { return mustParseTime("2000-01-01T01:00:00Z") }
+	e, err := p.Plan(MustParseSelectStatement(`SELECT derivative(max(value)), percentile(value, 90) + 1 FROM cpu WHERE time >= now() - 1h GROUP BY time(10m), host`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for _, values := range e.Explain(false).Values {
+		lines = append(lines, values[0].(string))
+	}
+	if act, exp := strings.Join(lines, "\n"), strings.Join([]string{
+		`statement: SELECT derivative(max(value)), percentile(value, 90.000) + 1.000 FROM cpu WHERE time >= "2000-01-01 00:00:00" GROUP BY time(10m), host`,
+		`source: cpu`,
+		`time range: 2000-01-01T00:00:00Z to now`,
+		`interval: 10m0s`,
+		`tag sets: host=servera, host=serverb`,
+		`shards: none`,
+		`field: derivative(max(value))`,
+		`  transform: derivative`,
+		`    reduce: ReduceMax`,
+		`      map: MapMax (tags=host=servera)`,
+		`      map: MapMax (tags=host=serverb)`,
+		`field: percentile(value, 90.000) + 1.000`,
+		`  expr: +`,
+		`    reduce: ReducePercentile`,
+		`      map: MapEcho (tags=host=servera)`,
+		`      map: MapEcho (tags=host=serverb)`,
+		`    literal: 1`,
+	}, "\n"); act != exp {
+		t.Fatalf("unexpected plan:\n\nexp=%s\n\ngot=%s", exp, act)
+	}
+}
+
 // Ensure the planner sends the correct simplified statements to the iterator creator.
 func TestPlanner_CreateIterators(t *testing.T) {
 	var flag0, flag1 bool
 	tx := NewTx()
 	tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
 		switch stmt.String() {
-		case `SELECT cpu.0.value FROM cpu.0 GROUP BY time(10s)`:
+		case `SELECT cpu.0.value FROM cpu.0 WHERE time >= "2000-01-01 00:00:00" AND time < "2000-01-01 00:01:00" GROUP BY time(10s)`:
 			flag0 = true
-		case `SELECT cpu.1.value FROM cpu.1 GROUP BY time(10s)`:
+		case `SELECT cpu.1.value FROM cpu.1 WHERE time >= "2000-01-01 00:00:00" AND time < "2000-01-01 00:01:00" GROUP BY time(10s)`:
 			flag1 = true
 		default:
 			t.Fatalf("unexpected stmt passed to iterator creator: %s", stmt.String())
@@ -87,11 +87,38 @@ func (p *Parser) ParseStatement() (Statement, error) {
 		return p.parseRevokeStatement()
 	case ALTER:
 		return p.parseAlterStatement()
+	case EXPLAIN:
+		return p.parseExplainStatement()
 	default:
 		return nil, newParseError(tokstr(tok, lit), []string{"SELECT"}, pos)
 	}
 }
 
+// parseExplainStatement parses a string and returns an ExplainStatement.
+// This function assumes the EXPLAIN token has already been consumed.
+func (p *Parser) parseExplainStatement() (*ExplainStatement, error) {
+	stmt := &ExplainStatement{}
+
+	// Parse optional ANALYZE token.
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == ANALYZE {
+		stmt.Analyze = true
+	} else {
+		p.unscan()
+	}
+
+	// Parse the select statement.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != SELECT {
+		return nil, newParseError(tokstr(tok, lit), []string{"SELECT"}, pos)
+	}
+	s, err := p.parseSelectStatement(targetNotAllowed)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Statement = s
+
+	return stmt, nil
+}
+
 // parseShowStatement parses a string and returns a list statement.
 // This function assumes the SHOW token has already been consumed.
 func (p *Parser) parseShowStatement() (Statement, error) {
@@ -238,6 +265,17 @@ func (p *Parser) parseCreateRetentionPolicyStatement() (*CreateRetentionPolicySt
 	}
 	stmt.Replication = n
 
+	// Parse optional SHARD DURATION.
+	if tok, _, _ = p.scanIgnoreWhitespace(); tok == SHARD {
+		d, err := p.parseShardDuration()
+		if err != nil {
+			return nil, err
+		}
+		stmt.ShardGroupDuration = d
+	} else {
+		p.unscan()
+	}
+
 	// Parse optional DEFAULT token.
 	if tok, pos, lit = p.scanIgnoreWhitespace(); tok == DEFAULT {
 		stmt.Default = true
@@ -248,6 +286,16 @@ func (p *Parser) parseCreateRetentionPolicyStatement() (*CreateRetentionPolicySt
 	return stmt, nil
 }
 
+// parseShardDuration parses the duration of a SHARD DURATION clause.
+// This function assumes the SHARD token has already been consumed.
+func (p *Parser) parseShardDuration() (time.Duration, error) {
+	// Consume the required DURATION token.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != DURATION {
+		return 0, newParseError(tokstr(tok, lit), []string{"DURATION"}, pos)
+	}
+	return p.parseDuration()
+}
+
 // parseAlterRetentionPolicyStatement parses a string and returns an alter retention policy statement.
 // This function assumes the ALTER RETENTION POLICY tokens have already been consumned.
 func (p *Parser) parseAlterRetentionPolicyStatement() (*AlterRetentionPolicyStatement, error) {
@@ -272,8 +320,8 @@ func (p *Parser) parseAlterRetentionPolicyStatement() (*AlterRetentionPolicyStat
 	}
 	stmt.Database = ident
 
-	// Loop through option tokens (DURATION, REPLICATION, DEFAULT, etc.).
-	maxNumOptions := 3
+	// Loop through option tokens (DURATION, REPLICATION, SHARD DURATION, DEFAULT, etc.).
+	maxNumOptions := 4
 Loop:
 	for i := 0; i < maxNumOptions; i++ {
 		tok, pos, lit := p.scanIgnoreWhitespace()
@@ -290,11 +338,17 @@ Loop:
 				return nil, err
 			}
 			stmt.Replication = &n
+		case SHARD:
+			d, err := p.parseShardDuration()
+			if err != nil {
+				return nil, err
+			}
+			stmt.ShardGroupDuration = &d
 		case DEFAULT:
 			stmt.Default = true
 		default:
 			if i < 1 {
-				return nil, newParseError(tokstr(tok, lit), []string{"DURATION", "RETENTION", "DEFAULT"}, pos)
+				return nil, newParseError(tokstr(tok, lit), []string{"DURATION", "RETENTION", "SHARD", "DEFAULT"}, pos)
 			}
 			p.unscan()
 			break Loop
@@ -528,6 +582,11 @@ func (p *Parser) parseSelectStatement(tr targetRequirement) (*SelectStatement, e
 		return nil, err
 	}
 
+	// Parse fill options: "fill(<option>)".
+	if stmt.Fill, stmt.FillValue, err = p.parseFill(); err != nil {
+		return nil, err
+	}
+
 	// Parse sort: "ORDER BY FIELD+".
 	if stmt.SortFields, err = p.parseOrderBy(); err != nil {
 		return nil, err
@@ -552,6 +611,7 @@ type targetRequirement int
 const (
 	targetRequired targetRequirement = iota
 	targetNotRequired
+	targetNotAllowed
 )
 
 // parseTarget parses a string and returns a Target.
@@ -562,9 +622,13 @@ func (p *Parser) parseTarget(tr targetRequirement) (*Target, error) {
 		}
 		p.unscan()
 		return nil, nil
+	} else if tr == targetNotAllowed {
+		return nil, &ParseError{Message: "INTO not allowed here", Pos: pos}
 	}
 
 	// Parse identifier.  Could be policy or measurement name.
+	_, pos, _ := p.scanIgnoreWhitespace()
+	p.unscan()
 	ident, err := p.parseIdent()
 	if err != nil {
 		return nil, err
@@ -573,12 +637,28 @@ func (p *Parser) parseTarget(tr targetRequirement) (*Target, error) {
 	target := &Target{}
 	target.Measurement = ident
 
+	// Move a database segment of the measurement to the target's database
+	// so that it's checked when authorizing the statement.
+	segments, err := SplitIdent(ident)
+	if err != nil {
+		return nil, &ParseError{Message: err.Error(), Pos: pos}
+	} else if len(segments) > 3 {
+		return nil, &ParseError{Message: "invalid target measurement: " + ident, Pos: pos}
+	} else if len(segments) == 3 {
+		target.Database, target.Measurement = segments[0], QuoteIdent(segments[1:])
+	}
+
 	// Parse optional ON.
 	if tok, _, _ := p.scanIgnoreWhitespace(); tok != ON {
 		p.unscan()
 		return target, nil
 	}
 
+	// A database can't be specified by both the measurement and ON.
+	if target.Database != "" {
+		return nil, &ParseError{Message: "target database specified twice: " + ident, Pos: pos}
+	}
+
 	// Found an ON token so parse required identifier.
 	if ident, err = p.parseIdent(); err != nil {
 		return nil, err
@@ -864,13 +944,27 @@ func (p *Parser) parseShowFieldKeysStatement() (*ShowFieldKeysStatement, error)
 // This function assumes the "DROP SERIES" tokens have already been consumed.
 func (p *Parser) parseDropSeriesStatement() (*DropSeriesStatement, error) {
 	stmt := &DropSeriesStatement{}
+	var err error
 
-	// Read the name of the series to drop.
-	lit, err := p.parseIdent()
-	if err != nil {
+	// Parse optional FROM.
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == FROM {
+		if stmt.Source, err = p.parseSource(); err != nil {
+			return nil, err
+		}
+	} else {
+		p.unscan()
+	}
+
+	// Parse condition: "WHERE EXPR".
+	if stmt.Condition, err = p.parseCondition(); err != nil {
 		return nil, err
 	}
-	stmt.Name = lit
+
+	// Require a source or a condition so all series aren't dropped by accident.
+	if stmt.Source == nil && stmt.Condition == nil {
+		tok, pos, lit := p.scanIgnoreWhitespace()
+		return nil, newParseError(tokstr(tok, lit), []string{"FROM", "WHERE"}, pos)
+	}
 
 	return stmt, nil
 }
@@ -1175,9 +1269,16 @@ func (p *Parser) parseAlias() (string, error) {
 
 // parseSource parses the "FROM" clause of the query.
 func (p *Parser) parseSource() (Source, error) {
-	// The first token can either be the series name or a join/merge call.
+	// The first token can either be the series name, a regex or a join/merge call.
 	tok, pos, lit := p.scanIgnoreWhitespace()
-	if tok != IDENT {
+	if tok == DIV {
+		p.unscan()
+		re, err := p.parseRegex()
+		if err != nil {
+			return nil, err
+		}
+		return &Measurement{Regex: re}, nil
+	} else if tok != IDENT {
 		return nil, newParseError(tokstr(tok, lit), []string{"identifier"}, pos)
 	}
 
@@ -1286,6 +1387,47 @@ func (p *Parser) parseDimension() (*Dimension, error) {
 	return &Dimension{Expr: expr}, nil
 }
 
+// parseFill parses the fill option of a "GROUP BY time()" clause, if it exists.
+func (p *Parser) parseFill() (FillOption, float64, error) {
+	// If the next token is not "fill" then exit.
+	if tok, _, lit := p.scanIgnoreWhitespace(); tok != IDENT || strings.ToLower(lit) != "fill" {
+		p.unscan()
+		return NoFill, 0, nil
+	}
+
+	if tok, pos, lit := p.scan(); tok != LPAREN {
+		return NoFill, 0, newParseError(tokstr(tok, lit), []string{"("}, pos)
+	}
+
+	// Parse the option.
+	var fill FillOption
+	var value float64
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	switch {
+	case tok == NUMBER:
+		v, err := strconv.ParseFloat(lit, 64)
+		if err != nil {
+			return NoFill, 0, &ParseError{Message: "unable to parse number", Pos: pos}
+		}
+		fill, value = NumberFill, v
+	case tok == IDENT && strings.ToLower(lit) == "null":
+		fill = NullFill
+	case tok == IDENT && strings.ToLower(lit) == "none":
+		fill = NoFill
+	case tok == IDENT && strings.ToLower(lit) == "previous":
+		fill = PreviousFill
+	case tok == IDENT && strings.ToLower(lit) == "linear":
+		fill = LinearFill
+	default:
+		return NoFill, 0, newParseError(tokstr(tok, lit), []string{"null", "none", "previous", "linear", "number"}, pos)
+	}
+
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != RPAREN {
+		return NoFill, 0, newParseError(tokstr(tok, lit), []string{")"}, pos)
+	}
+	return fill, value, nil
+}
+
 // parseOptionalTokenAndInt parses the specified token followed
 // by an int, if it exists.
 func (p *Parser) parseOptionalTokenAndInt(t Token) (int, error) {
@@ -1379,10 +1521,11 @@ func (p *Parser) parseSortField() (*SortField, error) {
 	tok, pos, lit := p.scanIgnoreWhitespace()
 	if tok == IDENT || tok == STRING {
 		field.Name = lit
-		// Check for optional ASC or DESC token.
+		// Check for optional ASC or DESC token. Fields default to ascending.
 		tok, pos, lit = p.scanIgnoreWhitespace()
 		if tok != ASC && tok != DESC {
 			p.unscan()
+			field.Ascending = true
 			return field, nil
 		}
 	} else if tok != ASC && tok != DESC {
@@ -1413,7 +1556,14 @@ func (p *Parser) ParseExpr() (Expr, error) {
 		}
 
 		// Otherwise parse the next unary expression.
-		rhs, err := p.parseUnaryExpr()
+		// Regular expression operators require a regex on the right side.
+		var rhs Expr
+		var err error
+		if op == EQREGEX || op == NEQREGEX {
+			rhs, err = p.parseRegex()
+		} else {
+			rhs, err = p.parseUnaryExpr()
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -1526,6 +1676,26 @@ func (p *Parser) parseCall(name string) (*Call, error) {
 	return &Call{Name: name, Args: args}, nil
 }
 
+// parseRegex parses a regular expression literal such as /cpu.*/.
+func (p *Parser) parseRegex() (*RegexLiteral, error) {
+	// Expect the opening slash.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != DIV {
+		return nil, newParseError(tokstr(tok, lit), []string{"regex"}, pos)
+	}
+
+	// Read the rest of the literal directly from the scanner.
+	tok, pos, lit := p.s.ScanRegex()
+	if tok == BADREGEX {
+		return nil, &ParseError{Message: "unterminated regex: /" + lit, Pos: pos}
+	}
+
+	re, err := regexp.Compile(lit)
+	if err != nil {
+		return nil, &ParseError{Message: "invalid regex: " + err.Error(), Pos: pos}
+	}
+	return &RegexLiteral{Val: re}, nil
+}
+
 // scan returns the next token from the underlying scanner.
 func (p *Parser) scan() (tok Token, pos Pos, lit string) { return p.s.Scan() }
 
@@ -2,6 +2,7 @@ package influxql_test
 
 import (
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -82,6 +83,33 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// SELECT statement with fill
+		{
+			s: `SELECT mean(value) FROM cpu GROUP BY time(1m) fill(-1.5)`,
+			stmt: &influxql.SelectStatement{
+				Fields: []*influxql.Field{{Expr: &influxql.Call{Name: "mean", Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}}}},
+				Source: &influxql.Measurement{Name: "cpu"},
+				Dimensions: []*influxql.Dimension{
+					{Expr: &influxql.Call{Name: "time", Args: []influxql.Expr{&influxql.DurationLiteral{Val: time.Minute}}}},
+				},
+				Fill:      influxql.NumberFill,
+				FillValue: -1.5,
+			},
+		},
+		{
+			s: `SELECT mean(value) FROM cpu GROUP BY time(1m), host FILL(previous) LIMIT 10`,
+			stmt: &influxql.SelectStatement{
+				Fields: []*influxql.Field{{Expr: &influxql.Call{Name: "mean", Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}}}},
+				Source: &influxql.Measurement{Name: "cpu"},
+				Dimensions: []*influxql.Dimension{
+					{Expr: &influxql.Call{Name: "time", Args: []influxql.Expr{&influxql.DurationLiteral{Val: time.Minute}}}},
+					{Expr: &influxql.VarRef{Val: "host"}},
+				},
+				Fill:  influxql.PreviousFill,
+				Limit: 10,
+			},
+		},
+
 		// SELECT statement with JOIN
 		{
 			s: `SELECT field1 FROM join(aa,"bb", cc) JOIN cc`,
@@ -111,6 +139,36 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// SELECT statement with regular expressions
+		{
+			s: `SELECT field1 FROM /cpu.*/ WHERE host =~ /^server\d+$/ AND region !~ /us\/west/`,
+			stmt: &influxql.SelectStatement{
+				Fields: []*influxql.Field{{Expr: &influxql.VarRef{Val: "field1"}}},
+				Source: &influxql.Measurement{Regex: &influxql.RegexLiteral{Val: regexp.MustCompile(`cpu.*`)}},
+				Condition: &influxql.BinaryExpr{
+					Op: influxql.AND,
+					LHS: &influxql.BinaryExpr{
+						Op:  influxql.EQREGEX,
+						LHS: &influxql.VarRef{Val: "host"},
+						RHS: &influxql.RegexLiteral{Val: regexp.MustCompile(`^server\d+$`)},
+					},
+					RHS: &influxql.BinaryExpr{
+						Op:  influxql.NEQREGEX,
+						LHS: &influxql.VarRef{Val: "region"},
+						RHS: &influxql.RegexLiteral{Val: regexp.MustCompile(`us/west`)},
+					},
+				},
+			},
+		},
+
+		// SHOW TAG KEYS with a regular expression source
+		{
+			s: `SHOW TAG KEYS FROM /cpu.*/`,
+			stmt: &influxql.ShowTagKeysStatement{
+				Source: &influxql.Measurement{Regex: &influxql.RegexLiteral{Val: regexp.MustCompile(`cpu.*`)}},
+			},
+		},
+
 		// SELECT statement (lowercase)
 		{
 			s: `select my_field from myseries`,
@@ -128,7 +186,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				Source: &influxql.Measurement{Name: "myseries"},
 				SortFields: []*influxql.SortField{
 					{Ascending: true},
-					{Name: "field1"},
+					{Name: "field1", Ascending: true},
 					{Name: "field2"},
 				},
 				Limit: 10,
@@ -148,6 +206,29 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// EXPLAIN statement
+		{
+			s: `EXPLAIN SELECT value FROM cpu`,
+			stmt: &influxql.ExplainStatement{
+				Statement: &influxql.SelectStatement{
+					Fields: []*influxql.Field{{Expr: &influxql.VarRef{Val: "value"}}},
+					Source: &influxql.Measurement{Name: "cpu"},
+				},
+			},
+		},
+
+		// EXPLAIN ANALYZE statement
+		{
+			s: `EXPLAIN ANALYZE SELECT value FROM cpu`,
+			stmt: &influxql.ExplainStatement{
+				Statement: &influxql.SelectStatement{
+					Fields: []*influxql.Field{{Expr: &influxql.VarRef{Val: "value"}}},
+					Source: &influxql.Measurement{Name: "cpu"},
+				},
+				Analyze: true,
+			},
+		},
+
 		// SHOW DATABASES
 		{
 			s:    `SHOW DATABASES`,
@@ -171,7 +252,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				},
 				SortFields: []*influxql.SortField{
 					{Ascending: true},
-					{Name: "field1"},
+					{Name: "field1", Ascending: true},
 					{Name: "field2"},
 				},
 				Limit: 10,
@@ -189,7 +270,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				},
 				SortFields: []*influxql.SortField{
 					{Ascending: true},
-					{Name: "field1"},
+					{Name: "field1", Ascending: true},
 					{Name: "field2"},
 				},
 				Limit: 10,
@@ -224,7 +305,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				},
 				SortFields: []*influxql.SortField{
 					{Ascending: true},
-					{Name: "field1"},
+					{Name: "field1", Ascending: true},
 					{Name: "field2"},
 				},
 				Limit: 10,
@@ -244,7 +325,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				},
 				SortFields: []*influxql.SortField{
 					{Ascending: true},
-					{Name: "field1"},
+					{Name: "field1", Ascending: true},
 					{Name: "field2"},
 				},
 				Limit: 10,
@@ -310,7 +391,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				},
 				SortFields: []*influxql.SortField{
 					{Ascending: true},
-					{Name: "field1"},
+					{Name: "field1", Ascending: true},
 					{Name: "field2"},
 				},
 				Limit: 10,
@@ -319,8 +400,33 @@ func TestParser_ParseStatement(t *testing.T) {
 
 		// DROP SERIES statement
 		{
-			s:    `DROP SERIES myseries`,
-			stmt: &influxql.DropSeriesStatement{Name: "myseries"},
+			s:    `DROP SERIES FROM myseries`,
+			stmt: &influxql.DropSeriesStatement{Source: &influxql.Measurement{Name: "myseries"}},
+		},
+
+		// DROP SERIES with WHERE clause
+		{
+			s: `DROP SERIES FROM cpu WHERE host = 'serverA'`,
+			stmt: &influxql.DropSeriesStatement{
+				Source: &influxql.Measurement{Name: "cpu"},
+				Condition: &influxql.BinaryExpr{
+					Op:  influxql.EQ,
+					LHS: &influxql.VarRef{Val: "host"},
+					RHS: &influxql.StringLiteral{Val: "serverA"},
+				},
+			},
+		},
+
+		// DROP SERIES with only a WHERE clause
+		{
+			s: `DROP SERIES WHERE host = 'serverA'`,
+			stmt: &influxql.DropSeriesStatement{
+				Condition: &influxql.BinaryExpr{
+					Op:  influxql.EQ,
+					LHS: &influxql.VarRef{Val: "host"},
+					RHS: &influxql.StringLiteral{Val: "serverA"},
+				},
+			},
 		},
 
 		// SHOW CONTINUOUS QUERIES statement
@@ -359,6 +465,23 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// CREATE CONTINUOUS QUERY ... INTO <database>.<retention-policy>.<measurement>
+		{
+			s: `CREATE CONTINUOUS QUERY myquery ON testdb BEGIN SELECT count() INTO otherdb."1h.policy1".cpu FROM myseries END`,
+			stmt: &influxql.CreateContinuousQueryStatement{
+				Name:     "myquery",
+				Database: "testdb",
+				Source: &influxql.SelectStatement{
+					Fields: []*influxql.Field{{Expr: &influxql.Call{Name: "count"}}},
+					Target: &influxql.Target{
+						Measurement: `"1h.policy1"."cpu"`,
+						Database:    "otherdb",
+					},
+					Source: &influxql.Measurement{Name: "myseries"},
+				},
+			},
+		},
+
 		// CREATE DATABASE statement
 		{
 			s: `CREATE DATABASE testdb`,
@@ -534,6 +657,19 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// CREATE RETENTION POLICY ... SHARD DURATION
+		{
+			s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 52w REPLICATION 1 SHARD DURATION 1w DEFAULT`,
+			stmt: &influxql.CreateRetentionPolicyStatement{
+				Name:               "policy1",
+				Database:           "testdb",
+				Duration:           52 * 7 * 24 * time.Hour,
+				Replication:        1,
+				ShardGroupDuration: 7 * 24 * time.Hour,
+				Default:            true,
+			},
+		},
+
 		// ALTER RETENTION POLICY
 		{
 			s:    `ALTER RETENTION POLICY policy1 ON testdb DURATION 1m REPLICATION 4 DEFAULT`,
@@ -564,6 +700,17 @@ func TestParser_ParseStatement(t *testing.T) {
 			stmt: newAlterRetentionPolicyStatement("policy1", "testdb", -1, 4, false),
 		},
 
+		// ALTER RETENTION POLICY with SHARD DURATION
+		{
+			s: `ALTER RETENTION POLICY policy1 ON testdb SHARD DURATION 1d`,
+			stmt: func() *influxql.AlterRetentionPolicyStatement {
+				stmt := newAlterRetentionPolicyStatement("policy1", "testdb", -1, -1, false)
+				d := 24 * time.Hour
+				stmt.ShardGroupDuration = &d
+				return stmt
+			}(),
+		},
+
 		// Errors
 		{s: ``, err: `found EOF, expected SELECT at line 1, char 1`},
 		{s: `SELECT`, err: `found EOF, expected identifier, string, number, bool at line 1, char 8`},
@@ -571,6 +718,8 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `SELECT field1 X`, err: `found X, expected FROM at line 1, char 15`},
 		{s: `SELECT field1 FROM "series" WHERE X +;`, err: `found ;, expected identifier, string, number, bool at line 1, char 38`},
 		{s: `SELECT field1 FROM myseries GROUP`, err: `found EOF, expected BY at line 1, char 35`},
+		{s: `SELECT field1 INTO "db"."rp".cpu ON db FROM myseries`, err: `target database specified twice: "db"."rp".cpu at line 1, char 20`},
+		{s: `SELECT field1 INTO a."b"."c"."d" FROM myseries`, err: `invalid target measurement: a."b"."c"."d" at line 1, char 20`},
 		{s: `SELECT field1 FROM myseries LIMIT`, err: `found EOF, expected number at line 1, char 35`},
 		{s: `SELECT field1 FROM myseries LIMIT 10.5`, err: `fractional parts not allowed in LIMIT at line 1, char 35`},
 		{s: `SELECT field1 FROM myseries LIMIT 0`, err: `LIMIT must be > 0 at line 1, char 35`},
@@ -582,13 +731,23 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `SELECT field1 FROM myseries ORDER BY 1`, err: `found 1, expected identifier, ASC, or DESC at line 1, char 38`},
 		{s: `SELECT field1 AS`, err: `found EOF, expected identifier at line 1, char 18`},
 		{s: `SELECT field1 FROM 12`, err: `found 12, expected identifier at line 1, char 20`},
+		{s: `SELECT field1 FROM /cpu.*`, err: `unterminated regex: /cpu.* at line 1, char 20`},
+		{s: `SELECT field1 FROM myseries WHERE host =~ /(/`, err: "invalid regex: error parsing regexp: missing closing ): `(` at line 1, char 43"},
+		{s: `SELECT field1 FROM myseries WHERE host =~ 'a'`, err: `found a, expected regex at line 1, char 42`},
 		{s: `SELECT field1 FROM myseries GROUP BY *`, err: `found *, expected identifier, string, number, bool at line 1, char 38`},
+		{s: `SELECT field1 FROM myseries GROUP BY time(1m) fill`, err: `found EOF, expected ( at line 1, char 52`},
+		{s: `SELECT field1 FROM myseries GROUP BY time(1m) fill(zero)`, err: `found zero, expected null, none, previous, linear, number at line 1, char 52`},
+		{s: `SELECT field1 FROM myseries GROUP BY time(1m) fill(null`, err: `found EOF, expected ) at line 1, char 57`},
 		{s: `SELECT 1000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000 FROM myseries`, err: `unable to parse number at line 1, char 8`},
 		{s: `SELECT 10.5h FROM myseries`, err: `found h, expected FROM at line 1, char 12`},
 		{s: `DELETE`, err: `found EOF, expected FROM at line 1, char 8`},
 		{s: `DELETE FROM`, err: `found EOF, expected identifier at line 1, char 13`},
+		{s: `EXPLAIN`, err: `found EOF, expected SELECT at line 1, char 9`},
+		{s: `EXPLAIN ANALYZE SHOW DATABASES`, err: `found SHOW, expected SELECT at line 1, char 17`},
+		{s: `EXPLAIN ANALYZE SELECT value INTO cpu2 FROM cpu`, err: `INTO not allowed here at line 1, char 30`},
 		{s: `DELETE FROM myseries WHERE`, err: `found EOF, expected identifier, string, number, bool at line 1, char 28`},
-		{s: `DROP SERIES`, err: `found EOF, expected identifier at line 1, char 13`},
+		{s: `DROP SERIES`, err: `found EOF, expected FROM, WHERE at line 1, char 13`},
+		{s: `DROP SERIES FROM`, err: `found EOF, expected identifier at line 1, char 18`},
 		{s: `SHOW CONTINUOUS`, err: `found EOF, expected QUERIES at line 1, char 17`},
 		{s: `SHOW RETENTION`, err: `found EOF, expected POLICIES at line 1, char 16`},
 		{s: `SHOW RETENTION POLICIES`, err: `found EOF, expected identifier at line 1, char 25`},
@@ -632,12 +791,14 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION 3.14`, err: `number must be an integer at line 1, char 67`},
 		{s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION 0`, err: `invalid value 0: must be 1 <= n <= 2147483647 at line 1, char 67`},
 		{s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION bad`, err: `found bad, expected number at line 1, char 67`},
+		{s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION 1 SHARD`, err: `found EOF, expected DURATION at line 1, char 75`},
+		{s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION 1 SHARD DURATION`, err: `found EOF, expected duration at line 1, char 84`},
 		{s: `ALTER`, err: `found EOF, expected RETENTION at line 1, char 7`},
 		{s: `ALTER RETENTION`, err: `found EOF, expected POLICY at line 1, char 17`},
 		{s: `ALTER RETENTION POLICY`, err: `found EOF, expected identifier at line 1, char 24`},
 		{s: `ALTER RETENTION POLICY policy1`, err: `found EOF, expected ON at line 1, char 32`},
 		{s: `ALTER RETENTION POLICY policy1 ON`, err: `found EOF, expected identifier at line 1, char 35`},
-		{s: `ALTER RETENTION POLICY policy1 ON testdb`, err: `found EOF, expected DURATION, RETENTION, DEFAULT at line 1, char 42`},
+		{s: `ALTER RETENTION POLICY policy1 ON testdb`, err: `found EOF, expected DURATION, RETENTION, SHARD, DEFAULT at line 1, char 42`},
 	}
 
 	for i, tt := range tests {
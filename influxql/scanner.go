@@ -61,7 +61,18 @@ func (s *Scanner) Scan() (tok Token, pos Pos, lit string) {
 	case '/':
 		return DIV, pos, ""
 	case '=':
+		if ch1, _ := s.r.read(); ch1 == '~' {
+			return EQREGEX, pos, ""
+		}
+		s.r.unread()
 		return EQ, pos, ""
+	case '!':
+		if ch1, _ := s.r.read(); ch1 == '=' {
+			return NEQ, pos, ""
+		} else if ch1 == '~' {
+			return NEQREGEX, pos, ""
+		}
+		s.r.unread()
 	case '>':
 		if ch1, _ := s.r.read(); ch1 == '=' {
 			return GTE, pos, ""
@@ -243,6 +254,33 @@ func (s *Scanner) scanNumber() (tok Token, pos Pos, lit string) {
 	return NUMBER, pos, buf.String()
 }
 
+// ScanRegex consumes a regular expression literal up to its closing slash.
+// The opening slash must have already been read. Escaped slashes are
+// unescaped and all other escapes are left for the regexp package.
+func (s *Scanner) ScanRegex() (tok Token, pos Pos, lit string) {
+	_, pos = s.r.curr()
+
+	var buf bytes.Buffer
+	for {
+		ch0, _ := s.r.read()
+		if ch0 == eof || ch0 == '\n' {
+			return BADREGEX, pos, buf.String()
+		} else if ch0 == '/' {
+			return REGEX, pos, buf.String()
+		} else if ch0 == '\\' {
+			ch1, _ := s.r.read()
+			if ch1 == eof || ch1 == '\n' {
+				return BADREGEX, pos, buf.String()
+			} else if ch1 != '/' {
+				_, _ = buf.WriteRune(ch0)
+			}
+			_, _ = buf.WriteRune(ch1)
+		} else {
+			_, _ = buf.WriteRune(ch0)
+		}
+	}
+}
+
 // scanDigits consume a contiguous series of digits.
 func (s *Scanner) scanDigits() string {
 	var buf bytes.Buffer
@@ -303,6 +341,16 @@ func (s *bufScanner) Scan() (tok Token, pos Pos, lit string) {
 	return s.curr()
 }
 
+// ScanRegex reads a regular expression literal from the scanner.
+// The previously scanned token must be the opening slash.
+func (s *bufScanner) ScanRegex() (tok Token, pos Pos, lit string) {
+	s.i = (s.i + 1) % len(s.buf)
+	buf := &s.buf[s.i]
+	buf.tok, buf.pos, buf.lit = s.s.ScanRegex()
+
+	return s.curr()
+}
+
 // Unscan pushes the previously token back onto the buffer.
 func (s *bufScanner) Unscan() { s.n++ }
 
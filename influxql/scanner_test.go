@@ -43,6 +43,9 @@ func TestScanner_Scan(t *testing.T) {
 
 		{s: `=`, tok: influxql.EQ},
 		{s: `<>`, tok: influxql.NEQ},
+		{s: `!=`, tok: influxql.NEQ},
+		{s: `=~`, tok: influxql.EQREGEX},
+		{s: `!~`, tok: influxql.NEQREGEX},
 		{s: `! `, tok: influxql.ILLEGAL, lit: "!"},
 		{s: `<`, tok: influxql.LT},
 		{s: `<=`, tok: influxql.LTE},
@@ -100,6 +103,7 @@ func TestScanner_Scan(t *testing.T) {
 		// Keywords
 		{s: `ALL`, tok: influxql.ALL},
 		{s: `ALTER`, tok: influxql.ALTER},
+		{s: `ANALYZE`, tok: influxql.ANALYZE},
 		{s: `AS`, tok: influxql.AS},
 		{s: `ASC`, tok: influxql.ASC},
 		{s: `BEGIN`, tok: influxql.BEGIN},
@@ -144,6 +148,7 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `REVOKE`, tok: influxql.REVOKE},
 		{s: `SELECT`, tok: influxql.SELECT},
 		{s: `SERIES`, tok: influxql.SERIES},
+		{s: `SHARD`, tok: influxql.SHARD},
 		{s: `TAG`, tok: influxql.TAG},
 		{s: `TO`, tok: influxql.TO},
 		{s: `USER`, tok: influxql.USER},
@@ -222,6 +227,34 @@ func TestScanner_Scan_Multi(t *testing.T) {
 }
 
 // Ensure the library can correctly scan strings.
+// Ensure the scanner can scan regular expressions after the opening slash.
+func TestScanner_ScanRegex(t *testing.T) {
+	var tests = []struct {
+		in  string
+		tok influxql.Token
+		lit string
+	}{
+		{in: `/cpu.*/`, tok: influxql.REGEX, lit: `cpu.*`},
+		{in: `/^server\d+$/ AND`, tok: influxql.REGEX, lit: `^server\d+$`},
+		{in: `/a\/b/`, tok: influxql.REGEX, lit: `a/b`},
+		{in: `/cpu.*`, tok: influxql.BADREGEX, lit: `cpu.*`},
+		{in: "/cpu\n/", tok: influxql.BADREGEX, lit: `cpu`},
+	}
+
+	for i, tt := range tests {
+		s := influxql.NewScanner(strings.NewReader(tt.in))
+		if tok, _, _ := s.Scan(); tok != influxql.DIV {
+			t.Fatalf("%d. %s: expected opening slash: %s", i, tt.in, tok)
+		}
+		tok, _, lit := s.ScanRegex()
+		if tok != tt.tok {
+			t.Errorf("%d. %s token mismatch: exp=%q got=%q", i, tt.in, tt.tok, tok)
+		} else if lit != tt.lit {
+			t.Errorf("%d. %s literal mismatch: exp=%q got=%q", i, tt.in, tt.lit, lit)
+		}
+	}
+}
+
 func TestScanString(t *testing.T) {
 	var tests = []struct {
 		in  string
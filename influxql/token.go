@@ -23,6 +23,8 @@ const (
 	BADESCAPE    // \q
 	TRUE         // true
 	FALSE        // false
+	REGEX        // /cpu.*/
+	BADREGEX     // /cpu.*
 	literal_end
 
 	operator_beg
@@ -35,12 +37,14 @@ const (
 	AND // AND
 	OR  // OR
 
-	EQ  // =
-	NEQ // !=
-	LT  // <
-	LTE // <=
-	GT  // >
-	GTE // >=
+	EQ       // =
+	NEQ      // !=
+	EQREGEX  // =~
+	NEQREGEX // !~
+	LT       // <
+	LTE      // <=
+	GT       // >
+	GTE      // >=
 	operator_end
 
 	LPAREN    // (
@@ -53,6 +57,7 @@ const (
 	// Keywords
 	ALL
 	ALTER
+	ANALYZE
 	AS
 	ASC
 	BEGIN
@@ -99,6 +104,7 @@ const (
 	REVOKE
 	SELECT
 	SERIES
+	SHARD
 	TAG
 	TO
 	USER
@@ -121,6 +127,7 @@ var tokens = [...]string{
 	STRING:       "STRING",
 	TRUE:         "TRUE",
 	FALSE:        "FALSE",
+	REGEX:        "REGEX",
 
 	ADD: "+",
 	SUB: "-",
@@ -130,12 +137,14 @@ var tokens = [...]string{
 	AND: "AND",
 	OR:  "OR",
 
-	EQ:  "=",
-	NEQ: "!=",
-	LT:  "<",
-	LTE: "<=",
-	GT:  ">",
-	GTE: ">=",
+	EQ:       "=",
+	NEQ:      "!=",
+	EQREGEX:  "=~",
+	NEQREGEX: "!~",
+	LT:       "<",
+	LTE:      "<=",
+	GT:       ">",
+	GTE:      ">=",
 
 	LPAREN:    "(",
 	RPAREN:    ")",
@@ -145,6 +154,7 @@ var tokens = [...]string{
 
 	ALL:          "ALL",
 	ALTER:        "ALTER",
+	ANALYZE:      "ANALYZE",
 	AS:           "AS",
 	ASC:          "ASC",
 	BEGIN:        "BEGIN",
@@ -191,6 +201,7 @@ var tokens = [...]string{
 	REVOKE:       "REVOKE",
 	SELECT:       "SELECT",
 	SERIES:       "SERIES",
+	SHARD:        "SHARD",
 	TAG:          "TAG",
 	TO:           "TO",
 	USER:         "USER",
@@ -232,7 +243,7 @@ func (tok Token) Precedence() int {
 		return 1
 	case AND:
 		return 2
-	case EQ, NEQ, LT, LTE, GT, GTE:
+	case EQ, NEQ, EQREGEX, NEQREGEX, LT, LTE, GT, GTE:
 		return 3
 	case ADD, SUB:
 		return 4
@@ -31,6 +31,44 @@ func TestMeasurement_uniqueTagValues(t *testing.T) {
 	}
 }
 
+// Ensure a batch of encoded points can be marshaled and unmarshaled.
+func TestPointBatch_MarshalUnmarshal(t *testing.T) {
+	points := [][]byte{
+		append(marshalPointHeader(1, 100), marshalValues(map[uint8]interface{}{1: float64(10)})...),
+		append(marshalPointHeader(2, 200), marshalValues(map[uint8]interface{}{1: "foo", 2: true})...),
+	}
+	if other := unmarshalPointBatch(marshalPointBatch(points)); !reflect.DeepEqual(points, other) {
+		t.Fatalf("mismatch: exp=%v, got=%v", points, other)
+	}
+}
+
+// Ensure typed values can be marshaled and unmarshaled.
+func TestValues_MarshalUnmarshal(t *testing.T) {
+	values := map[uint8]interface{}{1: float64(10), 2: int64(-20), 3: true, 4: "foo"}
+	if other := unmarshalValues(marshalValues(values)); !reflect.DeepEqual(values, other) {
+		t.Fatalf("mismatch: exp=%v, got=%v", values, other)
+	}
+	if other := unmarshalValues(marshalValues(map[uint8]interface{}{})); len(other) != 0 {
+		t.Fatalf("unexpected values: %v", other)
+	}
+}
+
+// Ensure values written before the typed encoding are decoded as floats.
+func TestValues_UnmarshalUntyped(t *testing.T) {
+	for i, tt := range []struct {
+		b      []byte
+		values map[uint8]interface{}
+	}{
+		{b: []byte{0}, values: map[uint8]interface{}{}},
+		{b: []byte{1, 1, 0x40, 0x24, 0, 0, 0, 0, 0, 0}, values: map[uint8]interface{}{1: float64(10)}},
+		{b: []byte{2, 1, 0x3f, 0xf0, 0, 0, 0, 0, 0, 0, 3, 0xc0, 0, 0, 0, 0, 0, 0, 0}, values: map[uint8]interface{}{1: float64(1), 3: float64(-2)}},
+	} {
+		if values := unmarshalValues(tt.b); !reflect.DeepEqual(tt.values, values) {
+			t.Errorf("%d. mismatch: exp=%v, got=%v", i, tt.values, values)
+		}
+	}
+}
+
 // Ensure a measurement can expand an expression for all possible tag values used.
 func TestMeasurement_expandExpr(t *testing.T) {
 	m := NewMeasurement("cpu")
@@ -90,6 +128,24 @@ func TestMeasurement_expandExpr(t *testing.T) {
 	}
 }
 
+// Ensure only the data node with the lowest id is the first data node.
+func TestServer_isFirstDataNode(t *testing.T) {
+	nodes := map[uint64]*DataNode{2: {ID: 2}, 3: {ID: 3}}
+	for i, tt := range []struct {
+		id    uint64
+		first bool
+	}{
+		{id: 2, first: true},
+		{id: 3, first: false},
+		{id: 1, first: false},
+	} {
+		s := &Server{id: tt.id, dataNodes: nodes}
+		if first := s.isFirstDataNode(); first != tt.first {
+			t.Errorf("%d. id=%d: unexpected result: %v", i, tt.id, first)
+		}
+	}
+}
+
 // MustParseExpr parses an expression string and returns its AST representation.
 func MustParseExpr(s string) influxql.Expr {
 	expr, err := influxql.ParseExpr(s)
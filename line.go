@@ -0,0 +1,206 @@
+package influxdb
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdb/influxdb/client"
+)
+
+// LineReader reads points from a stream in the line protocol format:
+//
+//	measurement[,tag=value...] field=value[,field=value...] [timestamp]
+//
+// Spaces, commas and equal signs in measurement names, tag keys, tag values
+// and field keys can be escaped with a backslash. String field values are
+// double quoted and may contain escaped double quotes. Numeric field values
+// are floats unless they have an "i" suffix, in which case they are integers.
+// Boolean values are written as t, true, f or false.
+//
+// Timestamps are integer epochs interpreted using the reader's precision,
+// with the same semantics as client.EpochToTime. Points without a timestamp
+// use the current time. Blank lines and lines beginning with "#" are ignored.
+type LineReader struct {
+	r         *bufio.Reader
+	precision string
+	n         int // current line number
+}
+
+// NewLineReader returns a new instance of LineReader that reads from r.
+func NewLineReader(r io.Reader, precision string) *LineReader {
+	return &LineReader{
+		r:         bufio.NewReader(r),
+		precision: precision,
+	}
+}
+
+// ReadPoint reads the next point from the stream.
+// Returns io.EOF when no more points are available.
+func (r *LineReader) ReadPoint() (Point, error) {
+	for {
+		line, err := r.r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return Point{}, err
+		} else if err == io.EOF && line == "" {
+			return Point{}, io.EOF
+		}
+		r.n++
+
+		// Skip blank lines and comments.
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		p, err := ParseLine(line, r.precision)
+		if err != nil {
+			return Point{}, fmt.Errorf("line %d: %s", r.n, err)
+		}
+		return p, nil
+	}
+}
+
+// ParseLine parses a single line protocol line into a point.
+func ParseLine(line, precision string) (Point, error) {
+	// Split the line into its key, fields and timestamp sections.
+	sections := splitUnescaped(line, ' ', true)
+	if len(sections) < 2 || len(sections) > 3 {
+		return Point{}, errors.New("expected measurement, fields and optional timestamp")
+	}
+
+	// Parse the measurement name and tags.
+	var p Point
+	keys := splitUnescaped(sections[0], ',', false)
+	if p.Name = unescapeLine(keys[0]); p.Name == "" {
+		return Point{}, ErrMeasurementNameRequired
+	}
+	for _, s := range keys[1:] {
+		k, v, err := splitKeyValue(s)
+		if err != nil {
+			return Point{}, fmt.Errorf("invalid tag: %s", err)
+		}
+		if p.Tags == nil {
+			p.Tags = make(map[string]string)
+		}
+		p.Tags[unescapeLine(k)] = unescapeLine(v)
+	}
+
+	// Parse the field values.
+	p.Values = make(map[string]interface{})
+	for _, s := range splitUnescaped(sections[1], ',', true) {
+		k, v, err := splitKeyValue(s)
+		if err != nil {
+			return Point{}, fmt.Errorf("invalid field: %s", err)
+		}
+		value, err := parseLineValue(v)
+		if err != nil {
+			return Point{}, fmt.Errorf("invalid field %q: %s", unescapeLine(k), err)
+		}
+		p.Values[unescapeLine(k)] = value
+	}
+
+	// Parse the timestamp, if one is specified.
+	if len(sections) == 3 {
+		epoch, err := strconv.ParseInt(sections[2], 10, 64)
+		if err != nil {
+			return Point{}, fmt.Errorf("invalid timestamp: %s", sections[2])
+		}
+		if p.Timestamp, err = client.EpochToTime(epoch, precision); err != nil {
+			return Point{}, err
+		}
+	} else {
+		p.Timestamp = time.Now()
+	}
+	p.Timestamp = p.Timestamp.UTC()
+
+	return p, nil
+}
+
+// parseLineValue parses an encoded field value.
+func parseLineValue(s string) (interface{}, error) {
+	switch {
+	case s == "":
+		return nil, errors.New("missing value")
+	case s[0] == '"':
+		if len(s) < 2 || s[len(s)-1] != '"' {
+			return nil, errors.New("unterminated string")
+		}
+		return unescapeLine(s[1 : len(s)-1]), nil
+	case s == "t" || s == "T" || s == "true" || s == "True" || s == "TRUE":
+		return true, nil
+	case s == "f" || s == "F" || s == "false" || s == "False" || s == "FALSE":
+		return false, nil
+	case s[len(s)-1] == 'i':
+		return strconv.ParseInt(s[:len(s)-1], 10, 64)
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, err
+	} else if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("invalid number: %s", s)
+	}
+	return f, nil
+}
+
+// splitKeyValue splits a string on its first unescaped equal sign.
+func splitKeyValue(s string) (key, value string, err error) {
+	a := splitUnescapedN(s, '=', true, 2)
+	if len(a) != 2 || a[0] == "" {
+		return "", "", fmt.Errorf("expected key=value: %s", s)
+	}
+	return a[0], a[1], nil
+}
+
+// splitUnescaped splits a string on each occurrence of sep that is not
+// escaped by a backslash. If quotes is true then separators within double
+// quotes are also ignored. Empty segments caused by repeated spaces are removed.
+func splitUnescaped(s string, sep byte, quotes bool) []string {
+	return splitUnescapedN(s, sep, quotes, -1)
+}
+
+// splitUnescapedN splits a string into at most n segments. See splitUnescaped.
+func splitUnescapedN(s string, sep byte, quotes bool, n int) []string {
+	var a []string
+	var quoted bool
+	start := 0
+	for i := 0; i < len(s) && n != len(a)+1; i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case s[i] == '"' && quotes:
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			if sep != ' ' || i > start {
+				a = append(a, s[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if sep != ' ' || start < len(s) {
+		a = append(a, s[start:])
+	}
+	return a
+}
+
+// unescapeLine removes the backslash from each escaped character in s.
+func unescapeLine(s string) string {
+	if strings.IndexByte(s, '\\') == -1 {
+		return s
+	}
+
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
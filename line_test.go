@@ -0,0 +1,73 @@
+package influxdb_test
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/influxdb/influxdb"
+)
+
+// Ensure a line can be parsed into a point.
+func TestParseLine(t *testing.T) {
+	for i, tt := range []struct {
+		line      string
+		precision string
+		p         influxdb.Point
+		err       string
+	}{
+		{
+			line: `cpu,host=serverA,region=us-west value=1.5 946684800`,
+			p:    influxdb.Point{Name: "cpu", Tags: map[string]string{"host": "serverA", "region": "us-west"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(1.5)}},
+		},
+		{
+			line:      `cpu load=2,count=10i,ok=t,status="up, \"fine\"" 946684800000`,
+			precision: "ms",
+			p:         influxdb.Point{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"load": float64(2), "count": int64(10), "ok": true, "status": `up, "fine"`}},
+		},
+		{
+			line:      `disk\ usage,path=C:\\Program\ Files,type=a\,b\=c free\ space=false 946684800000000000`,
+			precision: "n",
+			p:         influxdb.Point{Name: "disk usage", Tags: map[string]string{"path": `C:\Program Files`, "type": "a,b=c"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"free space": false}},
+		},
+		{line: `cpu`, err: `expected measurement, fields and optional timestamp`},
+		{line: `cpu,host value=1`, err: `invalid tag: expected key=value: host`},
+		{line: `cpu value=foo`, err: `invalid field "value": strconv.ParseFloat: parsing "foo": invalid syntax`},
+		{line: `cpu value=NaN`, err: `invalid field "value": invalid number: NaN`},
+		{line: `cpu value=-Inf`, err: `invalid field "value": invalid number: -Inf`},
+		{line: `cpu value="foo`, err: `invalid field "value": unterminated string`},
+		{line: `cpu value=1 x`, err: `invalid timestamp: x`},
+		{line: `cpu value=1 1`, precision: "y", err: `Unknowm precision "y"`},
+	} {
+		p, err := influxdb.ParseLine(tt.line, tt.precision)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%d. %s: unexpected error: %s", i, tt.line, err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%d. %s: unexpected error: %s", i, tt.line, err)
+		} else if !reflect.DeepEqual(tt.p, p) {
+			t.Errorf("%d. %s: mismatch:\n\nexp=%#v\n\ngot=%#v", i, tt.line, tt.p, p)
+		}
+	}
+}
+
+// Ensure a line reader can stream points and report the line of an error.
+func TestLineReader_ReadPoint(t *testing.T) {
+	r := influxdb.NewLineReader(strings.NewReader("# comment\ncpu value=1 1\n\ncpu value=2 2\ncpu value\n"), "s")
+	for _, v := range []float64{1, 2} {
+		if p, err := r.ReadPoint(); err != nil {
+			t.Fatal(err)
+		} else if p.Values["value"] != v {
+			t.Fatalf("unexpected value: %v", p.Values["value"])
+		}
+	}
+	if _, err := r.ReadPoint(); err == nil || err.Error() != "line 5: invalid field: expected key=value: value" {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := r.ReadPoint(); err != io.EOF {
+		t.Fatalf("expected EOF: %s", err)
+	}
+}
@@ -214,6 +214,19 @@ func (tx *metatx) createSeries(database, name string, tags map[string]string) (*
 	return s, nil
 }
 
+// dropSeries removes the series for the database and measurement name.
+func (tx *metatx) dropSeries(database, name string, seriesID uint32) error {
+	b := tx.Bucket([]byte("Databases")).Bucket([]byte(database)).Bucket([]byte("Series")).Bucket([]byte(name))
+	if b == nil {
+		return nil
+	}
+
+	// series ids are stored in the same encoding used by createSeries
+	idBytes := make([]byte, 4)
+	*(*uint32)(unsafe.Pointer(&idBytes[0])) = seriesID
+	return b.Delete(idBytes)
+}
+
 // loops through all the measurements and series in a database
 func (tx *metatx) indexDatabase(db *database) {
 	// get the bucket that holds series data for the database
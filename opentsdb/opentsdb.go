@@ -0,0 +1,438 @@
+package opentsdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdb/influxdb"
+)
+
+const (
+	// DefaultPort represents the default OpenTSDB port.
+	DefaultPort = 4242
+)
+
+var (
+	// ErrBindAddressRequired is returned when starting the Server
+	// without a listening address.
+	ErrBindAddressRequired = errors.New("bind address required")
+
+	// ErrDatabaseNotSpecified retuned when no database was specified in the config file
+	ErrDatabaseNotSpecified = errors.New("database was not specified in config")
+)
+
+// SeriesWriter defines the interface for the destination of the data.
+type SeriesWriter interface {
+	WriteSeries(database, retentionPolicy string, points []influxdb.Point) (uint64, error)
+}
+
+// Server processes OpenTSDB data received over TCP connections. Telnet
+// style "put" commands and HTTP requests to /api/put are both accepted on
+// the same port, as with OpenTSDB itself.
+type Server struct {
+	writer  SeriesWriter
+	batcher *influxdb.PointBatcher
+
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	listener net.Listener
+	httpln   *chanListener
+	conns    map[net.Conn]struct{}
+
+	// The name of the database and retention policy to insert data into.
+	Database        string
+	RetentionPolicy string
+
+	// Reading from connections blocks while the batch queue is full.
+	BatchSize    int
+	BatchTimeout time.Duration
+}
+
+// NewServer returns a new instance of a Server.
+func NewServer(w SeriesWriter) *Server {
+	return &Server{
+		writer:       w,
+		BatchSize:    influxdb.DefaultPointBatchSize,
+		BatchTimeout: influxdb.DefaultPointBatchTimeout,
+	}
+}
+
+// ListenAndServe instructs the Server to start processing OpenTSDB data
+// on the given interface. iface must be in the form host:port
+func (s *Server) ListenAndServe(iface string) error {
+	if iface == "" { // Make sure we have an address
+		return ErrBindAddressRequired
+	} else if s.Database == "" { // Make sure they have a database
+		return ErrDatabaseNotSpecified
+	}
+
+	ln, err := net.Listen("tcp", iface)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.listener = ln
+	s.httpln = newChanListener(ln.Addr())
+	s.conns = make(map[net.Conn]struct{})
+	s.mu.Unlock()
+
+	// Write batches of points to the database.
+	s.batcher = influxdb.StartPointBatcher(s.writer, s.Database, s.RetentionPolicy, s.BatchSize, s.BatchTimeout, &s.wg)
+	s.wg.Add(2)
+
+	// Serve HTTP requests from connections handed off by the TCP listener.
+	go func() {
+		defer s.wg.Done()
+		_ = http.Serve(s.httpln, s)
+	}()
+
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if strings.Contains(err.Error(), "closed network connection") {
+					return
+				}
+				log.Println("error accepting OpenTSDB connection", err.Error())
+				continue
+			}
+
+			s.mu.Lock()
+			if s.listener == nil {
+				s.mu.Unlock()
+				conn.Close()
+				return
+			}
+			s.conns[conn] = struct{}{}
+			s.wg.Add(1)
+			s.mu.Unlock()
+
+			go s.handleConnection(conn)
+		}
+	}()
+	return nil
+}
+
+// Addr returns the address the server is listening on.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Close stops the server from accepting new connections and closes open
+// connections. Queued points are written before Close returns.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.listener == nil {
+		s.mu.Unlock()
+		return errors.New("server already closed")
+	}
+	_ = s.httpln.Close()
+	err := s.listener.Close()
+	s.listener = nil
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+
+	// Write any queued points and wait for all goroutines to finish.
+	s.batcher.Stop()
+	s.wg.Wait()
+
+	return err
+}
+
+// closeConn closes a connection and stops tracking it.
+func (s *Server) closeConn(conn net.Conn) error {
+	s.mu.Lock()
+	delete(s.conns, conn)
+	s.mu.Unlock()
+	return conn.Close()
+}
+
+// handleConnection determines whether a connection is sending HTTP requests
+// or telnet commands and services it accordingly.
+func (s *Server) handleConnection(conn net.Conn) {
+	defer s.wg.Done()
+
+	reader := bufio.NewReader(conn)
+
+	// HTTP requests begin with a method name. Telnet commands are lowercase.
+	b, err := reader.Peek(4)
+	if err != nil {
+		s.closeConn(conn)
+		return
+	}
+	switch string(b) {
+	case "GET ", "POST", "PUT ", "HEAD":
+		select {
+		case s.httpln.ch <- &readerConn{Conn: conn, r: reader, s: s}:
+		case <-s.httpln.closing:
+			s.closeConn(conn)
+		}
+		return
+	}
+
+	s.handleTelnetConnection(conn, reader)
+}
+
+// handleTelnetConnection services a connection sending telnet commands.
+func (s *Server) handleTelnetConnection(conn net.Conn, reader *bufio.Reader) {
+	defer s.closeConn(conn)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return
+		}
+
+		// Ignore blank lines.
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		// Determine the command.
+		switch cmd := strings.Fields(line)[0]; cmd {
+		case "put":
+			point, err := ParsePut(line)
+			if err != nil {
+				fmt.Fprintf(conn, "put: illegal argument: %s\n", err)
+				continue
+			}
+
+			// Queue the data to be written to the database.
+			if !s.batcher.Write(point) {
+				return
+			}
+		case "version":
+			fmt.Fprintln(conn, "influxdb opentsdb input")
+		case "exit":
+			return
+		default:
+			fmt.Fprintf(conn, "unknown command: %s.\n", cmd)
+		}
+	}
+}
+
+// ServeHTTP handles OpenTSDB HTTP API requests.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/put":
+		s.servePut(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// servePut handles a request to write one or more data points.
+// The body is either a single data point object or an array of them.
+func (s *Server) servePut(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Decode one or more data points.
+	var dps []DataPoint
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &dps)
+	} else {
+		var dp DataPoint
+		err = json.Unmarshal(trimmed, &dp)
+		dps = []DataPoint{dp}
+	}
+	if err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Validate all data points before writing any of them.
+	points := make([]influxdb.Point, 0, len(dps))
+	for i, dp := range dps {
+		p, err := dp.Point()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("data point %d: %s", i, err), http.StatusBadRequest)
+			return
+		}
+		points = append(points, p)
+	}
+
+	for _, p := range points {
+		if !s.batcher.Write(p) {
+			http.Error(w, "server closed", http.StatusServiceUnavailable)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DataPoint represents a single data point sent to the /api/put endpoint.
+type DataPoint struct {
+	Metric    string            `json:"metric"`
+	Timestamp json.Number       `json:"timestamp"`
+	Value     json.Number       `json:"value"`
+	Tags      map[string]string `json:"tags"`
+}
+
+// Point converts the data point to a point.
+func (dp *DataPoint) Point() (influxdb.Point, error) {
+	if dp.Metric == "" {
+		return influxdb.Point{}, errors.New("metric required")
+	}
+
+	timestamp, err := parseTimestamp(dp.Timestamp.String())
+	if err != nil {
+		return influxdb.Point{}, err
+	}
+	value, err := parseValue(dp.Value.String())
+	if err != nil {
+		return influxdb.Point{}, err
+	}
+
+	return influxdb.Point{
+		Name:      dp.Metric,
+		Tags:      dp.Tags,
+		Timestamp: timestamp,
+		Values:    map[string]interface{}{"value": value},
+	}, nil
+}
+
+// ParsePut parses a telnet put command in the form:
+//
+//	put <metric> <timestamp> <value> <tagk1=tagv1[ tagk2=tagv2 ...tagkN=tagvN]>
+//
+// The metric becomes the measurement name and the value is stored in the
+// "value" field. Timestamps are in seconds or, if they have 13 digits, in
+// milliseconds.
+func ParsePut(line string) (influxdb.Point, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || fields[0] != "put" {
+		return influxdb.Point{}, fmt.Errorf("expected put <metric> <timestamp> <value> <tags>: %s", line)
+	}
+
+	timestamp, err := parseTimestamp(fields[2])
+	if err != nil {
+		return influxdb.Point{}, err
+	}
+	value, err := parseValue(fields[3])
+	if err != nil {
+		return influxdb.Point{}, err
+	}
+
+	tags := make(map[string]string)
+	for _, s := range fields[4:] {
+		a := strings.SplitN(s, "=", 2)
+		if len(a) != 2 || a[0] == "" || a[1] == "" {
+			return influxdb.Point{}, fmt.Errorf("invalid tag: %s", s)
+		}
+		tags[a[0]] = a[1]
+	}
+
+	return influxdb.Point{
+		Name:      fields[1],
+		Tags:      tags,
+		Timestamp: timestamp,
+		Values:    map[string]interface{}{"value": value},
+	}, nil
+}
+
+// parseTimestamp parses an epoch in seconds or milliseconds.
+func parseTimestamp(s string) (time.Time, error) {
+	ts, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || ts < 0 {
+		return time.Time{}, fmt.Errorf("invalid timestamp: %s", s)
+	}
+
+	// Millisecond timestamps have 13 digits.
+	if len(s) > 10 {
+		return time.Unix(0, ts*int64(time.Millisecond)).UTC(), nil
+	}
+	return time.Unix(ts, 0).UTC(), nil
+}
+
+// parseValue parses an integer or floating point value. Values are always
+// returned as floats so that a metric's field type doesn't depend on whether
+// its first value was a whole number.
+func parseValue(s string) (float64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value: %s", s)
+	}
+	return f, nil
+}
+
+// chanListener is a net.Listener that accepts connections sent on a channel.
+// It allows an http.Server to serve connections accepted by another listener.
+type chanListener struct {
+	addr    net.Addr
+	ch      chan net.Conn
+	once    sync.Once
+	closing chan struct{}
+}
+
+// newChanListener returns a new instance of chanListener.
+func newChanListener(addr net.Addr) *chanListener {
+	return &chanListener{
+		addr:    addr,
+		ch:      make(chan net.Conn),
+		closing: make(chan struct{}),
+	}
+}
+
+// Accept waits for and returns the next connection.
+func (ln *chanListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-ln.ch:
+		return conn, nil
+	case <-ln.closing:
+		return nil, errors.New("network connection closed")
+	}
+}
+
+// Close closes the listener.
+func (ln *chanListener) Close() error {
+	ln.once.Do(func() { close(ln.closing) })
+	return nil
+}
+
+// Addr returns the listener's address.
+func (ln *chanListener) Addr() net.Addr { return ln.addr }
+
+// readerConn is a net.Conn whose buffered data is read before the connection.
+type readerConn struct {
+	net.Conn
+	r *bufio.Reader
+	s *Server
+}
+
+// Read reads data from the buffered reader.
+func (c *readerConn) Read(p []byte) (int, error) { return c.r.Read(p) }
+
+// Close closes the connection and removes it from the server's open connections.
+func (c *readerConn) Close() error { return c.s.closeConn(c.Conn) }
@@ -0,0 +1,234 @@
+package opentsdb_test
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb"
+	"github.com/influxdb/influxdb/opentsdb"
+)
+
+func TestParsePut(t *testing.T) {
+	var tests = []struct {
+		line  string
+		point influxdb.Point
+		err   string
+	}{
+		{
+			line: `put sys.cpu.user 1356998400 42.5 host=webserver01 cpu=0`,
+			point: influxdb.Point{
+				Name:      "sys.cpu.user",
+				Tags:      map[string]string{"host": "webserver01", "cpu": "0"},
+				Values:    map[string]interface{}{"value": 42.5},
+				Timestamp: time.Unix(1356998400, 0).UTC(),
+			},
+		},
+		{
+			line: `put sys.cpu.user 1356998400500 42 host=webserver01`,
+			point: influxdb.Point{
+				Name:      "sys.cpu.user",
+				Tags:      map[string]string{"host": "webserver01"},
+				Values:    map[string]interface{}{"value": float64(42)},
+				Timestamp: time.Unix(1356998400, int64(500*time.Millisecond)).UTC(),
+			},
+		},
+		{
+			line: `put sys.cpu.user 1356998400 42`,
+			point: influxdb.Point{
+				Name:      "sys.cpu.user",
+				Tags:      map[string]string{},
+				Values:    map[string]interface{}{"value": float64(42)},
+				Timestamp: time.Unix(1356998400, 0).UTC(),
+			},
+		},
+		{line: `put sys.cpu.user 1356998400`, err: `expected put <metric> <timestamp> <value> <tags>: put sys.cpu.user 1356998400`},
+		{line: `put sys.cpu.user 13569x8400 42`, err: `invalid timestamp: 13569x8400`},
+		{line: `put sys.cpu.user 1356998400 4x2`, err: `invalid value: 4x2`},
+		{line: `put sys.cpu.user 1356998400 42 host`, err: `invalid tag: host`},
+	}
+
+	for i, tt := range tests {
+		p, err := opentsdb.ParsePut(tt.line)
+		if errstr(err) != tt.err {
+			t.Errorf("%d. %s: error mismatch: exp=%s, got=%v", i, tt.line, tt.err, err)
+		} else if err == nil && !reflect.DeepEqual(p, tt.point) {
+			t.Errorf("%d. %s: point mismatch:\n\nexp=%#v\n\ngot=%#v", i, tt.line, tt.point, p)
+		}
+	}
+}
+
+// Ensure the server writes points sent with telnet put commands.
+func TestServer_Telnet(t *testing.T) {
+	s, w := OpenServer(t)
+	defer s.Close()
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Send two points and an invalid command.
+	if _, err := conn.Write([]byte("put cpu 1356998400 10 host=a\nfoo\nput cpu 1356998401 20 host=b\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The invalid command is reported on the connection.
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	} else if line != "unknown command: foo.\n" {
+		t.Fatalf("unexpected response: %q", line)
+	}
+
+	if points := w.PointsN(t, 2); points[0].Tags["host"] != "a" || points[1].Values["value"] != float64(20) {
+		t.Fatalf("unexpected points: %#v", points)
+	}
+}
+
+// Ensure the server writes points sent to the /api/put endpoint.
+func TestServer_HTTP_Put(t *testing.T) {
+	s, w := OpenServer(t)
+	defer s.Close()
+
+	body := `[{"metric":"cpu","timestamp":1356998400,"value":18,"tags":{"host":"a"}},{"metric":"mem","timestamp":1356998400500,"value":"2.5","tags":{"host":"b"}}]`
+	resp, err := http.Post("http://"+s.Addr().String()+"/api/put", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	}
+
+	exp := []influxdb.Point{
+		{Name: "cpu", Tags: map[string]string{"host": "a"}, Values: map[string]interface{}{"value": float64(18)}, Timestamp: time.Unix(1356998400, 0).UTC()},
+		{Name: "mem", Tags: map[string]string{"host": "b"}, Values: map[string]interface{}{"value": 2.5}, Timestamp: time.Unix(1356998400, int64(500*time.Millisecond)).UTC()},
+	}
+	if points := w.PointsN(t, 2); !reflect.DeepEqual(points, exp) {
+		t.Fatalf("unexpected points:\n\nexp=%#v\n\ngot=%#v", exp, points)
+	}
+}
+
+// Ensure the /api/put endpoint accepts a single data point and rejects invalid ones.
+func TestServer_HTTP_Put_Single(t *testing.T) {
+	s, w := OpenServer(t)
+	defer s.Close()
+
+	var tests = []struct {
+		body   string
+		status int
+	}{
+		{body: `{"metric":"cpu","timestamp":1356998400,"value":18}`, status: http.StatusNoContent},
+		{body: `{"metric":"cpu","timestamp":1356998400,"value":"x"}`, status: http.StatusBadRequest},
+		{body: `{"timestamp":1356998400,"value":1}`, status: http.StatusBadRequest},
+		{body: `{"metric":`, status: http.StatusBadRequest},
+	}
+
+	for i, tt := range tests {
+		resp, err := http.Post("http://"+s.Addr().String()+"/api/put", "application/json", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%d. %s: unexpected status: exp=%d, got=%d", i, tt.body, tt.status, resp.StatusCode)
+		}
+	}
+
+	if points := w.PointsN(t, 1); points[0].Name != "cpu" {
+		t.Fatalf("unexpected points: %#v", points)
+	}
+}
+
+// Ensure closing the server closes open connections.
+func TestServer_Close(t *testing.T) {
+	s, _ := OpenServer(t)
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Wait for the connection to be serviced.
+	r := bufio.NewReader(conn)
+	if _, err := conn.Write([]byte("version\n")); err != nil {
+		t.Fatal(err)
+	} else if _, err := r.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() { done <- s.Close() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for close")
+	}
+
+	if _, err := r.ReadString('\n'); err == nil {
+		t.Fatal("expected connection to be closed")
+	} else if err := s.Close(); err == nil {
+		t.Fatal("expected error closing a closed server")
+	}
+}
+
+// Ensure the server requires a database.
+func TestServer_ListenAndServe_ErrDatabaseNotSpecified(t *testing.T) {
+	s := opentsdb.NewServer(&SeriesWriter{})
+	if err := s.ListenAndServe("127.0.0.1:0"); err != opentsdb.ErrDatabaseNotSpecified {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// OpenServer returns a server listening on a random port.
+func OpenServer(t *testing.T) (*opentsdb.Server, *SeriesWriter) {
+	w := &SeriesWriter{ch: make(chan []influxdb.Point, 10)}
+	s := opentsdb.NewServer(w)
+	s.Database = "db"
+	s.BatchTimeout = 10 * time.Millisecond
+	if err := s.ListenAndServe("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	return s, w
+}
+
+// SeriesWriter is a test implementation of opentsdb.SeriesWriter.
+type SeriesWriter struct {
+	ch chan []influxdb.Point
+}
+
+func (w *SeriesWriter) WriteSeries(database, retentionPolicy string, points []influxdb.Point) (uint64, error) {
+	w.ch <- points
+	return 0, nil
+}
+
+// PointsN waits for n points to be written.
+func (w *SeriesWriter) PointsN(t *testing.T, n int) []influxdb.Point {
+	var a []influxdb.Point
+	for len(a) < n {
+		select {
+		case points := <-w.ch:
+			a = append(a, points...)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for points: expected %d, got %d", n, len(a))
+		}
+	}
+	return a
+}
+
+func errstr(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
@@ -42,6 +43,14 @@ const (
 
 	// DefaultShardRetention is the length of time before a shard is dropped.
 	DefaultShardRetention = 7 * (24 * time.Hour)
+
+	// DefaultContinuousQueryCheckInterval is how often continuous queries
+	// are checked for completed intervals.
+	DefaultContinuousQueryCheckInterval = 1 * time.Second
+
+	// DefaultContinuousQueryFailoverDelay is how long a completed interval
+	// is left to each data node with a lower id before a data node computes it.
+	DefaultContinuousQueryFailoverDelay = 1 * time.Minute
 )
 
 const (
@@ -66,13 +75,23 @@ const (
 
 	// Shard messages
 	createShardGroupIfNotExistsMessageType = messaging.MessageType(0x40)
+	deleteShardGroupMessageType            = messaging.MessageType(0x41)
 
 	// Series messages
 	createSeriesIfNotExistsMessageType = messaging.MessageType(0x50)
+	dropSeriesMessageType              = messaging.MessageType(0x51)
+	createFieldsIfNotExistsMessageType = messaging.MessageType(0x52)
 
-	// Write series data messages (per-topic)
-	writeRawSeriesMessageType = messaging.MessageType(0x80)
-	writeSeriesMessageType    = messaging.MessageType(0x81)
+	// Continuous query messages
+	createContinuousQueryMessageType     = messaging.MessageType(0x60)
+	dropContinuousQueryMessageType       = messaging.MessageType(0x61)
+	setContinuousQueryLastRunMessageType = messaging.MessageType(0x62)
+
+	// Series data messages (per-topic)
+	writeRawSeriesMessageType      = messaging.MessageType(0x80)
+	writeSeriesMessageType         = messaging.MessageType(0x81)
+	deleteSeriesRangeMessageType   = messaging.MessageType(0x82)
+	writeRawSeriesBatchMessageType = messaging.MessageType(0x83)
 
 	// Privilege messages
 	setPrivilegeMessageType = messaging.MessageType(0x90)
@@ -80,14 +99,17 @@ const (
 
 // Server represents a collection of metadata and raw metric data.
 type Server struct {
-	mu   sync.RWMutex
-	id   uint64
-	path string
-	done chan struct{} // goroutine close notification
-
-	client MessagingClient  // broker client
-	index  uint64           // highest broadcast index seen
-	errors map[uint64]error // message errors
+	mu     sync.RWMutex
+	id     uint64
+	path   string
+	done   chan struct{} // goroutine close notification
+	rpDone chan struct{} // retention policy enforcement close notification
+	cqDone chan struct{} // continuous query runner close notification
+
+	client   MessagingClient  // broker client
+	index    uint64           // highest broadcast index seen
+	errors   map[uint64]error // message errors
+	deletedN map[uint64]int   // points removed by delete messages
 
 	meta *metastore // metadata store
 
@@ -108,6 +130,7 @@ func NewServer() *Server {
 	s := Server{
 		meta:      &metastore{},
 		errors:    make(map[uint64]error),
+		deletedN:  make(map[uint64]int),
 		dataNodes: make(map[uint64]*DataNode),
 		databases: make(map[string]*database),
 		users:     make(map[string]*User),
@@ -223,9 +246,26 @@ func (s *Server) Close() error {
 	// Remove path.
 	s.path = ""
 
+	// Stop retention policy enforcement.
+	if s.rpDone != nil {
+		close(s.rpDone)
+		s.rpDone = nil
+	}
+
+	// Stop running continuous queries.
+	if s.cqDone != nil {
+		close(s.cqDone)
+		s.cqDone = nil
+	}
+
 	// Close message processing.
 	s.setClient(nil)
 
+	// Close shard stores.
+	for _, sh := range s.shards {
+		_ = sh.close()
+	}
+
 	// Close metastore.
 	_ = s.meta.close()
 
@@ -268,6 +308,7 @@ func (s *Server) load() error {
 						if err := sh.open(s.shardPath(sh.ID)); err != nil {
 							return fmt.Errorf("cannot open shard store: id=%d, err=%s", sh.ID, err)
 						}
+						s.shards[sh.ID] = sh
 					}
 				}
 			}
@@ -534,6 +575,23 @@ func (s *Server) DataNodes() (a []*DataNode) {
 	return
 }
 
+// isFirstDataNode returns true if the server is the data node with the lowest
+// id. Periodic tasks that broadcast commands on behalf of the whole cluster
+// only run on this node.
+func (s *Server) isFirstDataNode() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.dataNodes[s.id] == nil {
+		return false
+	}
+	for id := range s.dataNodes {
+		if id < s.id {
+			return false
+		}
+	}
+	return true
+}
+
 // CreateDataNode creates a new data node with a given URL.
 func (s *Server) CreateDataNode(u *url.URL) error {
 	c := &createDataNodeCommand{URL: u.String()}
@@ -784,8 +842,8 @@ func (s *Server) applyCreateShardGroupIfNotExists(m *messaging.Message) (err err
 
 	// If no shards match then create a new one.
 	g := newShardGroup()
-	g.StartTime = c.Timestamp.Truncate(rp.Duration).UTC()
-	g.EndTime = g.StartTime.Add(rp.Duration).UTC()
+	g.StartTime = c.Timestamp.Truncate(rp.ShardGroupDuration).UTC()
+	g.EndTime = g.StartTime.Add(rp.ShardGroupDuration).UTC()
 
 	// Sort nodes so they're consistently assigned to the shards.
 	nodes := make([]*DataNode, 0, len(s.dataNodes))
@@ -883,6 +941,485 @@ type createShardGroupIfNotExistsCommand struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// DeleteShardGroup deletes the shard group identified by shardID.
+func (s *Server) DeleteShardGroup(database, policy string, shardID uint64) error {
+	c := &deleteShardGroupCommand{Database: database, Policy: policy, ID: shardID}
+	_, err := s.broadcast(deleteShardGroupMessageType, c)
+	return err
+}
+
+func (s *Server) applyDeleteShardGroup(m *messaging.Message) error {
+	var c deleteShardGroupCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	topicIDs, err := s.deleteShardGroup(&c)
+	if err != nil {
+		return err
+	}
+
+	// Unsubscribe from the shards' topics on the broker. This is done after
+	// releasing the lock since it requires a round trip to the broker.
+	for _, topicID := range topicIDs {
+		if err := s.client.Unsubscribe(s.id, topicID); err != nil {
+			log.Printf("unable to unsubscribe: replica=%d, topic=%d, err=%s", s.id, topicID, err)
+		}
+	}
+
+	return nil
+}
+
+// deleteShardGroup removes a shard group and its shards. Returns the ids of
+// the removed shards that this server subscribed to.
+func (s *Server) deleteShardGroup(c *deleteShardGroupCommand) (topicIDs []uint64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Retrieve database.
+	db := s.databases[c.Database]
+	if s.databases[c.Database] == nil {
+		return nil, ErrDatabaseNotFound
+	}
+
+	// Validate retention policy.
+	rp := db.policies[c.Policy]
+	if rp == nil {
+		return nil, ErrRetentionPolicyNotFound
+	}
+
+	// The group may already be gone if the deletion was broadcast twice.
+	g := rp.shardGroupByID(c.ID)
+	if g == nil {
+		return nil, ErrShardGroupNotFound
+	}
+
+	// Remove the group from the policy and persist to the metastore.
+	rp.removeShardGroupByID(c.ID)
+	if err = s.meta.mustUpdate(func(tx *metatx) error {
+		return tx.saveDatabase(db)
+	}); err != nil {
+		return nil, err
+	}
+
+	// Close and remove the shard stores, then remove the shards from the lookups.
+	for _, sh := range g.Shards {
+		if sh.HasDataNodeID(s.id) {
+			topicIDs = append(topicIDs, sh.ID)
+		}
+
+		// Close and delete the shard store, if one was opened.
+		if err := sh.close(); err != nil {
+			log.Printf("unable to close shard: id=%d, err=%s", sh.ID, err)
+		}
+		if err := os.Remove(s.shardPath(sh.ID)); err != nil && !os.IsNotExist(err) {
+			log.Printf("unable to remove shard store: id=%d, err=%s", sh.ID, err)
+		}
+
+		delete(s.shards, sh.ID)
+		s.removeShardFromSeries(sh)
+	}
+
+	return
+}
+
+type deleteShardGroupCommand struct {
+	Database string `json:"database"`
+	Policy   string `json:"policy"`
+	ID       uint64 `json:"id"`
+}
+
+// EnforceRetentionPolicies deletes the shard groups that have aged out of
+// their retention policy. Policies with a zero duration retain data forever.
+func (s *Server) EnforceRetentionPolicies() {
+	type group struct {
+		database string
+		policy   string
+		id       uint64
+	}
+
+	// Find shard groups that have expired.
+	var groups []group
+	now := time.Now().UTC()
+	s.mu.RLock()
+	for _, db := range s.databases {
+		for _, rp := range db.policies {
+			if rp.Duration == 0 {
+				continue
+			}
+			for _, g := range rp.shardGroups {
+				if g.EndTime.Add(rp.Duration).Before(now) {
+					groups = append(groups, group{db.name, rp.Name, g.ID})
+				}
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	// Broadcast the deletion of each group.
+	for _, g := range groups {
+		s.Logger.Printf("shard group %d, database %s, policy %s has expired and will be deleted", g.id, g.database, g.policy)
+		if err := s.DeleteShardGroup(g.database, g.policy, g.id); err != nil && err != ErrShardGroupNotFound {
+			s.Logger.Printf("failed to delete shard group %d: %s", g.id, err)
+		}
+	}
+}
+
+// StartRetentionPolicyEnforcement periodically deletes expired shard groups.
+// Expired groups are only deleted by the data node with the lowest id. The
+// enforcement runs until the server is closed.
+func (s *Server) StartRetentionPolicyEnforcement(checkInterval time.Duration) error {
+	if checkInterval == 0 {
+		return ErrRetentionSweepPeriodRequired
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Stop previous enforcement, if running.
+	if s.rpDone != nil {
+		close(s.rpDone)
+	}
+	done := make(chan struct{}, 0)
+	s.rpDone = done
+
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				// Only one data node broadcasts the deletions.
+				if s.isFirstDataNode() {
+					s.EnforceRetentionPolicies()
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// CreateContinuousQuery creates a continuous query on the database in the statement.
+func (s *Server) CreateContinuousQuery(q *influxql.CreateContinuousQueryStatement) error {
+	// Validate the query before broadcasting it.
+	if _, err := NewContinuousQuery(q.String()); err != nil {
+		return err
+	}
+
+	c := &createContinuousQueryCommand{Query: q.String()}
+	_, err := s.broadcast(createContinuousQueryMessageType, c)
+	return err
+}
+
+func (s *Server) applyCreateContinuousQuery(m *messaging.Message) error {
+	var c createContinuousQueryCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	cq, err := NewContinuousQuery(c.Query)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Validate command.
+	db := s.databases[cq.cq.Database]
+	if db == nil {
+		return ErrDatabaseNotFound
+	} else if db.continuousQueryByName(cq.Name()) != nil {
+		return ErrContinuousQueryExists
+	}
+
+	// Add query to database and persist.
+	db.continuousQueries = append(db.continuousQueries, cq)
+	return s.meta.mustUpdate(func(tx *metatx) error {
+		return tx.saveDatabase(db)
+	})
+}
+
+type createContinuousQueryCommand struct {
+	Query string `json:"query"`
+}
+
+// DropContinuousQuery removes a continuous query from a database.
+func (s *Server) DropContinuousQuery(database, name string) error {
+	c := &dropContinuousQueryCommand{Database: database, Name: name}
+	_, err := s.broadcast(dropContinuousQueryMessageType, c)
+	return err
+}
+
+func (s *Server) applyDropContinuousQuery(m *messaging.Message) error {
+	var c dropContinuousQueryCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Validate command.
+	db := s.databases[c.Database]
+	if db == nil {
+		return ErrDatabaseNotFound
+	}
+
+	// Remove query from database and persist.
+	for i, cq := range db.continuousQueries {
+		if cq.Name() == c.Name {
+			db.continuousQueries = append(db.continuousQueries[:i], db.continuousQueries[i+1:]...)
+			return s.meta.mustUpdate(func(tx *metatx) error {
+				return tx.saveDatabase(db)
+			})
+		}
+	}
+	return ErrContinuousQueryNotFound
+}
+
+type dropContinuousQueryCommand struct {
+	Database string `json:"database"`
+	Name     string `json:"name"`
+}
+
+// setContinuousQueryLastRun records the end of the last interval computed by
+// a continuous query so that any node running the queries can resume from it.
+func (s *Server) setContinuousQueryLastRun(database, name string, lastRun time.Time) error {
+	c := &setContinuousQueryLastRunCommand{Database: database, Name: name, LastRun: lastRun}
+	_, err := s.broadcast(setContinuousQueryLastRunMessageType, c)
+	return err
+}
+
+func (s *Server) applySetContinuousQueryLastRun(m *messaging.Message) error {
+	var c setContinuousQueryLastRunCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Validate command.
+	db := s.databases[c.Database]
+	if db == nil {
+		return ErrDatabaseNotFound
+	}
+	cq := db.continuousQueryByName(c.Name)
+	if cq == nil {
+		return ErrContinuousQueryNotFound
+	}
+
+	// Update the query and persist.
+	cq.lastRun = c.LastRun
+	return s.meta.mustUpdate(func(tx *metatx) error {
+		return tx.saveDatabase(db)
+	})
+}
+
+type setContinuousQueryLastRunCommand struct {
+	Database string    `json:"database"`
+	Name     string    `json:"name"`
+	LastRun  time.Time `json:"lastRun"`
+}
+
+// ContinuousQueries returns a list of all continuous queries for a database.
+func (s *Server) ContinuousQueries(database string) ([]*ContinuousQuery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	db := s.databases[database]
+	if db == nil {
+		return nil, ErrDatabaseNotFound
+	}
+
+	a := make([]*ContinuousQuery, len(db.continuousQueries))
+	copy(a, db.continuousQueries)
+	return a, nil
+}
+
+// RunContinuousQueries executes every continuous query whose GROUP BY time()
+// interval has completed since its last run. Intervals are computed relative
+// to now. Queries must not be run concurrently.
+//
+// The data node with the lowest id computes intervals as soon as they
+// complete. Every other data node only computes intervals that have been
+// complete for DefaultContinuousQueryFailoverDelay per data node with a lower
+// id so that it takes over when those nodes stop running the queries.
+func (s *Server) RunContinuousQueries(now time.Time) {
+	type query struct {
+		database string
+		cq       *ContinuousQuery
+		lastRun  time.Time
+	}
+
+	// Find all queries.
+	var queries []query
+	s.mu.RLock()
+	if s.dataNodes[s.id] == nil {
+		s.mu.RUnlock()
+		return
+	}
+	for id := range s.dataNodes {
+		if id < s.id {
+			now = now.Add(-DefaultContinuousQueryFailoverDelay)
+		}
+	}
+	for _, db := range s.databases {
+		for _, cq := range db.continuousQueries {
+			queries = append(queries, query{db.name, cq, cq.lastRun})
+		}
+	}
+	s.mu.RUnlock()
+
+	// Execute each query outside of the lock.
+	for _, q := range queries {
+		if err := s.runContinuousQuery(q.database, q.cq, q.lastRun, now); err != nil {
+			s.Logger.Printf("continuous query %s on %s: %s", q.cq.Name(), q.database, err)
+		}
+	}
+}
+
+// runContinuousQuery computes the intervals of a query that have completed
+// since its last run and writes the results into the query's target.
+// The end of the last computed interval is then broadcast to all nodes.
+func (s *Server) runContinuousQuery(database string, cq *ContinuousQuery, lastRun, now time.Time) error {
+	// Determine the time range of completed intervals that haven't been computed.
+	interval, _, _ := cq.cq.Source.Dimensions.Normalize()
+	end := now.Truncate(interval)
+	if !lastRun.Before(end) {
+		return nil
+	}
+	start := end.Add(-interval)
+	if !lastRun.IsZero() && lastRun.Before(start) {
+		start = lastRun
+	}
+
+	// Restrict the query to the time range.
+	stmt := cq.cq.Source.Clone()
+	cond := &influxql.BinaryExpr{
+		Op:  influxql.AND,
+		LHS: &influxql.BinaryExpr{Op: influxql.GTE, LHS: &influxql.VarRef{Val: "time"}, RHS: &influxql.TimeLiteral{Val: start}},
+		RHS: &influxql.BinaryExpr{Op: influxql.LT, LHS: &influxql.VarRef{Val: "time"}, RHS: &influxql.TimeLiteral{Val: end}},
+	}
+	if stmt.Condition != nil {
+		stmt.Condition = &influxql.BinaryExpr{Op: influxql.AND, LHS: &influxql.ParenExpr{Expr: stmt.Condition}, RHS: cond}
+	} else {
+		stmt.Condition = cond
+	}
+	if err := s.NormalizeStatement(stmt, database); err != nil {
+		return err
+	}
+
+	// Execute the query and read all rows.
+	executors, err := s.planSelectStatement(stmt, database)
+	if err != nil {
+		return err
+	}
+	var rows []*influxql.Row
+	for _, e := range executors {
+		ch, err := e.Execute()
+		if err != nil {
+			return err
+		}
+		for row := range ch {
+			if row.Err != nil {
+				return row.Err
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	// Write the results to the target.
+	if _, err := s.writeRows(stmt.Target, database, rows); err != nil {
+		return err
+	}
+
+	return s.setContinuousQueryLastRun(database, cq.Name(), end)
+}
+
+// StartContinuousQueries periodically runs continuous queries on every data
+// node. The queries run until the server is closed.
+func (s *Server) StartContinuousQueries(checkInterval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Stop previous runner, if running.
+	if s.cqDone != nil {
+		close(s.cqDone)
+	}
+	done := make(chan struct{}, 0)
+	s.cqDone = done
+
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				s.RunContinuousQueries(time.Now().UTC())
+			}
+		}
+	}()
+}
+
+// writeRows writes the rows of a query result as points into a target.
+// The target measurement may be qualified with a retention policy.
+// Returns the number of points written.
+func (s *Server) writeRows(target *influxql.Target, database string, rows []*influxql.Row) (int, error) {
+	// Determine the target database, retention policy and measurement.
+	if target.Database != "" {
+		database = target.Database
+	}
+	segments, err := influxql.SplitIdent(target.Measurement)
+	if err != nil {
+		return 0, err
+	}
+	var policy, name string
+	switch len(segments) {
+	case 1:
+		name = segments[0]
+	case 2:
+		policy, name = segments[0], segments[1]
+	default:
+		return 0, fmt.Errorf("invalid target measurement: %s", target.Measurement)
+	}
+
+	// Convert each row value into a point. The first column is the timestamp.
+	var points []Point
+	for _, row := range rows {
+		for _, v := range row.Values {
+			var timestamp time.Time
+			switch t := v[0].(type) {
+			case time.Time:
+				timestamp = t
+			case string:
+				if timestamp, err = time.Parse(time.RFC3339Nano, t); err != nil {
+					return 0, err
+				}
+			default:
+				return 0, fmt.Errorf("invalid timestamp: %v", v[0])
+			}
+
+			values := make(map[string]interface{})
+			for i, col := range row.Columns[1:] {
+				if v[i+1] != nil {
+					values[col] = v[i+1]
+				}
+			}
+			if len(values) == 0 {
+				continue
+			}
+
+			points = append(points, Point{Name: name, Tags: row.Tags, Timestamp: timestamp, Values: values})
+		}
+	}
+	if len(points) == 0 {
+		return 0, nil
+	}
+
+	if _, err := s.WriteSeries(database, policy, points); err != nil {
+		return 0, err
+	}
+	return len(points), nil
+}
+
 // User returns a user by username
 // Returns nil if the user does not exist.
 func (s *Server) User(name string) *User {
@@ -1165,10 +1702,11 @@ func (s *Server) RetentionPolicies(database string) ([]*RetentionPolicy, error)
 // CreateRetentionPolicy creates a retention policy for a database.
 func (s *Server) CreateRetentionPolicy(database string, rp *RetentionPolicy) error {
 	c := &createRetentionPolicyCommand{
-		Database: database,
-		Name:     rp.Name,
-		Duration: rp.Duration,
-		ReplicaN: rp.ReplicaN,
+		Database:           database,
+		Name:               rp.Name,
+		Duration:           rp.Duration,
+		ShardGroupDuration: rp.ShardGroupDuration,
+		ReplicaN:           rp.ReplicaN,
 	}
 	_, err := s.broadcast(createRetentionPolicyMessageType, c)
 	return err
@@ -1191,11 +1729,18 @@ func (s *Server) applyCreateRetentionPolicy(m *messaging.Message) error {
 		return ErrRetentionPolicyExists
 	}
 
+	// Use the default shard group duration if one is not specified.
+	sgDuration := c.ShardGroupDuration
+	if sgDuration == 0 {
+		sgDuration = shardGroupDuration(c.Duration)
+	}
+
 	// Add policy to the database.
 	db.policies[c.Name] = &RetentionPolicy{
-		Name:     c.Name,
-		Duration: c.Duration,
-		ReplicaN: c.ReplicaN,
+		Name:               c.Name,
+		Duration:           c.Duration,
+		ShardGroupDuration: sgDuration,
+		ReplicaN:           c.ReplicaN,
 	}
 
 	// Persist to metastore.
@@ -1207,19 +1752,21 @@ func (s *Server) applyCreateRetentionPolicy(m *messaging.Message) error {
 }
 
 type createRetentionPolicyCommand struct {
-	Database string        `json:"database"`
-	Name     string        `json:"name"`
-	Duration time.Duration `json:"duration"`
-	ReplicaN uint32        `json:"replicaN"`
-	SplitN   uint32        `json:"splitN"`
+	Database           string        `json:"database"`
+	Name               string        `json:"name"`
+	Duration           time.Duration `json:"duration"`
+	ShardGroupDuration time.Duration `json:"shardGroupDuration"`
+	ReplicaN           uint32        `json:"replicaN"`
+	SplitN             uint32        `json:"splitN"`
 }
 
 // RetentionPolicyUpdate represents retention policy fields that
 // need to be updated.
 type RetentionPolicyUpdate struct {
-	Name     *string        `json:"name,omitempty"`
-	Duration *time.Duration `json:"duration,omitempty"`
-	ReplicaN *uint32        `json:"replicaN,omitempty"`
+	Name               *string        `json:"name,omitempty"`
+	Duration           *time.Duration `json:"duration,omitempty"`
+	ShardGroupDuration *time.Duration `json:"shardGroupDuration,omitempty"`
+	ReplicaN           *uint32        `json:"replicaN,omitempty"`
 }
 
 // UpdateRetentionPolicy updates an existing retention policy on a database.
@@ -1268,6 +1815,15 @@ func (s *Server) applyUpdateRetentionPolicy(m *messaging.Message) (err error) {
 		p.Duration = *c.Policy.Duration
 	}
 
+	// Update shard group duration, using the default for the policy's
+	// duration if it is zero. Existing shard groups keep their width.
+	if c.Policy.ShardGroupDuration != nil {
+		p.ShardGroupDuration = *c.Policy.ShardGroupDuration
+		if p.ShardGroupDuration <= 0 {
+			p.ShardGroupDuration = shardGroupDuration(p.Duration)
+		}
+	}
+
 	// Update replication factor.
 	if c.Policy.ReplicaN != nil {
 		p.ReplicaN = *c.Policy.ReplicaN
@@ -1397,6 +1953,113 @@ type createSeriesIfNotExistsCommand struct {
 	Tags     map[string]string `json:"tags"`
 }
 
+// applyCreateFieldsIfNotExists adds the fields of a measurement that don't
+// exist yet. Fields beyond the maximum number of fields are logged and skipped.
+func (s *Server) applyCreateFieldsIfNotExists(m *messaging.Message) error {
+	var c createFieldsIfNotExistsCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Validate command.
+	db := s.databases[c.Database]
+	if db == nil {
+		return ErrDatabaseNotFound
+	}
+	mm := db.measurements[c.Measurement]
+	if mm == nil {
+		return ErrMeasurementNotFound
+	}
+
+	for _, f := range c.Fields {
+		if _, err := mm.createFieldIfNotExists(f.Name, f.Type); err == ErrFieldOverflow {
+			log.Printf("no more fields allowed: %s::%s", mm.Name, f.Name)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	// Update metastore.
+	return s.meta.mustUpdate(func(tx *metatx) error {
+		if err := tx.saveMeasurement(db.name, mm); err != nil {
+			return fmt.Errorf("save measurement: %s", err)
+		}
+		return tx.saveDatabase(db)
+	})
+}
+
+type createFieldsIfNotExistsCommand struct {
+	Database    string   `json:"database"`
+	Measurement string   `json:"measurement"`
+	Fields      []*Field `json:"fields"`
+}
+
+// DropSeries deletes the series and their data from a database.
+// Series ids are grouped by measurement name.
+func (s *Server) DropSeries(database string, seriesByMeasurement map[string][]uint32) error {
+	c := &dropSeriesCommand{Database: database, SeriesByMeasurement: seriesByMeasurement}
+	_, err := s.broadcast(dropSeriesMessageType, c)
+	return err
+}
+
+func (s *Server) applyDropSeries(m *messaging.Message) error {
+	var c dropSeriesCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Validate command.
+	db := s.databases[c.Database]
+	if db == nil {
+		return ErrDatabaseNotFound
+	}
+
+	// Remove from metastore.
+	if err := s.meta.mustUpdate(func(tx *metatx) error {
+		for name, ids := range c.SeriesByMeasurement {
+			for _, id := range ids {
+				if err := tx.dropSeries(db.name, name, id); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// Remove series data from the database's local shards.
+	for _, ids := range c.SeriesByMeasurement {
+		for _, rp := range db.policies {
+			for _, g := range rp.shardGroups {
+				for _, sh := range g.Shards {
+					if sh.store == nil {
+						continue
+					}
+					for _, id := range ids {
+						if err := sh.deleteSeries(id); err != nil {
+							return err
+						}
+						s.removeShardBySeriesID(sh, id)
+					}
+				}
+			}
+		}
+
+		// Remove from the in memory index.
+		db.dropSeries(ids...)
+	}
+
+	return nil
+}
+
+type dropSeriesCommand struct {
+	Database            string              `json:"database"`
+	SeriesByMeasurement map[string][]uint32 `json:"seriesIds"`
+}
+
 // Point defines the values that will be written to the database
 type Point struct {
 	Name      string
@@ -1419,29 +2082,85 @@ func (s *Server) WriteSeries(database, retentionPolicy string, points []Point) (
 		retentionPolicy = rp.Name
 	}
 
+	// Create the series and fields that don't exist yet so that points
+	// can be encoded as raw writes.
+	if err := s.createSeriesAndFields(database, points); err != nil {
+		return 0, err
+	}
+
+	// Encode the points and group the messages by shard in point order.
+	// Consecutive raw points of a shard are sent as a single batch message.
+	// Invalid points are skipped and the first error is returned after the
+	// rest are written.
+	var err error
+	var shardIDs []uint64
+	msgs := make(map[uint64][]*messaging.Message)
+	batches := make(map[uint64][][]byte)
+	flush := func(shardID uint64) {
+		if len(batches[shardID]) == 0 {
+			return
+		}
+		msgs[shardID] = append(msgs[shardID], &messaging.Message{
+			Type:    writeRawSeriesBatchMessageType,
+			TopicID: shardID,
+			Data:    marshalPointBatch(batches[shardID]),
+		})
+		batches[shardID] = nil
+	}
+	for i := range points {
+		m, e := s.encodePoint(database, retentionPolicy, &points[i])
+		if e != nil {
+			if err == nil {
+				err = e
+			}
+			continue
+		}
+
+		if _, ok := msgs[m.TopicID]; !ok {
+			shardIDs = append(shardIDs, m.TopicID)
+			msgs[m.TopicID] = nil
+		}
+		if m.Type == writeRawSeriesMessageType {
+			batches[m.TopicID] = append(batches[m.TopicID], m.Data)
+			continue
+		}
+		flush(m.TopicID)
+		msgs[m.TopicID] = append(msgs[m.TopicID], m)
+	}
+	for _, shardID := range shardIDs {
+		flush(shardID)
+	}
+
 	// Collect responses for each channel.
 	type resp struct {
 		index uint64
 		err   error
 	}
-	ch := make(chan resp, len(points))
+	ch := make(chan resp, len(shardIDs))
 
-	// Write each point in parallel.
+	// Publish the messages of each shard in order and the shards in parallel.
 	var wg sync.WaitGroup
-	for i := range points {
+	for _, shardID := range shardIDs {
 		wg.Add(1)
-		go func(p *Point) {
-			index, err := s.writePoint(database, retentionPolicy, p)
-			ch <- resp{index, err}
-			wg.Done()
-		}(&points[i])
+		go func(msgs []*messaging.Message) {
+			defer wg.Done()
+			var index uint64
+			for _, m := range msgs {
+				i, err := s.client.Publish(m)
+				if err != nil {
+					ch <- resp{index, err}
+					return
+				}
+				index = i
+			}
+			ch <- resp{index, nil}
+		}(msgs[shardID])
 	}
 	wg.Wait()
 	close(ch)
 
 	// Calculate max index and check for errors.
 	var index uint64
-	var err error
 	for resp := range ch {
 		if resp.index > index {
 			index = resp.index
@@ -1453,35 +2172,34 @@ func (s *Server) WriteSeries(database, retentionPolicy string, points []Point) (
 	return index, err
 }
 
-func (s *Server) writePoint(database, retentionPolicy string, point *Point) (uint64, error) {
+// encodePoint validates a point and encodes it into a message for its shard's topic.
+// The message is a raw write if all of the point's fields already exist.
+func (s *Server) encodePoint(database, retentionPolicy string, point *Point) (*messaging.Message, error) {
 	name, tags, timestamp, values := point.Name, point.Tags, point.Timestamp, point.Values
 
 	// Sanity-check the data point.
-	if name == "" {
-		return 0, ErrMeasurementNameRequired
-	}
-	if len(values) == 0 {
-		return 0, ErrValuesRequired
+	if err := validatePoint(point); err != nil {
+		return nil, err
 	}
 
 	// Find the id for the series and tagset
 	seriesID, err := s.createSeriesIfNotExists(database, name, tags)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	// Retrieve measurement.
 	m, err := s.measurement(database, name)
 	if err != nil {
-		return 0, err
+		return nil, err
 	} else if m == nil {
-		return 0, ErrMeasurementNotFound
+		return nil, ErrMeasurementNotFound
 	}
 
 	// Retrieve shard group.
 	g, err := s.createShardGroupIfNotExists(database, retentionPolicy, timestamp)
 	if err != nil {
-		return 0, fmt.Errorf("create shard(%s/%s): %s", retentionPolicy, timestamp.Format(time.RFC3339Nano), err)
+		return nil, fmt.Errorf("create shard(%s/%s): %s", retentionPolicy, timestamp.Format(time.RFC3339Nano), err)
 	}
 
 	// Find appropriate shard within the shard group.
@@ -1489,8 +2207,18 @@ func (s *Server) writePoint(database, retentionPolicy string, point *Point) (uin
 
 	// Convert string-key/values to fieldID-key/values.
 	// If not all fields can be converted then send as a non-raw write series.
-	rawValues := m.mapValues(values)
-	if rawValues == nil {
+	s.mu.RLock()
+	rawValues, err := m.mapValues(values)
+	s.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	} else if rawValues == nil {
+		// Record value types so they survive the JSON encoding.
+		types := make(map[string]influxql.DataType, len(values))
+		for k, v := range values {
+			types[k] = influxql.InspectDataType(v)
+		}
+
 		// Encode the command.
 		data := mustMarshalJSON(&writeSeriesCommand{
 			Database:    database,
@@ -1498,14 +2226,15 @@ func (s *Server) writePoint(database, retentionPolicy string, point *Point) (uin
 			SeriesID:    seriesID,
 			Timestamp:   timestamp.UnixNano(),
 			Values:      values,
+			Types:       types,
 		})
 
-		// Publish "write series" message on shard's topic to broker.
-		return s.client.Publish(&messaging.Message{
+		// Create "write series" message for the shard's topic.
+		return &messaging.Message{
 			Type:    writeSeriesMessageType,
 			TopicID: sh.ID,
 			Data:    data,
-		})
+		}, nil
 	}
 
 	// If we can successfully encode the string keys to raw field ids then
@@ -1515,28 +2244,34 @@ func (s *Server) writePoint(database, retentionPolicy string, point *Point) (uin
 	data := marshalPointHeader(seriesID, timestamp.UnixNano())
 	data = append(data, marshalValues(rawValues)...)
 
-	// Publish "raw write series" message on shard's topic to broker.
-	return s.client.Publish(&messaging.Message{
+	// Create "raw write series" message for the shard's topic.
+	return &messaging.Message{
 		Type:    writeRawSeriesMessageType,
 		TopicID: sh.ID,
 		Data:    data,
-	})
+	}, nil
 }
 
 type writeSeriesCommand struct {
-	Database    string                 `json:"database"`
-	Measurement string                 `json:"measurement"`
-	SeriesID    uint32                 `json:"seriesID"`
-	Timestamp   int64                  `json:"timestamp"`
-	Values      map[string]interface{} `json:"values"`
+	Database    string                       `json:"database"`
+	Measurement string                       `json:"measurement"`
+	SeriesID    uint32                       `json:"seriesID"`
+	Timestamp   int64                        `json:"timestamp"`
+	Values      map[string]interface{}       `json:"values"`
+	Types       map[string]influxql.DataType `json:"types,omitempty"`
 }
 
 // applyWriteSeries writes "non-raw" series data to the database.
 // Non-raw data occurs when fields have not been created yet so the field
 // names cannot be converted to field ids.
 func (s *Server) applyWriteSeries(m *messaging.Message) error {
+	// Decode numbers as json.Number so integers keep their precision.
 	var c writeSeriesCommand
-	mustUnmarshalJSON(m.Data, &c)
+	dec := json.NewDecoder(bytes.NewReader(m.Data))
+	dec.UseNumber()
+	if err := dec.Decode(&c); err != nil {
+		panic("unmarshal: " + err.Error())
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -1559,15 +2294,27 @@ func (s *Server) applyWriteSeries(m *messaging.Message) error {
 		return ErrMeasurementNotFound
 	}
 
+	// Verify that values match the types of existing fields before creating
+	// any new fields so a conflicting point is rejected as a whole.
+	for k, v := range c.Values {
+		typ := c.Types[k]
+		if f := mm.FieldByName(k); f != nil {
+			typ = f.Type
+		}
+		value, err := castFieldValue(v, typ)
+		if err != nil {
+			return err
+		}
+		c.Values[k] = value
+	}
+
 	// Encode value map and create fields as needed.
 	rawValues := make(map[uint8]interface{}, len(c.Values))
 	for k, v := range c.Values {
-		// TODO: Support non-float types.
-
 		// Find or create fields.
 		// If too many fields are on the measurement then log the issue.
 		// If any other error occurs then exit.
-		f, err := mm.createFieldIfNotExists(k, influxql.Number)
+		f, err := mm.createFieldIfNotExists(k, influxql.InspectDataType(v))
 		if err == ErrFieldOverflow {
 			log.Printf("no more fields allowed: %s::%s", mm.Name, k)
 			continue
@@ -1625,6 +2372,137 @@ func (s *Server) applyWriteRawSeries(m *messaging.Message) error {
 	return sh.writeSeries(seriesID, timestamp, data, overwrite)
 }
 
+// applyWriteRawSeriesBatch writes a batch of raw series data to a shard
+// in a single transaction.
+func (s *Server) applyWriteRawSeriesBatch(m *messaging.Message) error {
+	// Retrieve the shard.
+	sh := s.Shard(m.TopicID)
+	if sh == nil {
+		return ErrShardNotFound
+	}
+
+	// Add each series to the lookup.
+	points := unmarshalPointBatch(m.Data)
+	for _, p := range points {
+		seriesID, _ := unmarshalPointHeader(p[:pointHeaderSize])
+		s.addShardBySeriesID(sh, seriesID)
+	}
+
+	// Write to shard.
+	return sh.writeSeriesBatch(points)
+}
+
+// DeleteSeriesRange removes points between tmin and tmax, inclusive, from a
+// set of series in a retention policy. The delete is published to the topic
+// of every shard holding the series so all replicas remove the same points.
+// Returns the number of points removed from shards stored on this server.
+func (s *Server) DeleteSeriesRange(database, retentionPolicy string, seriesIDs []uint32, tmin, tmax time.Time) (int, error) {
+	var msgs []*messaging.Message
+	var local []bool
+
+	// Build a message for each shard holding the series in the time range.
+	if err := func() error {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		db := s.databases[database]
+		if db == nil {
+			return ErrDatabaseNotFound
+		}
+		rp := db.policies[retentionPolicy]
+		if rp == nil {
+			return ErrRetentionPolicyNotFound
+		}
+
+		for _, g := range rp.shardGroups {
+			if g.StartTime.After(tmax) || g.EndTime.Before(tmin) {
+				continue
+			}
+
+			// Group the series ids by the shard they're assigned to.
+			idsByShard := make(map[*Shard][]uint32)
+			for _, id := range seriesIDs {
+				sh := g.ShardBySeriesID(id)
+				idsByShard[sh] = append(idsByShard[sh], id)
+			}
+
+			for sh, ids := range idsByShard {
+				local = append(local, sh.store != nil)
+				msgs = append(msgs, &messaging.Message{
+					Type:    deleteSeriesRangeMessageType,
+					TopicID: sh.ID,
+					Data:    mustMarshalJSON(&deleteSeriesRangeCommand{SeriesIDs: ids, Min: tmin.UnixNano(), Max: tmax.UnixNano()}),
+				})
+			}
+		}
+		return nil
+	}(); err != nil {
+		return 0, err
+	}
+
+	// Publish "delete series range" messages on each shard's topic.
+	var index uint64
+	var indices []uint64
+	for i, m := range msgs {
+		n, err := s.client.Publish(m)
+		if err != nil {
+			return 0, err
+		} else if n > index {
+			index = n
+		}
+		if local[i] {
+			indices = append(indices, n)
+		}
+	}
+
+	// Wait for the local shards to apply the delete.
+	if len(indices) == 0 {
+		return 0, nil
+	}
+	if err := s.Sync(index); err != nil {
+		return 0, err
+	}
+
+	// Sum the points removed by each local shard.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int
+	for _, i := range indices {
+		n += s.deletedN[i]
+		delete(s.deletedN, i)
+	}
+	return n, nil
+}
+
+type deleteSeriesRangeCommand struct {
+	SeriesIDs []uint32 `json:"seriesIDs"`
+	Min       int64    `json:"min"`
+	Max       int64    `json:"max"`
+}
+
+// applyDeleteSeriesRange removes a time range of points from series in a shard.
+func (s *Server) applyDeleteSeriesRange(m *messaging.Message) error {
+	var c deleteSeriesRangeCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	// Retrieve the shard.
+	sh := s.Shard(m.TopicID)
+	if sh == nil {
+		return ErrShardNotFound
+	}
+
+	n, err := sh.deleteSeriesRange(c.SeriesIDs, c.Min, c.Max)
+	if err != nil {
+		return err
+	}
+
+	// Record the number of points removed for the publisher of the delete.
+	s.mu.Lock()
+	s.deletedN[m.Index] = n
+	s.mu.Unlock()
+	return nil
+}
+
 func (s *Server) addShardBySeriesID(sh *Shard, seriesID uint32) {
 	for _, other := range s.shardsBySeriesID[seriesID] {
 		if other.ID == sh.ID {
@@ -1634,6 +2512,124 @@ func (s *Server) addShardBySeriesID(sh *Shard, seriesID uint32) {
 	s.shardsBySeriesID[seriesID] = append(s.shardsBySeriesID[seriesID], sh)
 }
 
+// removeShardBySeriesID removes a shard from the lookup of a series.
+func (s *Server) removeShardBySeriesID(sh *Shard, seriesID uint32) {
+	shards := s.shardsBySeriesID[seriesID]
+	for i, other := range shards {
+		if other.ID == sh.ID {
+			s.shardsBySeriesID[seriesID] = append(shards[:i], shards[i+1:]...)
+			return
+		}
+	}
+}
+
+// removeShardFromSeries removes a shard from the lookups of all series.
+func (s *Server) removeShardFromSeries(sh *Shard) {
+	for seriesID := range s.shardsBySeriesID {
+		s.removeShardBySeriesID(sh, seriesID)
+	}
+}
+
+// validatePoint returns an error if a point has no name or values or if any
+// of its values can't be stored.
+func validatePoint(p *Point) error {
+	if p.Name == "" {
+		return ErrMeasurementNameRequired
+	}
+	if len(p.Values) == 0 {
+		return ErrValuesRequired
+	}
+	for _, v := range p.Values {
+		switch influxql.InspectDataType(v) {
+		case influxql.Number, influxql.Integer, influxql.Boolean:
+		case influxql.String:
+			if len(v.(string)) > maxStringValueSize {
+				return ErrFieldValueTooLarge
+			}
+		default:
+			return ErrFieldTypeUnsupported
+		}
+	}
+	return nil
+}
+
+// createSeriesAndFields creates the series and fields of valid points that
+// don't exist yet. New fields take the type of their first value. The commands
+// are published in the order of the points so series ids follow that order,
+// and the server only waits once for all of them to be applied.
+func (s *Server) createSeriesAndFields(database string, points []Point) error {
+	s.mu.RLock()
+	db := s.databases[database]
+	if db == nil {
+		s.mu.RUnlock()
+		return fmt.Errorf("database not found %q", database)
+	}
+	var msgs []*messaging.Message
+	var names []string
+	keys := make(map[string]struct{})   // series and fields already collected
+	fields := make(map[string][]*Field) // new fields by measurement
+	for i := range points {
+		p := &points[i]
+		if validatePoint(p) != nil {
+			continue
+		}
+
+		// Create the series.
+		if _, series := db.MeasurementAndSeries(p.Name, p.Tags); series == nil {
+			key := p.Name + "," + string(marshalTags(p.Tags))
+			if _, ok := keys[key]; !ok {
+				keys[key] = struct{}{}
+				msgs = append(msgs, &messaging.Message{
+					Type:    createSeriesIfNotExistsMessageType,
+					TopicID: messaging.BroadcastTopicID,
+					Data:    mustMarshalJSON(&createSeriesIfNotExistsCommand{Database: database, Name: p.Name, Tags: p.Tags}),
+				})
+			}
+		}
+
+		// Collect the fields the measurement doesn't have yet.
+		m := db.measurements[p.Name]
+		for k, v := range p.Values {
+			if m != nil && m.FieldByName(k) != nil {
+				continue
+			}
+			key := p.Name + "\x00" + k
+			if _, ok := keys[key]; ok {
+				continue
+			}
+			keys[key] = struct{}{}
+			if _, ok := fields[p.Name]; !ok {
+				names = append(names, p.Name)
+			}
+			fields[p.Name] = append(fields[p.Name], &Field{Name: k, Type: influxql.InspectDataType(v)})
+		}
+	}
+	// release the read lock so the commands can be applied under the write lock
+	s.mu.RUnlock()
+
+	// Fields are created once their measurements exist.
+	for _, name := range names {
+		msgs = append(msgs, &messaging.Message{
+			Type:    createFieldsIfNotExistsMessageType,
+			TopicID: messaging.BroadcastTopicID,
+			Data:    mustMarshalJSON(&createFieldsIfNotExistsCommand{Database: database, Measurement: name, Fields: fields[name]}),
+		})
+	}
+
+	var index uint64
+	for _, m := range msgs {
+		i, err := s.client.Publish(m)
+		if err != nil {
+			return err
+		}
+		index = i
+	}
+	if index == 0 {
+		return nil
+	}
+	return s.Sync(index)
+}
+
 func (s *Server) createSeriesIfNotExists(database, name string, tags map[string]string) (uint32, error) {
 	// Try to find series locally first.
 	s.mu.RLock()
@@ -1750,60 +2746,11 @@ func (s *Server) ExecuteQuery(q *influxql.Query, database string, user *User) Re
 		// Set default database and policy on the statement.
 		if err := s.NormalizeStatement(stmt, database); err != nil {
 			results.Results[i] = &Result{Err: err}
-			break
-		}
-
-		var res *Result
-		switch stmt := stmt.(type) {
-		case *influxql.SelectStatement:
-			res = s.executeSelectStatement(stmt, database, user)
-		case *influxql.CreateDatabaseStatement:
-			res = s.executeCreateDatabaseStatement(stmt, user)
-		case *influxql.DropDatabaseStatement:
-			res = s.executeDropDatabaseStatement(stmt, user)
-		case *influxql.ShowDatabasesStatement:
-			res = s.executeShowDatabasesStatement(stmt, user)
-		case *influxql.CreateUserStatement:
-			res = s.executeCreateUserStatement(stmt, user)
-		case *influxql.DropUserStatement:
-			res = s.executeDropUserStatement(stmt, user)
-		case *influxql.ShowUsersStatement:
-			res = s.executeShowUsersStatement(stmt, user)
-		case *influxql.DropSeriesStatement:
-			continue
-		case *influxql.ShowSeriesStatement:
-			res = s.executeShowSeriesStatement(stmt, database, user)
-		case *influxql.ShowMeasurementsStatement:
-			res = s.executeShowMeasurementsStatement(stmt, database, user)
-		case *influxql.ShowTagKeysStatement:
-			res = s.executeShowTagKeysStatement(stmt, database, user)
-		case *influxql.ShowTagValuesStatement:
-			res = s.executeShowTagValuesStatement(stmt, database, user)
-		case *influxql.ShowFieldKeysStatement:
-			res = s.executeShowFieldKeysStatement(stmt, database, user)
-		case *influxql.GrantStatement:
-			res = s.executeGrantStatement(stmt, user)
-		case *influxql.RevokeStatement:
-			res = s.executeRevokeStatement(stmt, user)
-		case *influxql.CreateRetentionPolicyStatement:
-			res = s.executeCreateRetentionPolicyStatement(stmt, user)
-		case *influxql.AlterRetentionPolicyStatement:
-			res = s.executeAlterRetentionPolicyStatement(stmt, user)
-		case *influxql.DropRetentionPolicyStatement:
-			res = s.executeDropRetentionPolicyStatement(stmt, user)
-		case *influxql.ShowRetentionPoliciesStatement:
-			res = s.executeShowRetentionPoliciesStatement(stmt, user)
-		case *influxql.CreateContinuousQueryStatement:
-			continue
-		case *influxql.DropContinuousQueryStatement:
-			continue
-		case *influxql.ShowContinuousQueriesStatement:
-			continue
-		default:
-			panic(fmt.Sprintf("unsupported statement type: %T", stmt))
+			break
 		}
 
 		// If an error occurs then stop processing remaining statements.
+		res := s.executeStatement(stmt, database, user)
 		results.Results[i] = res
 		if res.Err != nil {
 			break
@@ -1820,31 +2767,303 @@ func (s *Server) ExecuteQuery(q *influxql.Query, database string, user *User) Re
 	return results
 }
 
+// ExecuteQueryChunked executes an InfluxQL query against the server and
+// streams the results on the returned channel as they are produced.
+//
+// Each result holds the rows for part of a statement, identified by its
+// StatementID. Select statements are split into results of at most chunkSize
+// points, all of which are marked as partial except the statement's last
+// result. A chunkSize of zero or less does not limit the size of results.
+// Other statements produce a single result. If a statement fails then
+// the remaining statements are not executed. Execution stops once closing is
+// closed, such as when the client goes away. The channel is closed once all
+// statements have been processed.
+//
+// Returns an error if the user is not authorized to execute the query.
+func (s *Server) ExecuteQueryChunked(q *influxql.Query, database string, user *User, chunkSize int, closing <-chan struct{}) (<-chan *Result, error) {
+	// Authorize user to execute the query.
+	if s.authenticationEnabled {
+		if err := s.Authorize(user, q, database); err != nil {
+			return nil, err
+		}
+	}
+
+	ch := make(chan *Result)
+	go func() {
+		defer close(ch)
+
+		// Execute each statement.
+		var err error
+		for i, stmt := range q.Statements {
+			// Stop executing statements once the query is closed.
+			select {
+			case <-closing:
+				err = ErrNotExecuted
+			default:
+			}
+
+			// Mark remaining statements as not executed after an error.
+			if err != nil {
+				ch <- &Result{StatementID: i, Err: ErrNotExecuted}
+				continue
+			}
+
+			// Set default database and policy on the statement.
+			if err = s.NormalizeStatement(stmt, database); err != nil {
+				ch <- &Result{StatementID: i, Err: err}
+				continue
+			}
+
+			// Stream rows from select statements. Statements which write
+			// into a target only return the number of points written.
+			if stmt, ok := stmt.(*influxql.SelectStatement); ok && stmt.Target == nil {
+				err = s.executeSelectStatementChunked(i, stmt, database, chunkSize, ch, closing)
+				continue
+			}
+
+			res := s.executeStatement(stmt, database, user)
+			res.StatementID = i
+			err = res.Err
+			ch <- res
+		}
+	}()
+
+	return ch, nil
+}
+
+// executeSelectStatementChunked plans and executes a select statement and
+// sends its rows to ch in results of at most chunkSize points. Raw queries
+// are streamed from the executor so only one chunk per series is held in
+// memory. Execution is stopped once closing is closed.
+// Returns the error sent if the statement fails.
+func (s *Server) executeSelectStatementChunked(id int, stmt *influxql.SelectStatement, database string, chunkSize int, ch chan<- *Result, closing <-chan struct{}) error {
+	// Plan statement execution.
+	executors, err := s.planSelectStatement(stmt, database)
+	if err != nil {
+		ch <- &Result{StatementID: id, Err: err}
+		return err
+	}
+	if chunkSize > 0 {
+		for _, e := range executors {
+			e.ChunkSize = chunkSize
+		}
+	} else {
+		chunkSize = int(^uint(0) >> 1)
+	}
+
+	// Stop the execution if the query is closed.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-closing:
+			for _, e := range executors {
+				e.Close()
+			}
+		case <-done:
+		}
+	}()
+
+	// Execute each plan and send rows as they are produced, splitting rows
+	// between results when they would exceed the chunk size. Full results
+	// are held until more rows arrive so that the last result is never empty.
+	var rows []*influxql.Row
+	var n int
+	var full *Result
+	for _, e := range executors {
+		rowCh, err := e.Execute()
+		if err != nil {
+			if full != nil {
+				ch <- full
+			}
+			ch <- &Result{StatementID: id, Err: err}
+			return err
+		}
+
+		for row := range rowCh {
+			if row.Err != nil {
+				if full != nil {
+					ch <- full
+				}
+				ch <- &Result{StatementID: id, Err: row.Err}
+				return row.Err
+			}
+
+			values := row.Values
+			for {
+				if full != nil {
+					ch <- full
+					full = nil
+				}
+
+				// Take as many values as fit into the current result.
+				sz := len(values)
+				if sz > chunkSize-n {
+					sz = chunkSize - n
+				}
+
+				other := *row
+				other.Values = values[:sz]
+				rows = append(rows, &other)
+				values = values[sz:]
+				n += sz
+
+				if n >= chunkSize {
+					full = &Result{StatementID: id, Rows: rows, Partial: true}
+					rows, n = nil, 0
+				}
+
+				if len(values) == 0 {
+					break
+				}
+			}
+		}
+	}
+
+	// Send the statement's final result.
+	if full != nil && rows == nil {
+		full.Partial = false
+		ch <- full
+		return nil
+	} else if full != nil {
+		ch <- full
+	}
+	ch <- &Result{StatementID: id, Rows: rows}
+	return nil
+}
+
+// executeStatement executes a single normalized statement against a database.
+func (s *Server) executeStatement(stmt influxql.Statement, database string, user *User) *Result {
+	switch stmt := stmt.(type) {
+	case *influxql.SelectStatement:
+		return s.executeSelectStatement(stmt, database, user)
+	case *influxql.ExplainStatement:
+		return s.executeExplainStatement(stmt, database, user)
+	case *influxql.CreateDatabaseStatement:
+		return s.executeCreateDatabaseStatement(stmt, user)
+	case *influxql.DropDatabaseStatement:
+		return s.executeDropDatabaseStatement(stmt, user)
+	case *influxql.ShowDatabasesStatement:
+		return s.executeShowDatabasesStatement(stmt, user)
+	case *influxql.CreateUserStatement:
+		return s.executeCreateUserStatement(stmt, user)
+	case *influxql.DropUserStatement:
+		return s.executeDropUserStatement(stmt, user)
+	case *influxql.ShowUsersStatement:
+		return s.executeShowUsersStatement(stmt, user)
+	case *influxql.DropSeriesStatement:
+		return s.executeDropSeriesStatement(stmt, database, user)
+	case *influxql.DeleteStatement:
+		return s.executeDeleteStatement(stmt, database, user)
+	case *influxql.ShowSeriesStatement:
+		return s.executeShowSeriesStatement(stmt, database, user)
+	case *influxql.ShowMeasurementsStatement:
+		return s.executeShowMeasurementsStatement(stmt, database, user)
+	case *influxql.ShowTagKeysStatement:
+		return s.executeShowTagKeysStatement(stmt, database, user)
+	case *influxql.ShowTagValuesStatement:
+		return s.executeShowTagValuesStatement(stmt, database, user)
+	case *influxql.ShowFieldKeysStatement:
+		return s.executeShowFieldKeysStatement(stmt, database, user)
+	case *influxql.GrantStatement:
+		return s.executeGrantStatement(stmt, user)
+	case *influxql.RevokeStatement:
+		return s.executeRevokeStatement(stmt, user)
+	case *influxql.CreateRetentionPolicyStatement:
+		return s.executeCreateRetentionPolicyStatement(stmt, user)
+	case *influxql.AlterRetentionPolicyStatement:
+		return s.executeAlterRetentionPolicyStatement(stmt, user)
+	case *influxql.DropRetentionPolicyStatement:
+		return s.executeDropRetentionPolicyStatement(stmt, user)
+	case *influxql.ShowRetentionPoliciesStatement:
+		return s.executeShowRetentionPoliciesStatement(stmt, user)
+	case *influxql.CreateContinuousQueryStatement:
+		return s.executeCreateContinuousQueryStatement(stmt, user)
+	case *influxql.DropContinuousQueryStatement:
+		return s.executeDropContinuousQueryStatement(stmt, database, user)
+	case *influxql.ShowContinuousQueriesStatement:
+		return s.executeShowContinuousQueriesStatement(stmt, user)
+	default:
+		panic(fmt.Sprintf("unsupported statement type: %T", stmt))
+	}
+}
+
 // executeSelectStatement plans and executes a select statement against a database.
 func (s *Server) executeSelectStatement(stmt *influxql.SelectStatement, database string, user *User) *Result {
 	// Plan statement execution.
-	e, err := s.planSelectStatement(stmt, database)
+	executors, err := s.planSelectStatement(stmt, database)
 	if err != nil {
 		return &Result{Err: err}
 	}
 
-	// Execute plan.
-	ch, err := e.Execute()
+	// Execute each plan and read all rows from its channel.
+	res := &Result{Rows: make([]*influxql.Row, 0)}
+	for _, e := range executors {
+		ch, err := e.Execute()
+		if err != nil {
+			return &Result{Err: err}
+		}
+		for row := range ch {
+			if row.Err != nil {
+				return &Result{Err: row.Err}
+			}
+			res.Rows = append(res.Rows, row)
+		}
+	}
+
+	// If the statement has a target then write the rows into it and
+	// return the number of points written instead of the data.
+	if stmt.Target != nil {
+		for _, row := range res.Rows {
+			if row.Err != nil {
+				return &Result{Err: row.Err}
+			}
+		}
+
+		n, err := s.writeRows(stmt.Target, database, res.Rows)
+		if err != nil {
+			return &Result{Err: err}
+		}
+		return &Result{
+			Rows: []*influxql.Row{{
+				Columns: []string{"written"},
+				Values:  [][]interface{}{{n}},
+			}},
+		}
+	}
+
+	return res
+}
+
+// executeExplainStatement returns the execution plan of a select statement.
+// The statement is only executed if the plan is analyzed.
+func (s *Server) executeExplainStatement(stmt *influxql.ExplainStatement, database string, user *User) *Result {
+	// Plan statement execution.
+	executors, err := s.planSelectStatement(stmt.Statement, database)
 	if err != nil {
 		return &Result{Err: err}
 	}
 
-	// Read all rows from channel.
-	res := &Result{Rows: make([]*influxql.Row, 0)}
-	for row := range ch {
-		res.Rows = append(res.Rows, row)
+	// Execute each plan and discard the rows to measure each stage.
+	res := &Result{}
+	for _, e := range executors {
+		if stmt.Analyze {
+			ch, err := e.Execute()
+			if err != nil {
+				return &Result{Err: err}
+			}
+			for range ch {
+			}
+		}
+		res.Rows = append(res.Rows, e.Explain(stmt.Analyze))
 	}
-
 	return res
 }
 
-// plans a selection statement under lock.
-func (s *Server) planSelectStatement(stmt *influxql.SelectStatement, database string) (*influxql.Executor, error) {
+// plans a selection statement under lock. A statement selecting from a
+// measurement regex is planned once for each matching measurement so that
+// each measurement's series are returned separately.
+func (s *Server) planSelectStatement(stmt *influxql.SelectStatement, database string) ([]*influxql.Executor, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -1854,11 +3073,106 @@ func (s *Server) planSelectStatement(stmt *influxql.SelectStatement, database st
 		return nil, ErrDatabaseNotFound
 	}
 
+	// Expand a regex source into a statement for each measurement.
+	m, ok := stmt.Source.(*influxql.Measurement)
+	if !ok || m.Regex == nil {
+		e, err := s.planSelect(stmt)
+		if err != nil {
+			return nil, err
+		}
+		return []*influxql.Executor{e}, nil
+	}
+
+	names, err := s.matchMeasurements(m.Regex, database)
+	if err != nil {
+		return nil, err
+	}
+
+	var executors []*influxql.Executor
+	var notFound error
+	for _, name := range names {
+		other := stmt.Clone()
+		if other.Source.(*influxql.Measurement).Name, err = s.normalizeMeasurement(influxql.QuoteIdent([]string{name}), database); err != nil {
+			return nil, err
+		}
+		other.Source.(*influxql.Measurement).Regex = nil
+
+		// Skip measurements without the selected fields.
+		e, err := s.planSelect(other)
+		if _, ok := err.(fieldNotFoundError); ok {
+			notFound = err
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		executors = append(executors, e)
+	}
+	if len(executors) == 0 {
+		return nil, notFound
+	}
+	return executors, nil
+}
+
+// planSelect plans a select statement with a single source.
+// The server lock must be held.
+func (s *Server) planSelect(stmt *influxql.SelectStatement) (*influxql.Executor, error) {
+	// Replace wildcards with the fields of the source measurements.
+	if stmt.HasWildcard() {
+		names, err := s.sourceFieldNames(stmt.Source)
+		if err != nil {
+			return nil, err
+		}
+		stmt = stmt.RewriteWildcards(names)
+	}
+
 	// Plan query.
 	p := influxql.NewPlanner(s)
 	return p.Plan(stmt)
 }
 
+// sourceFieldNames returns the sorted names of the fields in a source's
+// measurements. Fields of a join are prefixed by their measurement.
+func (s *Server) sourceFieldNames(src influxql.Source) ([]string, error) {
+	var measurements influxql.Measurements
+	switch src := src.(type) {
+	case *influxql.Measurement:
+		measurements = influxql.Measurements{src}
+	case *influxql.Merge:
+		measurements = src.Measurements
+	case *influxql.Join:
+		measurements = src.Measurements
+	}
+
+	set := make(map[string]struct{})
+	for _, m := range measurements {
+		database, _, name, err := splitIdent(m.Name)
+		if err != nil {
+			return nil, err
+		}
+		mm, err := s.measurement(database, name)
+		if err != nil {
+			return nil, err
+		} else if mm == nil {
+			return nil, ErrMeasurementNotFound
+		}
+
+		for _, f := range mm.Fields {
+			if _, ok := src.(*influxql.Join); ok {
+				set[m.Name+"."+influxql.QuoteIdent([]string{f.Name})] = struct{}{}
+			} else {
+				set[f.Name] = struct{}{}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 func (s *Server) executeCreateDatabaseStatement(q *influxql.CreateDatabaseStatement, user *User) *Result {
 	return &Result{Err: s.CreateDatabase(q.Name)}
 }
@@ -1887,6 +3201,127 @@ func (s *Server) executeDropUserStatement(q *influxql.DropUserStatement, user *U
 	return &Result{Err: s.DeleteUser(q.Name)}
 }
 
+func (s *Server) executeDropSeriesStatement(stmt *influxql.DropSeriesStatement, database string, user *User) *Result {
+	seriesByMeasurement := make(map[string][]uint32)
+
+	// Find the matching series while holding the read lock.
+	err := func() error {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		// Find the database.
+		db := s.databases[database]
+		if db == nil {
+			return ErrDatabaseNotFound
+		}
+
+		// Get the list of measurements we're interested in.
+		measurements, err := measurementsFromSourceOrDB(stmt.Source, db)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range measurements {
+			ids := m.seriesIDs
+			if stmt.Condition != nil {
+				// Get series IDs that match the WHERE clause.
+				filters := map[uint32]influxql.Expr{}
+				var expr influxql.Expr
+				ids, _, expr = m.walkWhereForSeriesIds(stmt.Condition, filters)
+				if expr != nil || len(filters) > 0 {
+					return errors.New("fields not supported in WHERE clause of DROP SERIES")
+				}
+			}
+
+			if len(ids) > 0 {
+				seriesByMeasurement[m.Name] = ids
+			}
+		}
+		return nil
+	}()
+	if err != nil {
+		return &Result{Err: err}
+	} else if len(seriesByMeasurement) == 0 {
+		return &Result{}
+	}
+
+	return &Result{Err: s.DropSeries(database, seriesByMeasurement)}
+}
+
+func (s *Server) executeDeleteStatement(stmt *influxql.DeleteStatement, database string, user *User) *Result {
+	// Only measurements can be deleted from.
+	source, ok := stmt.Source.(*influxql.Measurement)
+	if !ok || source.Regex != nil {
+		return &Result{Err: errors.New("identifiers in FROM clause must be measurement names")}
+	}
+	sourceDatabase, policyName, name, err := splitIdent(source.Name)
+	if err != nil {
+		return &Result{Err: err}
+	}
+
+	// Replace instances of "now()" with the current time.
+	condition := influxql.Reduce(stmt.Condition, &influxql.NowValuer{Now: time.Now().UTC()})
+
+	// Determine the time range to delete.
+	tmin, tmax := influxql.TimeRange(condition)
+	if tmin.IsZero() {
+		tmin = time.Unix(0, 0)
+	}
+	if tmax.IsZero() {
+		tmax = time.Unix(0, math.MaxInt64)
+	}
+
+	// Find the matching series while holding the read lock.
+	var ids seriesIDs
+	if err := func() error {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		db := s.databases[sourceDatabase]
+		if db == nil {
+			return ErrDatabaseNotFound
+		}
+		m := db.measurements[name]
+		if m == nil {
+			return ErrMeasurementNotFound
+		}
+
+		// Points can only be selected by tags and time.
+		influxql.WalkFunc(condition, func(n influxql.Node) {
+			if ref, ok := n.(*influxql.VarRef); ok && m.FieldByName(ref.Val) != nil {
+				err = errors.New("fields not supported in WHERE clause of DELETE")
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		// A condition that doesn't restrict the tags, such as a time range,
+		// matches every series.
+		ids = m.seriesIDs
+		if condition != nil {
+			if a, ok, _ := m.walkWhereForSeriesIds(condition, map[uint32]influxql.Expr{}); ok {
+				ids = a
+			}
+		}
+		return nil
+	}(); err != nil {
+		return &Result{Err: err}
+	}
+
+	// Remove the points and report how many were deleted.
+	n, err := s.DeleteSeriesRange(sourceDatabase, policyName, ids, tmin, tmax)
+	if err != nil {
+		return &Result{Err: err}
+	}
+	return &Result{
+		Rows: []*influxql.Row{{
+			Columns: []string{"deleted"},
+			Values:  [][]interface{}{{n}},
+		}},
+	}
+}
+
 func (s *Server) executeShowSeriesStatement(stmt *influxql.ShowSeriesStatement, database string, user *User) *Result {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -2221,7 +3656,14 @@ func measurementsFromSourceOrDB(stmt influxql.Source, db *database) (Measurement
 	var measurements Measurements
 	if stmt != nil {
 		// TODO: handle multiple measurement sources
-		if m, ok := stmt.(*influxql.Measurement); ok {
+		if m, ok := stmt.(*influxql.Measurement); ok && m.Regex != nil {
+			// Find all measurements matching the regex.
+			for _, measurement := range db.measurements {
+				if m.Regex.Val.MatchString(measurement.Name) {
+					measurements = append(measurements, measurement)
+				}
+			}
+		} else if ok {
 			segments, err := influxql.SplitIdent(m.Name)
 			if err != nil {
 				return nil, err
@@ -2260,6 +3702,7 @@ func (s *Server) executeShowUsersStatement(q *influxql.ShowUsersStatement, user
 func (s *Server) executeCreateRetentionPolicyStatement(q *influxql.CreateRetentionPolicyStatement, user *User) *Result {
 	rp := NewRetentionPolicy(q.Name)
 	rp.Duration = q.Duration
+	rp.ShardGroupDuration = q.ShardGroupDuration
 	rp.ReplicaN = uint32(q.Replication)
 
 	// Create new retention policy.
@@ -2278,8 +3721,12 @@ func (s *Server) executeCreateRetentionPolicyStatement(q *influxql.CreateRetenti
 
 func (s *Server) executeAlterRetentionPolicyStatement(stmt *influxql.AlterRetentionPolicyStatement, user *User) *Result {
 	rpu := &RetentionPolicyUpdate{
-		Duration: stmt.Duration,
-		ReplicaN: func() *uint32 { n := uint32(*stmt.Replication); return &n }(),
+		Duration:           stmt.Duration,
+		ShardGroupDuration: stmt.ShardGroupDuration,
+	}
+	if stmt.Replication != nil {
+		n := uint32(*stmt.Replication)
+		rpu.ReplicaN = &n
 	}
 
 	// Update the retention policy.
@@ -2313,6 +3760,37 @@ func (s *Server) executeShowRetentionPoliciesStatement(q *influxql.ShowRetention
 	return &Result{Rows: []*influxql.Row{row}}
 }
 
+func (s *Server) executeCreateContinuousQueryStatement(q *influxql.CreateContinuousQueryStatement, user *User) *Result {
+	return &Result{Err: s.CreateContinuousQuery(q)}
+}
+
+func (s *Server) executeDropContinuousQueryStatement(q *influxql.DropContinuousQueryStatement, database string, user *User) *Result {
+	return &Result{Err: s.DropContinuousQuery(database, q.Name)}
+}
+
+func (s *Server) executeShowContinuousQueriesStatement(q *influxql.ShowContinuousQueriesStatement, user *User) *Result {
+	var rows []*influxql.Row
+	for _, name := range s.Databases() {
+		// Only list the queries of databases the user can read.
+		if user != nil && !user.Authorize(influxql.ReadPrivilege, name) {
+			continue
+		}
+
+		a, err := s.ContinuousQueries(name)
+		if err != nil {
+			return &Result{Err: err}
+		}
+
+		// Add a row for each database with its queries.
+		row := &influxql.Row{Name: name, Columns: []string{"name", "query"}}
+		for _, cq := range a {
+			row.Values = append(row.Values, []interface{}{cq.Name(), cq.Query})
+		}
+		rows = append(rows, row)
+	}
+	return &Result{Rows: rows}
+}
+
 // MeasurementNames returns a list of all measurements for the specified database.
 func (s *Server) MeasurementNames(database string) []string {
 	s.mu.RLock()
@@ -2358,16 +3836,37 @@ func (s *Server) NormalizeStatement(stmt influxql.Statement, defaultDatabase str
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	// Ensure a regex source of a select statement matches a measurement.
+	// The statement is expanded into each measurement when it's planned.
+	var sel *influxql.SelectStatement
+	switch stmt := stmt.(type) {
+	case *influxql.SelectStatement:
+		sel = stmt
+	case *influxql.ExplainStatement:
+		sel = stmt.Statement
+	}
+	if sel != nil {
+		if m, ok := sel.Source.(*influxql.Measurement); ok && m.Regex != nil {
+			if _, err = s.matchMeasurements(m.Regex, defaultDatabase); err != nil {
+				return err
+			}
+		}
+	}
+
 	// Track prefixes for replacing field names.
 	prefixes := make(map[string]string)
 
-	// Qualify all measurements.
+	// Qualify all measurements. Regex sources are matched against the
+	// measurements of the database the statement is executed against.
 	influxql.WalkFunc(stmt, func(n influxql.Node) {
 		if err != nil {
 			return
 		}
 		switch n := n.(type) {
 		case *influxql.Measurement:
+			if n.Regex != nil {
+				return
+			}
 			name, e := s.normalizeMeasurement(n.Name, defaultDatabase)
 			if e != nil {
 				err = e
@@ -2396,6 +3895,27 @@ func (s *Server) NormalizeStatement(stmt influxql.Statement, defaultDatabase str
 	return
 }
 
+// matchMeasurements returns the sorted names of the measurements in a
+// database that match a regex.
+func (s *Server) matchMeasurements(re *influxql.RegexLiteral, database string) ([]string, error) {
+	db := s.databases[database]
+	if db == nil {
+		return nil, fmt.Errorf("database not found: %s", database)
+	}
+
+	var names []string
+	for name := range db.measurements {
+		if re.Val.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, ErrMeasurementNotFound
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 // NormalizeMeasurement inserts the default database or policy into all measurement names.
 func (s *Server) NormalizeMeasurement(name string, defaultDatabase string) (string, error) {
 	s.mu.RLock()
@@ -2477,6 +3997,10 @@ func (s *Server) processor(client MessagingClient, done chan struct{}) {
 			err = s.applyWriteSeries(m)
 		case writeRawSeriesMessageType:
 			err = s.applyWriteRawSeries(m)
+		case writeRawSeriesBatchMessageType:
+			err = s.applyWriteRawSeriesBatch(m)
+		case deleteSeriesRangeMessageType:
+			err = s.applyDeleteSeriesRange(m)
 		case createDataNodeMessageType:
 			err = s.applyCreateDataNode(m)
 		case deleteDataNodeMessageType:
@@ -2499,10 +4023,22 @@ func (s *Server) processor(client MessagingClient, done chan struct{}) {
 			err = s.applyDeleteRetentionPolicy(m)
 		case createShardGroupIfNotExistsMessageType:
 			err = s.applyCreateShardGroupIfNotExists(m)
+		case deleteShardGroupMessageType:
+			err = s.applyDeleteShardGroup(m)
 		case setDefaultRetentionPolicyMessageType:
 			err = s.applySetDefaultRetentionPolicy(m)
 		case createSeriesIfNotExistsMessageType:
 			err = s.applyCreateSeriesIfNotExists(m)
+		case createFieldsIfNotExistsMessageType:
+			err = s.applyCreateFieldsIfNotExists(m)
+		case dropSeriesMessageType:
+			err = s.applyDropSeries(m)
+		case createContinuousQueryMessageType:
+			err = s.applyCreateContinuousQuery(m)
+		case dropContinuousQueryMessageType:
+			err = s.applyDropContinuousQuery(m)
+		case setContinuousQueryLastRunMessageType:
+			err = s.applySetContinuousQueryLastRun(m)
 		case setPrivilegeMessageType:
 			err = s.applySetPrivilege(m)
 		}
@@ -2521,18 +4057,27 @@ func (s *Server) processor(client MessagingClient, done chan struct{}) {
 type Result struct {
 	Rows []*influxql.Row
 	Err  error
+
+	// The index of the statement within the query and whether more results
+	// follow for the same statement. Only set when results are streamed.
+	StatementID int
+	Partial     bool
 }
 
 // MarshalJSON encodes the result into JSON.
 func (r *Result) MarshalJSON() ([]byte, error) {
 	// Define a struct that outputs "error" as a string.
 	var o struct {
-		Rows []*influxql.Row `json:"rows,omitempty"`
-		Err  string          `json:"error,omitempty"`
+		StatementID int             `json:"statement_id,omitempty"`
+		Rows        []*influxql.Row `json:"rows,omitempty"`
+		Partial     bool            `json:"partial,omitempty"`
+		Err         string          `json:"error,omitempty"`
 	}
 
 	// Copy fields to output struct.
+	o.StatementID = r.StatementID
 	o.Rows = r.Rows
+	o.Partial = r.Partial
 	if r.Err != nil {
 		o.Err = r.Err.Error()
 	}
@@ -2543,15 +4088,19 @@ func (r *Result) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON decodes the data into the Result struct
 func (r *Result) UnmarshalJSON(b []byte) error {
 	var o struct {
-		Rows []*influxql.Row `json:"rows,omitempty"`
-		Err  string          `json:"error,omitempty"`
+		StatementID int             `json:"statement_id,omitempty"`
+		Rows        []*influxql.Row `json:"rows,omitempty"`
+		Partial     bool            `json:"partial,omitempty"`
+		Err         string          `json:"error,omitempty"`
 	}
 
 	err := json.Unmarshal(b, &o)
 	if err != nil {
 		return err
 	}
+	r.StatementID = o.StatementID
 	r.Rows = o.Rows
+	r.Partial = o.Partial
 	if o.Err != "" {
 		r.Err = errors.New(o.Err)
 	}
@@ -2757,9 +4306,69 @@ func HashPassword(password string) ([]byte, error) {
 // ContinuousQuery represents a query that exists on the server and processes
 // each incoming event.
 type ContinuousQuery struct {
-	ID    uint32
-	Query string
-	// TODO: ParsedQuery *parser.SelectQuery
+	Query string `json:"query"`
+
+	cq      *influxql.CreateContinuousQueryStatement
+	lastRun time.Time // end of the last computed interval, guarded by the server lock
+}
+
+// NewContinuousQuery returns a ContinuousQuery parsed from a
+// CREATE CONTINUOUS QUERY statement.
+func NewContinuousQuery(q string) (*ContinuousQuery, error) {
+	stmt, err := influxql.NewParser(strings.NewReader(q)).ParseStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	cq, ok := stmt.(*influxql.CreateContinuousQueryStatement)
+	if !ok {
+		return nil, errors.New("query isn't a continuous query")
+	}
+
+	// Queries are run at their GROUP BY time() interval.
+	if interval, _, err := cq.Source.Dimensions.Normalize(); err != nil {
+		return nil, err
+	} else if interval <= 0 {
+		return nil, ErrContinuousQueryIntervalRequired
+	}
+
+	return &ContinuousQuery{Query: q, cq: cq}, nil
+}
+
+// Name returns the name of the continuous query.
+func (cq *ContinuousQuery) Name() string { return cq.cq.Name }
+
+// continuousQueryJSON represents the JSON-encoded form of a continuous query.
+type continuousQueryJSON struct {
+	Query   string     `json:"query"`
+	LastRun *time.Time `json:"lastRun,omitempty"`
+}
+
+// MarshalJSON encodes a continuous query and the end of its last run.
+func (cq *ContinuousQuery) MarshalJSON() ([]byte, error) {
+	o := continuousQueryJSON{Query: cq.Query}
+	if !cq.lastRun.IsZero() {
+		o.LastRun = &cq.lastRun
+	}
+	return json.Marshal(&o)
+}
+
+// UnmarshalJSON decodes and parses a JSON-encoded continuous query.
+func (cq *ContinuousQuery) UnmarshalJSON(data []byte) error {
+	var o continuousQueryJSON
+	if err := json.Unmarshal(data, &o); err != nil {
+		return err
+	}
+
+	other, err := NewContinuousQuery(o.Query)
+	if err != nil {
+		return err
+	}
+	if o.LastRun != nil {
+		other.lastRun = *o.LastRun
+	}
+	*cq = *other
+	return nil
 }
 
 // copyURL returns a copy of the the URL.
@@ -8,7 +8,10 @@ import (
 	"log"
 	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -238,6 +241,12 @@ func TestServer_SingleStatementQueryAuthorization(t *testing.T) {
 	if err := s.Authorize(user, readWriteQuery, ""); err != nil {
 		t.Fatal(err)
 	}
+
+	// A database in the target measurement requires write privileges on it.
+	user.Privileges["foo"] = influxql.WritePrivilege
+	if err := s.Authorize(user, MustParseQuery(`SELECT value INTO "baz"."raw".cpu FROM cpu`), "foo"); err == nil {
+		t.Fatalf("normal user should not be authorized to write to database baz")
+	}
 }
 
 // Test multiple statement query authorization.
@@ -532,9 +541,10 @@ func TestServer_CreateRetentionPolicy(t *testing.T) {
 
 	// Create a retention policy on the database.
 	rp := &influxdb.RetentionPolicy{
-		Name:     "bar",
-		Duration: time.Hour,
-		ReplicaN: 2,
+		Name:               "bar",
+		Duration:           time.Hour,
+		ShardGroupDuration: time.Hour,
+		ReplicaN:           2,
 	}
 	if err := s.CreateRetentionPolicy("foo", rp); err != nil {
 		t.Fatal(err)
@@ -593,9 +603,10 @@ func TestServer_AlterRetentionPolicy(t *testing.T) {
 
 	// Create a retention policy on the database.
 	rp := &influxdb.RetentionPolicy{
-		Name:     "bar",
-		Duration: time.Hour,
-		ReplicaN: 2,
+		Name:               "bar",
+		Duration:           time.Hour,
+		ShardGroupDuration: time.Hour,
+		ReplicaN:           2,
 	}
 	if err := s.CreateRetentionPolicy("foo", rp); err != nil {
 		t.Fatal(err)
@@ -603,10 +614,12 @@ func TestServer_AlterRetentionPolicy(t *testing.T) {
 
 	// Alter the retention policy.
 	duration := time.Minute
+	sgDuration := 2 * time.Hour
 	replicaN := uint32(3)
 	rp2 := &influxdb.RetentionPolicyUpdate{
-		Duration: &duration,
-		ReplicaN: &replicaN,
+		Duration:           &duration,
+		ShardGroupDuration: &sgDuration,
+		ReplicaN:           &replicaN,
 	}
 	if err := s.UpdateRetentionPolicy("foo", "bar", rp2); err != nil {
 		t.Fatal(err)
@@ -624,6 +637,16 @@ func TestServer_AlterRetentionPolicy(t *testing.T) {
 		t.Fatalf("retention policy mismatch:\n\texp Duration = %s\n\tgot Duration = %s\n", rp2.Duration, o.Duration)
 	} else if o.ReplicaN != *rp2.ReplicaN {
 		t.Fatalf("retention policy mismatch:\n\texp ReplicaN = %d\n\tgot ReplicaN = %d\n", rp2.ReplicaN, o.ReplicaN)
+	} else if o.ShardGroupDuration != *rp2.ShardGroupDuration {
+		t.Fatalf("retention policy mismatch:\n\texp ShardGroupDuration = %s\n\tgot ShardGroupDuration = %s\n", rp2.ShardGroupDuration, o.ShardGroupDuration)
+	}
+
+	// Set a zero shard group duration to use the default.
+	sgDuration = 0
+	if err := s.UpdateRetentionPolicy("foo", "bar", &influxdb.RetentionPolicyUpdate{ShardGroupDuration: &sgDuration}); err != nil {
+		t.Fatal(err)
+	} else if o, _ := s.RetentionPolicy("foo", "bar"); o.ShardGroupDuration != time.Hour {
+		t.Fatalf("unexpected shard group duration: %s", o.ShardGroupDuration)
 	}
 }
 
@@ -688,7 +711,7 @@ func TestServer_SetDefaultRetentionPolicy(t *testing.T) {
 	defer s.Close()
 	s.CreateDatabase("foo")
 
-	rp := &influxdb.RetentionPolicy{Name: "bar"}
+	rp := &influxdb.RetentionPolicy{Name: "bar", ShardGroupDuration: 7 * 24 * time.Hour}
 	if err := s.CreateRetentionPolicy("foo", rp); err != nil {
 		t.Fatal(err)
 	} else if rp, _ := s.RetentionPolicy("foo", "bar"); rp == nil {
@@ -785,6 +808,478 @@ func TestServer_WriteSeries(t *testing.T) {
 	}
 }
 
+// Ensure the server publishes a single message per shard for a batch of points.
+func TestServer_WriteSeries_Batch(t *testing.T) {
+	c := NewMessagingClient()
+	s := OpenDefaultServer(c)
+	defer s.Close()
+
+	// Write a point first so the field exists and later writes are raw.
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(0)}}})
+
+	// Count messages published to shard topics.
+	var n int
+	c.PublishFunc = func(m *messaging.Message) (uint64, error) {
+		if m.TopicID != messaging.BroadcastTopicID {
+			n++
+		}
+		return c.send(m)
+	}
+
+	// Write a batch of points to the same shard.
+	var points []influxdb.Point
+	for i := 1; i <= 100; i++ {
+		points = append(points, influxdb.Point{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z").Add(time.Duration(i) * time.Second), Values: map[string]interface{}{"value": float64(i)}})
+	}
+	s.MustWriteSeries("db", "raw", points)
+	if n != 1 {
+		t.Fatalf("unexpected shard message count: %d", n)
+	}
+
+	// Verify all points were written.
+	results := s.ExecuteQuery(MustParseQuery(`SELECT count(value), sum(value) FROM cpu`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu","columns":["time","count","sum"],"values":[["1970-01-01T00:00:00Z",101,5050]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+}
+
+// Ensure the server publishes a single message per shard for the first points of a measurement.
+func TestServer_WriteSeries_BatchNewFields(t *testing.T) {
+	c := NewMessagingClient()
+	s := OpenDefaultServer(c)
+	defer s.Close()
+
+	// Count messages published to shard topics.
+	var n int
+	c.PublishFunc = func(m *messaging.Message) (uint64, error) {
+		if m.TopicID != messaging.BroadcastTopicID {
+			n++
+		}
+		return c.send(m)
+	}
+
+	// Write a batch of points with new series and fields to the same shard.
+	var points []influxdb.Point
+	for i := 0; i < 100; i++ {
+		points = append(points, influxdb.Point{Name: "cpu", Tags: map[string]string{"host": fmt.Sprintf("server%d", i%3)}, Timestamp: mustParseTime("2000-01-01T00:00:00Z").Add(time.Duration(i) * time.Second), Values: map[string]interface{}{"value": float64(i), "load": int64(i % 2)}})
+	}
+	s.MustWriteSeries("db", "raw", points)
+	if n != 1 {
+		t.Fatalf("unexpected shard message count: %d", n)
+	}
+
+	// Verify all points were written.
+	results := s.ExecuteQuery(MustParseQuery(`SELECT count(value), sum(load) FROM cpu`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu","columns":["time","count","sum"],"values":[["1970-01-01T00:00:00Z",100,50]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+}
+
+// Ensure the server can write and read back string, boolean and integer values.
+func TestServer_WriteSeries_NativeTypes(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+
+	// Write one point to create the fields and another through "raw series".
+	tags := map[string]string{"host": "serverA"}
+	values := map[string]interface{}{"load": float64(1.5), "count": int64(10), "healthy": true, "status": "ok"}
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "host", Tags: tags, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: values}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "host", Tags: tags, Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Values: map[string]interface{}{"load": float64(2), "count": 20, "healthy": false, "status": "down"}}})
+
+	// Verify the original types are returned.
+	if v, err := s.ReadSeries("db", "raw", "host", tags, mustParseTime("2000-01-01T00:00:00Z")); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(v, values) {
+		t.Fatalf("values mismatch: %#v", v)
+	}
+	if v, err := s.ReadSeries("db", "raw", "host", tags, mustParseTime("2000-01-01T00:00:10Z")); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(v, map[string]interface{}{"load": float64(2), "count": int64(20), "healthy": false, "status": "down"}) {
+		t.Fatalf("values mismatch: %#v", v)
+	}
+
+	// Verify the query engine returns the original types.
+	results := s.ExecuteQuery(MustParseQuery(`SELECT status FROM host WHERE status = 'ok'`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"host","columns":["time","status"],"values":[["2000-01-01T00:00:00Z","ok"]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+}
+
+// Ensure the server rejects a write whose value type conflicts with an existing field.
+func TestServer_WriteSeries_ErrFieldTypeConflict(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(100)}}})
+
+	if _, err := s.WriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Values: map[string]interface{}{"value": "foo"}}}); err != influxdb.ErrFieldTypeConflict {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure the server can drop series and their data.
+func TestServer_DropSeries(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(10)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverB"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(20)}}})
+
+	// Drop one of the series.
+	results := s.ExecuteQuery(MustParseQuery(`DROP SERIES FROM cpu WHERE host = 'serverA'`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+
+	// Verify the series is no longer listed or readable, including after a restart.
+	for i := 0; i < 2; i++ {
+		results = s.ExecuteQuery(MustParseQuery(`SHOW SERIES`), "db", nil)
+		if res := results.Results[0]; res.Err != nil {
+			t.Fatalf("unexpected error: %s", res.Err)
+		} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu","columns":["host"],"values":[["serverB"]]}]}` {
+			t.Fatalf("unexpected row(0): %s", s)
+		}
+		if _, err := s.ReadSeries("db", "raw", "cpu", map[string]string{"host": "serverA"}, mustParseTime("2000-01-01T00:00:00Z")); err != influxdb.ErrSeriesNotFound {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		s.Restart()
+	}
+
+	// Verify the dropped data does not reappear when the series is recreated.
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Values: map[string]interface{}{"value": float64(30)}}})
+	results = s.ExecuteQuery(MustParseQuery(`SELECT value FROM cpu WHERE host = 'serverA'`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:10Z",30]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+}
+
+// Ensure the server can delete points from series by tags and time.
+func TestServer_DeleteSeriesRange(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(10)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Values: map[string]interface{}{"value": float64(20)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:20Z"), Values: map[string]interface{}{"value": float64(30)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverB"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(40)}}})
+
+	// Delete the older points for one host.
+	results := s.ExecuteQuery(MustParseQuery(`DELETE FROM cpu WHERE host = 'serverA' AND time < '2000-01-01 00:00:15'`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"columns":["deleted"],"values":[[2]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+
+	// Verify the remaining points.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT value FROM cpu WHERE host = 'serverA'`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:20Z",30]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+	results = s.ExecuteQuery(MustParseQuery(`SELECT value FROM cpu WHERE host = 'serverB'`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00Z",40]]}]}` {
+		t.Fatalf("unexpected row(1): %s", s)
+	}
+
+	// Delete by time range only.
+	results = s.ExecuteQuery(MustParseQuery(`DELETE FROM cpu WHERE time < '2000-01-01 00:00:05'`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"columns":["deleted"],"values":[[1]]}]}` {
+		t.Fatalf("unexpected row(2): %s", s)
+	}
+	results = s.ExecuteQuery(MustParseQuery(`SELECT value FROM cpu WHERE host = 'serverB'`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{}` {
+		t.Fatalf("unexpected row(3): %s", s)
+	}
+
+	// Deleting by field is not allowed.
+	results = s.ExecuteQuery(MustParseQuery(`DELETE FROM cpu WHERE value = 30`), "db", nil)
+	if res := results.Results[0]; res.Err == nil || res.Err.Error() != "fields not supported in WHERE clause of DELETE" {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+}
+
+// Ensure the server can create, list, run and drop continuous queries.
+func TestServer_ContinuousQueries(t *testing.T) {
+	c := NewMessagingClient()
+	s := OpenDefaultServer(c)
+	defer s.Close()
+	s.CreateRetentionPolicy("db", &influxdb.RetentionPolicy{Name: "rollup", Duration: 24 * time.Hour})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-east"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(20)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-east"}, Timestamp: mustParseTime("2000-01-01T00:00:05Z"), Values: map[string]interface{}{"value": float64(10)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-east"}, Timestamp: mustParseTime("2000-01-01T00:00:12Z"), Values: map[string]interface{}{"value": float64(5)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-west"}, Timestamp: mustParseTime("2000-01-01T00:00:02Z"), Values: map[string]interface{}{"value": float64(100)}}})
+
+	// Create a continuous query into another retention policy.
+	results := s.ExecuteQuery(MustParseQuery(`CREATE CONTINUOUS QUERY cpu_sum ON db BEGIN SELECT sum(value) INTO "rollup".cpu_sum FROM cpu GROUP BY time(10s), region END`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+
+	// Verify the query is listed, including after a restart.
+	for i := 0; i < 2; i++ {
+		results = s.ExecuteQuery(MustParseQuery(`SHOW CONTINUOUS QUERIES`), "db", nil)
+		if res := results.Results[0]; res.Err != nil {
+			t.Fatalf("unexpected error: %s", res.Err)
+		} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"db","columns":["name","query"],"values":[["cpu_sum","CREATE CONTINUOUS QUERY cpu_sum ON db BEGIN SELECT sum(value) INTO \"rollup\".cpu_sum FROM cpu GROUP BY time(10s), region END"]]}]}` {
+			t.Fatalf("unexpected row(0): %s", s)
+		}
+		s.Restart()
+	}
+
+	// Run the query over two completed intervals.
+	s.RunContinuousQueries(mustParseTime("2000-01-01T00:00:10Z"))
+	s.RunContinuousQueries(mustParseTime("2000-01-01T00:00:25Z"))
+	if err := s.Sync(c.index); err != nil {
+		t.Fatalf("sync error: %s", err)
+	}
+
+	// Verify the aggregated points were written into the target.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT sum FROM "rollup".cpu_sum WHERE region = 'us-east'`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu_sum","columns":["time","sum"],"values":[["2000-01-01T00:00:00Z",30],["2000-01-01T00:00:10Z",5]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+	results = s.ExecuteQuery(MustParseQuery(`SELECT sum FROM "rollup".cpu_sum WHERE region = 'us-west'`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu_sum","columns":["time","sum"],"values":[["2000-01-01T00:00:00Z",100]]}]}` {
+		t.Fatalf("unexpected row(1): %s", s)
+	}
+
+	// Intervals missed across a restart are computed on the next run.
+	s.Restart()
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-west"}, Timestamp: mustParseTime("2000-01-01T00:00:22Z"), Values: map[string]interface{}{"value": float64(1)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-west"}, Timestamp: mustParseTime("2000-01-01T00:00:31Z"), Values: map[string]interface{}{"value": float64(2)}}})
+	s.RunContinuousQueries(mustParseTime("2000-01-01T00:00:45Z"))
+	if err := s.Sync(c.index); err != nil {
+		t.Fatalf("sync error: %s", err)
+	}
+	results = s.ExecuteQuery(MustParseQuery(`SELECT sum FROM "rollup".cpu_sum WHERE region = 'us-west'`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu_sum","columns":["time","sum"],"values":[["2000-01-01T00:00:00Z",100],["2000-01-01T00:00:20Z",1],["2000-01-01T00:00:30Z",2]]}]}` {
+		t.Fatalf("unexpected row(2): %s", s)
+	}
+
+	// Queries are only listed for databases the user can read.
+	s.CreateUser("susy", "pass", false)
+	results = s.ExecuteQuery(MustParseQuery(`SHOW CONTINUOUS QUERIES`), "db", s.User("susy"))
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{}` {
+		t.Fatalf("unexpected row(3): %s", s)
+	}
+
+	// Drop the query.
+	results = s.ExecuteQuery(MustParseQuery(`DROP CONTINUOUS QUERY cpu_sum`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+	if a, err := s.ContinuousQueries("db"); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 0 {
+		t.Fatalf("unexpected continuous query count: %d", len(a))
+	}
+	results = s.ExecuteQuery(MustParseQuery(`DROP CONTINUOUS QUERY cpu_sum`), "db", nil)
+	if res := results.Results[0]; res.Err != influxdb.ErrContinuousQueryNotFound {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+}
+
+// Ensure the server requires a continuous query to group by time.
+func TestServer_CreateContinuousQuery_ErrContinuousQueryIntervalRequired(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+
+	results := s.ExecuteQuery(MustParseQuery(`CREATE CONTINUOUS QUERY cq ON db BEGIN SELECT sum(value) INTO cpu_sum FROM cpu END`), "db", nil)
+	if res := results.Results[0]; res.Err != influxdb.ErrContinuousQueryIntervalRequired {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+}
+
+// Ensure the server can order and limit the points returned for each series.
+func TestServer_ExecuteQuery_OrderByLimit(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+	for i, host := range []string{"serverA", "serverB"} {
+		for j := 0; j < 3; j++ {
+			s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": host}, Timestamp: mustParseTime("2000-01-01T00:00:00Z").Add(time.Duration(j) * 10 * time.Second), Values: map[string]interface{}{"value": float64(i*10 + j)}}})
+		}
+	}
+
+	// Fetch the latest value for each series.
+	results := s.ExecuteQuery(MustParseQuery(`SELECT value FROM cpu GROUP BY host ORDER BY time DESC LIMIT 1`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu","tags":{"host":"serverA"},"columns":["time","value"],"values":[["2000-01-01T00:00:20Z",2]]},{"name":"cpu","tags":{"host":"serverB"},"columns":["time","value"],"values":[["2000-01-01T00:00:20Z",12]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+
+	// Page through the values of a series.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT value FROM cpu WHERE host = 'serverB' LIMIT 2 OFFSET 1`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:10Z",11],["2000-01-01T00:00:20Z",12]]}]}` {
+		t.Fatalf("unexpected row(1): %s", s)
+	}
+
+	// Limits apply to aggregated values rather than the points read.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT sum(value) FROM cpu WHERE time >= '2000-01-01 00:00:00' AND time < '2000-01-01 00:01:00' GROUP BY time(10s) LIMIT 2`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu","columns":["time","sum"],"values":[["2000-01-01T00:00:00Z",10],["2000-01-01T00:00:10Z",12]]}]}` {
+		t.Fatalf("unexpected row(2): %s", s)
+	}
+}
+
+// Ensure the server can stream query results in chunks.
+func TestServer_ExecuteQueryChunked(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+	for i, host := range []string{"serverA", "serverB"} {
+		for j := 0; j < 3; j++ {
+			s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": host}, Timestamp: mustParseTime("2000-01-01T00:00:00Z").Add(time.Duration(j) * 10 * time.Second), Values: map[string]interface{}{"value": float64(i*10 + j)}}})
+		}
+	}
+
+	// Rows are split into chunks of at most two points. Series are streamed
+	// so full chunks of each series are sent before the remaining points.
+	ch, err := s.ExecuteQueryChunked(MustParseQuery(`SELECT value FROM cpu GROUP BY host; SHOW MEASUREMENTS; SELECT value FROM foo; SHOW MEASUREMENTS`), "db", nil, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var a []string
+	for res := range ch {
+		a = append(a, mustMarshalJSON(res))
+	}
+
+	exp := []string{
+		`{"rows":[{"name":"cpu","tags":{"host":"serverA"},"columns":["time","value"],"values":[["2000-01-01T00:00:00Z",0],["2000-01-01T00:00:10Z",1]]}],"partial":true}`,
+		`{"rows":[{"name":"cpu","tags":{"host":"serverB"},"columns":["time","value"],"values":[["2000-01-01T00:00:00Z",10],["2000-01-01T00:00:10Z",11]]}],"partial":true}`,
+		`{"rows":[{"name":"cpu","tags":{"host":"serverA"},"columns":["time","value"],"values":[["2000-01-01T00:00:20Z",2]]},{"name":"cpu","tags":{"host":"serverB"},"columns":["time","value"],"values":[["2000-01-01T00:00:20Z",12]]}]}`,
+		`{"statement_id":1,"rows":[{"name":"cpu","columns":["host"]}]}`,
+		`{"statement_id":2,"error":"measurement not found"}`,
+		`{"statement_id":3,"error":"not executed"}`,
+	}
+	if !reflect.DeepEqual(a, exp) {
+		t.Fatalf("unexpected results:\n\nexp=%s\n\ngot=%s", strings.Join(exp, "\n"), strings.Join(a, "\n"))
+	}
+
+	// Offset and limit are applied to each streamed series.
+	ch, err = s.ExecuteQueryChunked(MustParseQuery(`SELECT value FROM cpu GROUP BY host LIMIT 1 OFFSET 1`), "db", nil, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a = nil
+	for res := range ch {
+		a = append(a, mustMarshalJSON(res))
+	}
+	exp = []string{
+		`{"rows":[{"name":"cpu","tags":{"host":"serverA"},"columns":["time","value"],"values":[["2000-01-01T00:00:10Z",1]]}],"partial":true}`,
+		`{"rows":[{"name":"cpu","tags":{"host":"serverB"},"columns":["time","value"],"values":[["2000-01-01T00:00:10Z",11]]}]}`,
+	}
+	if !reflect.DeepEqual(a, exp) {
+		t.Fatalf("unexpected limited results:\n\nexp=%s\n\ngot=%s", strings.Join(exp, "\n"), strings.Join(a, "\n"))
+	}
+
+	// Statements are not executed once the query is closed.
+	closing := make(chan struct{})
+	close(closing)
+	ch, err = s.ExecuteQueryChunked(MustParseQuery(`SELECT value FROM cpu; SHOW MEASUREMENTS`), "db", nil, 2, closing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a = nil
+	for res := range ch {
+		a = append(a, mustMarshalJSON(res))
+	}
+	exp = []string{`{"error":"not executed"}`, `{"statement_id":1,"error":"not executed"}`}
+	if !reflect.DeepEqual(a, exp) {
+		t.Fatalf("unexpected closed results:\n\nexp=%s\n\ngot=%s", strings.Join(exp, "\n"), strings.Join(a, "\n"))
+	}
+}
+
+// Ensure the server can compute derivatives across shard group boundaries.
+func TestServer_ExecuteQuery_Derivative(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:59:40Z"), Values: map[string]interface{}{"value": float64(10)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:59:50Z"), Values: map[string]interface{}{"value": float64(30)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T01:00:10Z"), Values: map[string]interface{}{"value": float64(20)}}})
+
+	results := s.ExecuteQuery(MustParseQuery(`SELECT derivative(value, 10s) FROM cpu`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu","columns":["time","derivative"],"values":[["2000-01-01T00:59:50Z",20],["2000-01-01T01:00:10Z",-5]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+
+	results = s.ExecuteQuery(MustParseQuery(`SELECT non_negative_derivative(value, 10s) FROM cpu`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu","columns":["time","non_negative_derivative"],"values":[["2000-01-01T00:59:50Z",20]]}]}` {
+		t.Fatalf("unexpected row(1): %s", s)
+	}
+}
+
+// Ensure the server can write the results of a query into a target measurement.
+func TestServer_ExecuteQuery_SelectInto(t *testing.T) {
+	c := NewMessagingClient()
+	s := OpenDefaultServer(c)
+	defer s.Close()
+	s.CreateRetentionPolicy("db", &influxdb.RetentionPolicy{Name: "rollup", Duration: 24 * time.Hour})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-east"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(20)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-east"}, Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Values: map[string]interface{}{"value": float64(30)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-west"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(100)}}})
+
+	// Write aggregated results into a measurement in the default retention policy.
+	results := s.ExecuteQuery(MustParseQuery(`SELECT sum(value) INTO cpu_10s FROM cpu WHERE time >= '2000-01-01 00:00:00' AND time < '2000-01-01 00:00:20' GROUP BY time(10s), region`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"columns":["written"],"values":[[3]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+
+	// Write raw results into a retention policy qualified measurement.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT value INTO "rollup".cpu_west FROM cpu WHERE region = 'us-west'`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"columns":["written"],"values":[[1]]}]}` {
+		t.Fatalf("unexpected row(1): %s", s)
+	}
+	if err := s.Sync(c.index); err != nil {
+		t.Fatalf("sync error: %s", err)
+	}
+
+	// Verify the written points and their tags.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT sum FROM cpu_10s WHERE region = 'us-east'`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu_10s","columns":["time","sum"],"values":[["2000-01-01T00:00:00Z",20],["2000-01-01T00:00:10Z",30]]}]}` {
+		t.Fatalf("unexpected row(2): %s", s)
+	}
+	results = s.ExecuteQuery(MustParseQuery(`SELECT value FROM "rollup".cpu_west`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu_west","columns":["time","value"],"values":[["2000-01-01T00:00:00Z",100]]}]}` {
+		t.Fatalf("unexpected row(3): %s", s)
+	}
+}
+
 // Ensure the server can execute a query and return the data correctly.
 func TestServer_ExecuteQuery(t *testing.T) {
 	s := OpenServer(NewMessagingClient())
@@ -826,6 +1321,240 @@ func TestServer_ExecuteQuery(t *testing.T) {
 	}
 }
 
+// Ensure the server can select from a merge of measurements.
+func TestServer_ExecuteQuery_Merge(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "servera"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(10)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "servera"}, Timestamp: mustParseTime("2000-01-01T00:00:20Z"), Values: map[string]interface{}{"value": float64(30)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "gpu", Tags: map[string]string{"host": "servera"}, Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Values: map[string]interface{}{"value": float64(20)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "gpu", Tags: map[string]string{"host": "serverb"}, Timestamp: mustParseTime("2000-01-01T00:00:30Z"), Values: map[string]interface{}{"value": float64(40)}}})
+
+	// Raw points are interleaved in time order.
+	results := s.ExecuteQuery(MustParseQuery(`SELECT value FROM merge(cpu, gpu)`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"merge(cpu,gpu)","columns":["time","measurement","value"],"values":[["2000-01-01T00:00:00Z","cpu",10],["2000-01-01T00:00:10Z","gpu",20],["2000-01-01T00:00:20Z","cpu",30],["2000-01-01T00:00:30Z","gpu",40]]}]}` {
+		t.Fatalf("unexpected result: %s", s)
+	}
+
+	// Aggregates are computed across all measurements.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT sum(value) FROM merge(cpu, gpu) WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:00Z' GROUP BY time(20s), host`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"merge(cpu,gpu)","tags":{"host":"servera"},"columns":["time","sum"],"values":[["2000-01-01T00:00:00Z",30],["2000-01-01T00:00:20Z",30]]},{"name":"merge(cpu,gpu)","tags":{"host":"serverb"},"columns":["time","sum"],"values":[["2000-01-01T00:00:20Z",40]]}]}` {
+		t.Fatalf("unexpected result: %s", s)
+	}
+}
+
+// Ensure the server keeps the raw values of a merge whose measurements have points at the same time.
+func TestServer_ExecuteQuery_Merge_SameTimestamp(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "servera"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(10)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "gpu", Tags: map[string]string{"host": "servera"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(20), "load": float64(5)}}})
+
+	// Both values are returned along with their measurement.
+	results := s.ExecuteQuery(MustParseQuery(`SELECT value, load FROM merge(cpu, gpu)`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"merge(cpu,gpu)","columns":["time","measurement","value","load"],"values":[["2000-01-01T00:00:00Z","cpu",10,null],["2000-01-01T00:00:00Z","gpu",20,5]]}]}` {
+		t.Fatalf("unexpected result: %s", s)
+	}
+
+	// Aggregates still combine the values of all measurements.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT count(value) FROM merge(cpu, gpu)`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"merge(cpu,gpu)","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",2]]}]}` {
+		t.Fatalf("unexpected result: %s", s)
+	}
+}
+
+// Ensure the server can select from a join of measurements.
+func TestServer_ExecuteQuery_Join(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(10)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Values: map[string]interface{}{"value": float64(20)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "mem", Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Values: map[string]interface{}{"value": float64(100)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "mem", Timestamp: mustParseTime("2000-01-01T00:00:20Z"), Values: map[string]interface{}{"value": float64(200)}}})
+
+	// Fields are aligned on timestamp and prefixed by their measurement.
+	results := s.ExecuteQuery(MustParseQuery(`SELECT value FROM join(cpu, mem)`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"join(cpu,mem)","columns":["time","cpu.value","mem.value"],"values":[["2000-01-01T00:00:00Z",10,null],["2000-01-01T00:00:10Z",20,100],["2000-01-01T00:00:20Z",null,200]]}]}` {
+		t.Fatalf("unexpected result: %s", s)
+	}
+
+	// Prefixed fields and aggregates can be selected from each measurement.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT mean(cpu.value), max(mem.value) FROM join(cpu, mem) WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:30Z' GROUP BY time(20s)`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"join(cpu,mem)","columns":["time","cpu.mean","mem.max"],"values":[["2000-01-01T00:00:00Z",15,100],["2000-01-01T00:00:20Z",null,200]]}]}` {
+		t.Fatalf("unexpected result: %s", s)
+	}
+}
+
+// Ensure the server can explain the execution plan of a query.
+func TestServer_ExecuteQuery_Explain(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "servera"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(10)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "servera"}, Timestamp: mustParseTime("2000-01-01T00:00:40Z"), Values: map[string]interface{}{"value": float64(30)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverb"}, Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Values: map[string]interface{}{"value": float64(20)}}})
+
+	// The plan describes the query without executing it.
+	results := s.ExecuteQuery(MustParseQuery(`EXPLAIN SELECT mean(value) FROM cpu WHERE time >= '2000-01-01 00:00:00' AND time < '2000-01-01 00:01:00' GROUP BY time(30s), host`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"columns":["plan"],"values":[`+
+		`["statement: SELECT mean(value) FROM \"db\".\"raw\".\"cpu\" WHERE time \u003e= \"2000-01-01 00:00:00\" AND time \u003c \"2000-01-01 00:01:00\" GROUP BY time(30s), host"],`+
+		`["source: \"db\".\"raw\".\"cpu\""],`+
+		`["time range: 2000-01-01T00:00:00Z to 2000-01-01T00:00:59.999999Z"],`+
+		`["interval: 30s"],`+
+		`["tag sets: host=servera, host=serverb"],`+
+		`["shards: 1"],`+
+		`["field: mean(value)"],`+
+		`["  reduce: ReduceMean"],`+
+		`["    map: MapMean (tags=host=servera, shard=1, series=1)"],`+
+		`["    map: MapMean (tags=host=serverb, shard=1, series=1)"]]}]}` {
+		t.Fatalf("unexpected result: %s", s)
+	}
+
+	// An analyzed plan reports the points scanned and the time spent by each stage.
+	results = s.ExecuteQuery(MustParseQuery(`EXPLAIN ANALYZE SELECT sum(value) FROM cpu WHERE host = 'servera'`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if len(res.Rows) != 1 {
+		t.Fatalf("unexpected row count: %d", len(res.Rows))
+	} else {
+		var lines []string
+		for _, values := range res.Rows[0].Values {
+			lines = append(lines, values[0].(string))
+		}
+		if s := strings.Join(lines, "\n"); !regexp.MustCompile(`(?s)\nplanning: elapsed=\S+\nexecution: rows=1, elapsed=\S+\n.*\n  reduce: ReduceSum \(elapsed=\S+\)\n    map: MapSum \(tags=\*, shard=1, series=1, points=2, elapsed=\S+\)$`).MatchString(s) {
+			t.Fatalf("unexpected plan:\n%s", s)
+		}
+	}
+}
+
+// Ensure the server can select multiple fields and wildcards.
+func TestServer_ExecuteQuery_MultipleFields(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "servera"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(10), "load": float64(1)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "servera"}, Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Values: map[string]interface{}{"value": float64(20)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverb"}, Timestamp: mustParseTime("2000-01-01T00:00:20Z"), Values: map[string]interface{}{"value": float64(30), "load": float64(3)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "mem", Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"free": float64(100)}}})
+
+	var tests = []struct {
+		query string
+		exp   string
+	}{
+		{
+			query: `SELECT * FROM cpu`,
+			exp:   `{"rows":[{"name":"cpu","columns":["time","load","value"],"values":[["2000-01-01T00:00:00Z",1,10],["2000-01-01T00:00:10Z",null,20],["2000-01-01T00:00:20Z",3,30]]}]}`,
+		},
+		{
+			query: `SELECT value, load AS l FROM cpu GROUP BY host`,
+			exp:   `{"rows":[{"name":"cpu","tags":{"host":"servera"},"columns":["time","value","l"],"values":[["2000-01-01T00:00:00Z",10,1],["2000-01-01T00:00:10Z",20,null]]},{"name":"cpu","tags":{"host":"serverb"},"columns":["time","value","l"],"values":[["2000-01-01T00:00:20Z",30,3]]}]}`,
+		},
+		{
+			query: `SELECT value, load FROM cpu WHERE load > 2`,
+			exp:   `{"rows":[{"name":"cpu","columns":["time","value","load"],"values":[["2000-01-01T00:00:20Z",30,3]]}]}`,
+		},
+		{
+			query: `SELECT sum(value), mean(load) FROM cpu WHERE time >= '2000-01-01 00:00:00' AND time < '2000-01-01 00:00:30' GROUP BY time(20s)`,
+			exp:   `{"rows":[{"name":"cpu","columns":["time","sum","mean"],"values":[["2000-01-01T00:00:00Z",30,1],["2000-01-01T00:00:20Z",30,3]]}]}`,
+		},
+		{
+			query: `SELECT * FROM join(cpu, mem)`,
+			exp:   `{"rows":[{"name":"join(cpu,mem)","columns":["time","cpu.load","cpu.value","mem.free"],"values":[["2000-01-01T00:00:00Z",1,10,100],["2000-01-01T00:00:10Z",null,20,null],["2000-01-01T00:00:20Z",3,30,null]]}]}`,
+		},
+	}
+
+	for i, tt := range tests {
+		results := s.ExecuteQuery(MustParseQuery(tt.query), "db", nil)
+		if s := mustMarshalJSON(results.Results[0]); s != tt.exp {
+			t.Errorf("%d. %s: unexpected result:\n\nexp=%s\n\ngot=%s", i, tt.query, tt.exp, s)
+		}
+	}
+}
+
+// Ensure the server can filter and select with regular expressions.
+func TestServer_ExecuteQuery_Regex(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "servera", "region": "us-east"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(10), "status": "ok"}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverb", "region": "us-west"}, Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Values: map[string]interface{}{"value": float64(20), "status": "failed"}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu_idle", Tags: map[string]string{"host": "servera"}, Timestamp: mustParseTime("2000-01-01T00:00:20Z"), Values: map[string]interface{}{"value": float64(30)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "mem", Tags: map[string]string{"host": "servera"}, Timestamp: mustParseTime("2000-01-01T00:00:30Z"), Values: map[string]interface{}{"value": float64(40)}}})
+
+	var tests = []struct {
+		query string
+		exp   string
+	}{
+		// Tag predicates.
+		{
+			query: `SELECT value FROM cpu WHERE host =~ /a$/`,
+			exp:   `{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00Z",10]]}]}`,
+		},
+		{
+			query: `SELECT value FROM cpu WHERE host !~ /a$/`,
+			exp:   `{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:10Z",20]]}]}`,
+		},
+		{
+			query: `SELECT value FROM cpu WHERE host =~ /server/ AND region =~ /west/`,
+			exp:   `{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:10Z",20]]}]}`,
+		},
+		{
+			query: `SELECT value FROM cpu WHERE dc !~ /x/`,
+			exp:   `{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00Z",10],["2000-01-01T00:00:10Z",20]]}]}`,
+		},
+
+		// Field predicates.
+		{
+			query: `SELECT status FROM cpu WHERE status =~ /^fail/`,
+			exp:   `{"rows":[{"name":"cpu","columns":["time","status"],"values":[["2000-01-01T00:00:10Z","failed"]]}]}`,
+		},
+		{
+			query: `SELECT status FROM cpu WHERE status !~ /^fail/`,
+			exp:   `{"rows":[{"name":"cpu","columns":["time","status"],"values":[["2000-01-01T00:00:00Z","ok"]]}]}`,
+		},
+
+		// Measurement regexes select from all matching measurements.
+		{
+			query: `SELECT value FROM /^cpu/ WHERE host = 'servera'`,
+			exp:   `{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00Z",10]]},{"name":"cpu_idle","columns":["time","value"],"values":[["2000-01-01T00:00:20Z",30]]}]}`,
+		},
+		{
+			query: `SELECT value FROM /mem/`,
+			exp:   `{"rows":[{"name":"mem","columns":["time","value"],"values":[["2000-01-01T00:00:30Z",40]]}]}`,
+		},
+		{
+			query: `SELECT value FROM /disk/`,
+			exp:   `{"error":"measurement not found"}`,
+		},
+		{
+			query: `SHOW TAG KEYS FROM /^cpu/`,
+			exp:   `{"rows":[{"name":"cpu","columns":["tagKey"],"values":[["host"],["region"]]},{"name":"cpu_idle","columns":["tagKey"],"values":[["host"]]}]}`,
+		},
+		{
+			query: `SHOW MEASUREMENTS WHERE region =~ /east/`,
+			exp:   `{"rows":[{"name":"cpu","columns":["host","region"]}]}`,
+		},
+	}
+
+	for i, tt := range tests {
+		results := s.ExecuteQuery(MustParseQuery(tt.query), "db", nil)
+		if s := mustMarshalJSON(results.Results[0]); s != tt.exp {
+			t.Errorf("%d. %s: unexpected result:\n\nexp=%s\n\ngot=%s", i, tt.query, tt.exp, s)
+		}
+	}
+}
+
 func TestServer_CreateShardGroupIfNotExist(t *testing.T) {
 	s := OpenServer(NewMessagingClient())
 	defer s.Close()
@@ -846,6 +1575,132 @@ func TestServer_CreateShardGroupIfNotExist(t *testing.T) {
 	}
 }
 
+// Ensure the server can delete a shard group and its local shard stores.
+func TestServer_DeleteShardGroup(t *testing.T) {
+	c := NewMessagingClient()
+	s := OpenServer(c)
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "bar", Duration: time.Hour})
+
+	if err := s.CreateShardGroupIfNotExists("foo", "bar", mustParseTime("2000-01-01T00:00:00Z")); err != nil {
+		t.Fatal(err)
+	}
+	a, err := s.ShardGroups("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, sh := a[0], a[0].Shards[0]
+	path := filepath.Join(s.Path(), "shards", strconv.FormatUint(sh.ID, 10))
+
+	// Track unsubscriptions.
+	var unsubscribed uint64
+	c.UnsubscribeFunc = func(replicaID, topicID uint64) error {
+		unsubscribed = topicID
+		return nil
+	}
+
+	// Delete the group.
+	if err := s.DeleteShardGroup("foo", "bar", g.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify the group, shard and shard store are gone.
+	if a, err := s.ShardGroups("foo"); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 0 {
+		t.Fatalf("expected 0 shard groups but found %d", len(a))
+	} else if s.Shard(sh.ID) != nil {
+		t.Fatal("expected shard to be removed")
+	} else if unsubscribed != sh.ID {
+		t.Fatalf("unexpected unsubscribe: %d", unsubscribed)
+	} else if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected shard store to be removed: %s", err)
+	}
+
+	// Restart the server and verify the deletion was persisted.
+	s.Restart()
+	if a, err := s.ShardGroups("foo"); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 0 {
+		t.Fatalf("expected 0 shard groups after restart but found %d", len(a))
+	}
+}
+
+// Ensure the server returns an error when deleting a non-existent shard group.
+func TestServer_DeleteShardGroup_ErrShardGroupNotFound(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+	if err := s.DeleteShardGroup("db", "raw", 100); err != influxdb.ErrShardGroupNotFound {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure the server deletes shard groups that have aged out of their retention policy.
+func TestServer_EnforceRetentionPolicies(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "bar", Duration: time.Hour})
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "inf"})
+
+	// Write points that have expired and one that is still within the policy.
+	now := time.Now().UTC()
+	s.MustWriteSeries("foo", "bar", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(100)}}})
+	s.MustWriteSeries("foo", "bar", []influxdb.Point{{Name: "cpu", Timestamp: now, Values: map[string]interface{}{"value": float64(100)}}})
+	s.MustWriteSeries("foo", "inf", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(100)}}})
+
+	s.EnforceRetentionPolicies()
+
+	// Verify only the expired group was removed.
+	if v, err := s.ReadSeries("foo", "bar", "cpu", nil, mustParseTime("2000-01-01T00:00:00Z")); err != nil {
+		t.Fatal(err)
+	} else if v != nil {
+		t.Fatalf("expected expired point to be removed: %#v", v)
+	}
+	if v, err := s.ReadSeries("foo", "bar", "cpu", nil, now); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(v, map[string]interface{}{"value": float64(100)}) {
+		t.Fatalf("values mismatch: %#v", v)
+	}
+	if v, err := s.ReadSeries("foo", "inf", "cpu", nil, mustParseTime("2000-01-01T00:00:00Z")); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(v, map[string]interface{}{"value": float64(100)}) {
+		t.Fatalf("values mismatch: %#v", v)
+	}
+}
+
+// Ensure the server creates shard groups using the policy's shard group duration.
+func TestServer_CreateShardGroupIfNotExist_ShardGroupDuration(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "bar", Duration: 365 * 24 * time.Hour})
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "baz", Duration: 365 * 24 * time.Hour, ShardGroupDuration: time.Hour})
+
+	// Verify the default shard group duration was derived from the retention duration.
+	if rp, _ := s.RetentionPolicy("foo", "bar"); rp.ShardGroupDuration != 7*24*time.Hour {
+		t.Fatalf("unexpected shard group duration: %s", rp.ShardGroupDuration)
+	}
+
+	// Verify the shard group widths.
+	if err := s.CreateShardGroupIfNotExists("foo", "bar", mustParseTime("2000-01-05T00:00:00Z")); err != nil {
+		t.Fatal(err)
+	} else if err := s.CreateShardGroupIfNotExists("foo", "baz", mustParseTime("2000-01-05T00:30:00Z")); err != nil {
+		t.Fatal(err)
+	}
+	a, err := s.ShardGroups("foo")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(a) != 2 {
+		t.Fatalf("expected 2 shard groups but found %d", len(a))
+	}
+	durations := map[time.Duration]bool{a[0].Duration(): true, a[1].Duration(): true}
+	if !durations[7*24*time.Hour] || !durations[time.Hour] {
+		t.Fatalf("unexpected shard group durations: %v", durations)
+	}
+}
+
 /* TODO(benbjohnson): Change test to not expose underlying series ids directly.
 func TestServer_Measurements(t *testing.T) {
 	s := OpenServer(NewMessagingClient())
@@ -129,8 +129,65 @@ func (s *Shard) writeSeries(seriesID uint32, timestamp int64, values []byte, ove
 	})
 }
 
-func (s *Shard) deleteSeries(name string) error {
-	panic("not yet implemented") // TODO
+// writeSeriesBatch writes a list of encoded points to a shard in a single transaction.
+// Each point is a point header followed by its encoded values.
+func (s *Shard) writeSeriesBatch(points [][]byte) error {
+	return s.store.Update(func(tx *bolt.Tx) error {
+		for _, p := range points {
+			seriesID, timestamp := unmarshalPointHeader(p[:pointHeaderSize])
+
+			// Create a bucket for the series.
+			b, err := tx.CreateBucketIfNotExists(u32tob(seriesID))
+			if err != nil {
+				return err
+			}
+
+			// Insert the values by timestamp.
+			if err := b.Put(u64tob(uint64(timestamp)), p[pointHeaderSize:]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// deleteSeries removes all data for a series from the shard.
+func (s *Shard) deleteSeries(seriesID uint32) error {
+	return s.store.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(u32tob(seriesID)); err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+		return nil
+	})
+}
+
+// deleteSeriesRange removes points for a set of series between tmin and tmax,
+// inclusive. Returns the number of points removed.
+func (s *Shard) deleteSeriesRange(seriesIDs []uint32, tmin, tmax int64) (n int, err error) {
+	err = s.store.Update(func(tx *bolt.Tx) error {
+		for _, id := range seriesIDs {
+			b := tx.Bucket(u32tob(id))
+			if b == nil {
+				continue
+			}
+
+			// Collect keys first since deleting moves the cursor.
+			var timestamps []uint64
+			c := b.Cursor()
+			for k, _ := c.Seek(u64tob(uint64(tmin))); k != nil && int64(btou64(k)) <= tmax; k, _ = c.Next() {
+				timestamps = append(timestamps, btou64(k))
+			}
+
+			for _, timestamp := range timestamps {
+				if err := b.Delete(u64tob(timestamp)); err != nil {
+					return err
+				}
+			}
+			n += len(timestamps)
+		}
+		return nil
+	})
+	return
 }
 
 // Shards represents a list of shards.
@@ -154,7 +211,53 @@ func unmarshalPointHeader(b []byte) (seriesID uint32, timestamp int64) {
 	return
 }
 
+// marshalPointBatch encodes a list of encoded points into a single byte slice.
+// Each point is prefixed with its size so the batch can be split again.
+func marshalPointBatch(points [][]byte) []byte {
+	var n int
+	for _, p := range points {
+		n += 4 + len(p)
+	}
+
+	b := make([]byte, 0, n)
+	for _, p := range points {
+		var size [4]byte
+		binary.BigEndian.PutUint32(size[:], uint32(len(p)))
+		b = append(b, size[:]...)
+		b = append(b, p...)
+	}
+	return b
+}
+
+// unmarshalPointBatch decodes a byte slice into a list of encoded points.
+func unmarshalPointBatch(b []byte) (points [][]byte) {
+	for len(b) > 0 {
+		n := binary.BigEndian.Uint32(b[0:4])
+		points = append(points, b[4:4+n])
+		b = b[4+n:]
+	}
+	return
+}
+
+// Field value type tags used in the encoded value format.
+const (
+	fieldTypeFloat   = byte(1)
+	fieldTypeInteger = byte(2)
+	fieldTypeBoolean = byte(3)
+	fieldTypeString  = byte(4)
+)
+
+// maxStringValueSize is the largest string value that can be encoded.
+const maxStringValueSize = math.MaxUint16
+
+// valuesFormatTyped is the version of the typed value encoding. Encoded values
+// start with a zero byte followed by the version so they can be told apart
+// from untyped values written by earlier versions, which start with a field
+// count and hold a float64 for each field.
+const valuesFormatTyped = byte(1)
+
 // marshalValues encodes a set of field ids and values to a byte slice.
+// Each value is written as its field id, a type tag and the encoded value.
 func marshalValues(values map[uint8]interface{}) []byte {
 	// Sort fields for consistency.
 	fieldIDs := make([]uint8, 0, len(values))
@@ -163,30 +266,48 @@ func marshalValues(values map[uint8]interface{}) []byte {
 	}
 	sort.Sort(uint8Slice(fieldIDs))
 
-	// Allocate byte slice and write field count.
-	b := make([]byte, 1, 10)
-	b[0] = byte(len(values))
+	// Allocate byte slice and write format version and field count.
+	b := make([]byte, 3, 12)
+	b[1] = valuesFormatTyped
+	b[2] = byte(len(values))
 
 	// Write out each field.
 	for _, fieldID := range fieldIDs {
-		// Create a temporary buffer for this field.
-		buf := make([]byte, 9)
-		buf[0] = fieldID
-
-		// Convert integers to floats.
+		// Convert integers to int64.
 		v := values[fieldID]
 		if intval, ok := v.(int); ok {
-			v = float64(intval)
+			v = int64(intval)
 		}
 
-		// Encode value after field id.
-		// TODO: Support non-float types.
+		// Encode value after field id and type tag.
+		var buf []byte
 		switch v := v.(type) {
 		case float64:
-			binary.BigEndian.PutUint64(buf[1:9], math.Float64bits(v))
+			buf = make([]byte, 10)
+			buf[1] = fieldTypeFloat
+			binary.BigEndian.PutUint64(buf[2:10], math.Float64bits(v))
+		case int64:
+			buf = make([]byte, 10)
+			buf[1] = fieldTypeInteger
+			binary.BigEndian.PutUint64(buf[2:10], uint64(v))
+		case bool:
+			buf = make([]byte, 3)
+			buf[1] = fieldTypeBoolean
+			if v {
+				buf[2] = 1
+			}
+		case string:
+			if len(v) > maxStringValueSize {
+				panic(fmt.Sprintf("string value too large: %d bytes", len(v)))
+			}
+			buf = make([]byte, 4, 4+len(v))
+			buf[1] = fieldTypeString
+			binary.BigEndian.PutUint16(buf[2:4], uint16(len(v)))
+			buf = append(buf, v...)
 		default:
 			panic(fmt.Sprintf("unsupported value type: %T", v))
 		}
+		buf[0] = fieldID
 
 		// Append temp buffer to the end.
 		b = append(b, buf...)
@@ -201,28 +322,59 @@ func unmarshalValues(b []byte) map[uint8]interface{} {
 		return nil
 	}
 
-	// Read the field count from the field byte.
-	n := int(b[0])
+	// Values written before the typed encoding start with a non-zero field
+	// count, or are a single zero byte if there are no fields.
+	if b[0] != 0 || len(b) == 1 {
+		return unmarshalUntypedValues(b)
+	} else if b[1] != valuesFormatTyped {
+		panic(fmt.Sprintf("unsupported value format: %d", b[1]))
+	}
+
+	// Read the field count after the format version.
+	n := int(b[2])
 
 	// Create a map to hold the decoded data.
 	values := make(map[uint8]interface{}, n)
 
-	// Start from the second byte and iterate over until we're done decoding.
-	b = b[1:]
+	// Start after the header and iterate over until we're done decoding.
+	b = b[3:]
 	for i := 0; i < n; i++ {
-		// First byte is the field identifier.
-		fieldID := b[0]
-
-		// Decode value.
-		// TODO: Support non-float types.
-		value := math.Float64frombits(binary.BigEndian.Uint64(b[1:9]))
+		// First byte is the field identifier, second byte is the type.
+		fieldID, typ := b[0], b[1]
+
+		// Decode value and move bytes forward.
+		switch typ {
+		case fieldTypeFloat:
+			values[fieldID] = math.Float64frombits(binary.BigEndian.Uint64(b[2:10]))
+			b = b[10:]
+		case fieldTypeInteger:
+			values[fieldID] = int64(binary.BigEndian.Uint64(b[2:10]))
+			b = b[10:]
+		case fieldTypeBoolean:
+			values[fieldID] = b[2] == 1
+			b = b[3:]
+		case fieldTypeString:
+			sz := int(binary.BigEndian.Uint16(b[2:4]))
+			values[fieldID] = string(b[4 : 4+sz])
+			b = b[4+sz:]
+		default:
+			panic(fmt.Sprintf("unsupported value type tag: %d", typ))
+		}
+	}
 
-		values[fieldID] = value
+	return values
+}
 
-		// Move bytes forward.
+// unmarshalUntypedValues decodes values written before the typed encoding.
+// Each value is written as its field id followed by a float64.
+func unmarshalUntypedValues(b []byte) map[uint8]interface{} {
+	n := int(b[0])
+	values := make(map[uint8]interface{}, n)
+	b = b[1:]
+	for i := 0; i < n; i++ {
+		values[b[0]] = math.Float64frombits(binary.BigEndian.Uint64(b[1:9]))
 		b = b[9:]
 	}
-
 	return values
 }
 
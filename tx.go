@@ -17,7 +17,7 @@ type tx struct {
 	opened bool
 	now    time.Time
 
-	itrs []*shardIterator // shard iterators
+	readers map[string]*shardReader // shard readers by shard, tagset and condition
 }
 
 // newTx return a new initialized Tx.
@@ -39,9 +39,9 @@ func (tx *tx) Open() error {
 	// Mark transaction as open.
 	tx.opened = true
 
-	// Open each iterator individually. If any fail close the transaction and error out
-	for _, itr := range tx.itrs {
-		if err := itr.open(); err != nil {
+	// Open each reader individually. If any fail close the transaction and error out
+	for _, r := range tx.readers {
+		if err := r.open(); err != nil {
 			_ = tx.close()
 			return err
 		}
@@ -61,15 +61,47 @@ func (tx *tx) close() error {
 	// Mark transaction as closed.
 	tx.opened = false
 
-	for _, itr := range tx.itrs {
-		_ = itr.close()
+	for _, r := range tx.readers {
+		_ = r.close()
 	}
 
 	return nil
 }
 
 // CreateIterators returns an iterator for a simple select statement.
+// Iterators for a merge are created for each of its measurements.
 func (tx *tx) CreateIterators(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
+	switch source := stmt.Source.(type) {
+	case *influxql.Measurement:
+		return tx.createMeasurementIterators(stmt.StripSourcePrefix())
+	case *influxql.Merge:
+		var itrs []influxql.Iterator
+		var found bool
+		for _, m := range source.Measurements {
+			other := stmt.Clone()
+			other.Source = m
+			a, err := tx.createMeasurementIterators(other.StripSourcePrefix())
+			if _, ok := err.(fieldNotFoundError); ok {
+				// The field only needs to exist in one measurement of the merge.
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			itrs = append(itrs, a...)
+			found = true
+		}
+		if !found {
+			return nil, fieldNotFoundError(stmt.Fields[0].Expr.(*influxql.VarRef).Val)
+		}
+		return itrs, nil
+	default:
+		return nil, fmt.Errorf("unsupported source: %s", stmt.Source)
+	}
+}
+
+// createMeasurementIterators returns an iterator for a simple select statement
+// with a single measurement source.
+func (tx *tx) createMeasurementIterators(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
 	// Parse the source segments.
 	database, policyName, measurement, err := splitIdent(stmt.Source.(*influxql.Measurement).Name)
 	if err != nil {
@@ -98,7 +130,7 @@ func (tx *tx) CreateIterators(stmt *influxql.SelectStatement) ([]influxql.Iterat
 	// Find shard groups within time range.
 	var shardGroups []*ShardGroup
 	for _, group := range rp.shardGroups {
-		if timeBetweenInclusive(group.StartTime, tmin, tmax) || timeBetweenInclusive(group.EndTime, tmin, tmax) {
+		if !group.StartTime.After(tmax) && !group.EndTime.Before(tmin) {
 			shardGroups = append(shardGroups, group)
 		}
 	}
@@ -125,38 +157,52 @@ func (tx *tx) CreateIterators(stmt *influxql.SelectStatement) ([]influxql.Iterat
 	fieldName := stmt.Fields[0].Expr.(*influxql.VarRef).Val
 	f := m.FieldByName(fieldName)
 	if f == nil {
-		return nil, fmt.Errorf("field not found: %s", fieldName)
+		return nil, fieldNotFoundError(fieldName)
 	}
 	tagSets := m.tagSets(stmt, dimensions)
 
-	// Create an iterator for every shard.
+	// Each iterator only needs to read enough points to fill a limited result
+	// if points are returned in the same order they're read.
+	var limit int
+	if stmt.Limit > 0 && stmt.TimeAscending() {
+		limit = stmt.Offset + stmt.Limit
+	}
+
+	// Create an iterator for every shard. Iterators for different fields of
+	// the same tagset and shard share a reader so points are read only once.
 	var itrs []influxql.Iterator
 	for tag, set := range tagSets {
 		for _, group := range shardGroups {
 			// TODO: only create iterators for the shards we actually have to hit in a group
 			for _, sh := range group.Shards {
-
-				// create a series cursor for each unique series id
-				cursors := make([]*seriesCursor, 0, len(set))
-				for id, cond := range set {
-					cursors = append(cursors, &seriesCursor{id: id, condition: cond})
+				key := fmt.Sprintf("%d\x00%s\x00%s\x00%s\x00%s", sh.ID, measurement, tag, stmt.Condition, stmt.Dimensions)
+				r := tx.readers[key]
+				if r == nil {
+					// create a series cursor for each unique series id
+					cursors := make([]*seriesCursor, 0, len(set))
+					for id, cond := range set {
+						cursors = append(cursors, &seriesCursor{id: id, condition: cond})
+					}
+
+					// create the shard reader that will map over all series for the shard
+					r = &shardReader{
+						shardID:     sh.ID,
+						measurement: measurement,
+						fields:      m.Fields,
+						db:          sh.store,
+						cursors:     cursors,
+						tmin:        tmin.UnixNano(),
+						tmax:        tmax.UnixNano(),
+					}
+
+					// Add to tx so the bolt transaction can be opened/closed.
+					if tx.readers == nil {
+						tx.readers = make(map[string]*shardReader)
+					}
+					tx.readers[key] = r
 				}
 
-				// create the shard iterator that will map over all series for the shard
-				itr := &shardIterator{
-					fieldName: f.Name,
-					fieldID:   f.ID,
-					tags:      tag,
-					db:        sh.store,
-					cursors:   cursors,
-					tmin:      tmin.UnixNano(),
-					tmax:      tmax.UnixNano(),
-				}
-
-				// Add to tx so the bolt transaction can be opened/closed.
-				tx.itrs = append(tx.itrs, itr)
-
-				itrs = append(itrs, itr)
+				itrs = append(itrs, r.iterator(f.ID, tag, limit))
 			}
 		}
 	}
@@ -164,6 +210,11 @@ func (tx *tx) CreateIterators(stmt *influxql.SelectStatement) ([]influxql.Iterat
 	return itrs, nil
 }
 
+// fieldNotFoundError is returned when a selected field doesn't exist.
+type fieldNotFoundError string
+
+func (e fieldNotFoundError) Error() string { return "field not found: " + string(e) }
+
 // splitIdent splits an identifier into it's database, policy, and measurement parts.
 func splitIdent(s string) (db, rp, m string, err error) {
 	a, err := influxql.SplitIdent(s)
@@ -175,29 +226,40 @@ func splitIdent(s string) (db, rp, m string, err error) {
 	return a[0], a[1], a[2], nil
 }
 
-// shardIterator represents an iterator for traversing over a single series.
-type shardIterator struct {
-	fieldName  string
-	fieldID    uint8
-	tags       string // encoded dimensional tag values
-	cursors    []*seriesCursor
-	keyValues  []keyValue
-	db         *bolt.DB // data stores by shard id
-	txn        *bolt.Tx // read transactions by shard id
-	tmin, tmax int64
+// shardReader reads the points of a set of series from a single shard.
+// Points are decoded once and buffered for each field's iterator.
+type shardReader struct {
+	mu          sync.Mutex
+	shardID     uint64
+	measurement string // measurement name
+	fields      Fields // measurement fields
+	cursors     []*seriesCursor
+	keyValues   []keyValues
+	iterators   []*shardIterator
+	db          *bolt.DB // data store
+	txn         *bolt.Tx // read transaction
+	closed      bool     // true once the transaction is closed
+	tmin, tmax  int64
 }
 
-func (i *shardIterator) open() error {
+// iterator returns a new iterator over a field's values.
+func (r *shardReader) iterator(fieldID uint8, tags string, limit int) *shardIterator {
+	itr := &shardIterator{r: r, fieldID: fieldID, tags: tags, limit: limit}
+	r.iterators = append(r.iterators, itr)
+	return itr
+}
+
+func (r *shardReader) open() error {
 	// Open the data store
-	txn, err := i.db.Begin(false)
+	txn, err := r.db.Begin(false)
 	if err != nil {
 		return err
 	}
-	i.txn = txn
+	r.txn, r.closed = txn, false
 
 	// Open cursors for each series id
-	for _, c := range i.cursors {
-		b := i.txn.Bucket(u32tob(c.id))
+	for _, c := range r.cursors {
+		b := r.txn.Bucket(u32tob(c.id))
 		if b == nil {
 			continue
 		}
@@ -205,41 +267,108 @@ func (i *shardIterator) open() error {
 		c.cur = b.Cursor()
 	}
 
-	i.keyValues = make([]keyValue, len(i.cursors))
-	for j, cur := range i.cursors {
-		i.keyValues[j].key, i.keyValues[j].value = cur.Next(i.fieldName, i.fieldID, i.tmin, i.tmax)
+	r.keyValues = make([]keyValues, len(r.cursors))
+	for j, cur := range r.cursors {
+		r.keyValues[j].key, r.keyValues[j].values = cur.Next(r.fields, r.tmin, r.tmax)
 	}
 
 	return nil
 }
 
-func (i *shardIterator) close() error {
-	_ = i.txn.Rollback()
+// close rolls back the read transaction. Iterators return no more values
+// once the reader is closed.
+func (r *shardReader) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.txn != nil && !r.closed {
+		_ = r.txn.Rollback()
+	}
+	r.closed = true
 	return nil
 }
 
-func (i *shardIterator) Tags() string { return i.tags }
-
-func (i *shardIterator) Next() (key int64, value interface{}) {
+// read reads the next point across all series and appends its values to
+// the buffer of each iterator. Returns false if there are no more points.
+func (r *shardReader) read() bool {
+	// Find the cursor with the lowest key.
 	min := -1
-
-	for ind, kv := range i.keyValues {
-		if kv.key != 0 && kv.key < i.tmax {
+	for ind, kv := range r.keyValues {
+		if kv.key != 0 && kv.key < r.tmax && (min == -1 || kv.key < r.keyValues[min].key) {
 			min = ind
 		}
 	}
 
 	// if min is -1 we've exhausted all cursors for the given time range
 	if min == -1 {
+		return false
+	}
+
+	// Skip iterators that have reached their limit since they're no longer read.
+	kv := r.keyValues[min]
+	for _, itr := range r.iterators {
+		if itr.limit > 0 && itr.n+len(itr.buf) >= itr.limit {
+			continue
+		}
+		itr.buf = append(itr.buf, keyValue{key: kv.key, value: kv.values[itr.fieldID]})
+	}
+
+	r.keyValues[min].key, r.keyValues[min].values = r.cursors[min].Next(r.fields, r.tmin, r.tmax)
+	return true
+}
+
+// shardIterator represents an iterator for traversing over the values of a
+// single field read by a shard reader.
+type shardIterator struct {
+	r       *shardReader
+	fieldID uint8
+	tags    string     // encoded dimensional tag values
+	buf     []keyValue // values read but not yet returned
+	limit   int        // maximum number of points to read, if non-zero
+	n       int        // number of points read
+}
+
+func (i *shardIterator) Tags() string { return i.tags }
+
+// Measurement returns the name of the measurement the iterator reads.
+func (i *shardIterator) Measurement() string { return i.r.measurement }
+
+// Explain returns a description of the shard and series read by the iterator.
+// The points scanned are shared with the iterators of other fields.
+func (i *shardIterator) Explain() influxql.IteratorExplanation {
+	i.r.mu.Lock()
+	defer i.r.mu.Unlock()
+
+	x := influxql.IteratorExplanation{ShardID: i.r.shardID, SeriesN: len(i.r.cursors)}
+	for _, c := range i.r.cursors {
+		x.PointN += c.n
+	}
+	return x
+}
+
+func (i *shardIterator) Next() (key int64, value interface{}) {
+	// Stop reading once the limit has been reached.
+	if i.limit > 0 && i.n >= i.limit {
 		return 0, nil
 	}
 
-	kv := i.keyValues[min]
-	key = kv.key
-	value = kv.value
+	i.r.mu.Lock()
+	defer i.r.mu.Unlock()
 
-	i.keyValues[min].key, i.keyValues[min].value = i.cursors[min].Next(i.fieldName, i.fieldID, i.tmin, i.tmax)
-	return key, value
+	// Stop reading once the transaction is closed.
+	if i.r.closed {
+		return 0, nil
+	}
+
+	// Read from the shard if no values are buffered.
+	if len(i.buf) == 0 && !i.r.read() {
+		return 0, nil
+	}
+	i.n++
+
+	kv := i.buf[0]
+	i.buf[0] = keyValue{}
+	i.buf = i.buf[1:]
+	return kv.key, kv.value
 }
 
 type keyValue struct {
@@ -247,14 +376,22 @@ type keyValue struct {
 	value interface{}
 }
 
+type keyValues struct {
+	key    int64
+	values map[uint8]interface{}
+}
+
 type seriesCursor struct {
 	id          uint32
 	condition   influxql.Expr
 	cur         *bolt.Cursor
 	initialized bool
+	n           int // number of points scanned
 }
 
-func (c *seriesCursor) Next(fieldName string, fieldID uint8, tmin, tmax int64) (key int64, value interface{}) {
+// Next returns the key and field values of the next point that matches the
+// cursor's condition. The condition is evaluated against all fields.
+func (c *seriesCursor) Next(fields Fields, tmin, tmax int64) (key int64, values map[uint8]interface{}) {
 	// TODO: clean this up when we make it so series ids are only queried against the shards they exist in.
 	//       Right now we query for all series ids on a query against each shard, even if that shard may not have the
 	//       data, so cur could be nil.
@@ -276,20 +413,27 @@ func (c *seriesCursor) Next(fieldName string, fieldID uint8, tmin, tmax int64) (
 			return 0, nil
 		}
 
-		// Marshal key & value.
-		key, value = int64(btou64(k)), unmarshalValue(v, fieldID)
+		// Marshal key & values.
+		key, values = int64(btou64(k)), unmarshalValues(v)
 
 		if key > tmax {
 			return 0, nil
 		}
+		c.n++
 
 		// Evaluate condition. Move to next key/value if non-true.
 		if c.condition != nil {
-			if ok, _ := influxql.Eval(c.condition, map[string]interface{}{fieldName: value}).(bool); !ok {
+			m := make(map[string]interface{}, len(values))
+			for _, f := range fields {
+				if v, ok := values[f.ID]; ok {
+					m[f.Name] = v
+				}
+			}
+			if ok, _ := influxql.Eval(c.condition, m).(bool); !ok {
 				continue
 			}
 		}
 
-		return key, value
+		return key, values
 	}
 }
@@ -69,6 +69,49 @@ func TestTx_CreateIterators(t *testing.T) {
 	}
 }
 
+// Ensure iterators for different fields of a measurement share the points read.
+func TestTx_CreateIterators_MultipleFields(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(100), "load": float64(1)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverB"}, Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Values: map[string]interface{}{"value": float64(90)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:20Z"), Values: map[string]interface{}{"value": float64(80), "load": float64(3)}}})
+
+	tx, err := s.Begin()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	tx.SetNow(mustParseTime("2000-01-02T00:00:00Z"))
+
+	// Create iterators for each field within the same transaction.
+	// Conditions can reference fields other than the one selected.
+	values, err := tx.CreateIterators(MustParseSelectStatement(`SELECT value FROM "db"."raw"."cpu" WHERE load > 2`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	loads, err := tx.CreateIterators(MustParseSelectStatement(`SELECT load FROM "db"."raw"."cpu" WHERE load > 2`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := tx.Open(); err != nil {
+		t.Fatalf("tx open error: %s", err)
+	}
+	defer tx.Close()
+
+	// Read the fields in different orders.
+	if data := slurp(loads); !reflect.DeepEqual(data, []keyValue{
+		{key: 946684820000000000, value: float64(3)},
+	}) {
+		t.Fatalf("unexpected loads: %#v", data)
+	}
+	if data := slurp(values); !reflect.DeepEqual(data, []keyValue{
+		{key: 946684820000000000, value: float64(80)},
+	}) {
+		t.Fatalf("unexpected values: %#v", data)
+	}
+}
+
 func slurp(itrs []influxql.Iterator) []keyValue {
 	var rows []keyValue
 	for _, itr := range itrs {
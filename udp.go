@@ -1,41 +1,57 @@
 package influxdb
 
 import (
+	"bytes"
+	"encoding/json"
+	"log"
 	"net"
 	"sync"
+	"time"
 )
 
+// udpBufferSize is the largest datagram that can be received.
+const udpBufferSize = 65536
+
 // UDPServer represents a UDP transport for InfluxDB.
+// Each datagram contains a JSON encoded BatchPoints. Points are buffered
+// across datagrams and written to the server in batches.
 type UDPServer struct {
-	server *Server
+	server  *Server
+	batcher *PointBatcher
 
 	mu   sync.Mutex
 	wg   sync.WaitGroup
-	done chan struct{} // close notification
+	conn *net.UDPConn
 
 	// The UDP address to listen on.
 	Addr *net.UDPAddr
 
-	// The name of the database to insert data into.
-	Database string
+	// The name of the database and retention policy to insert data into.
+	// The database's default retention policy is used if one is not set.
+	Database        string
+	RetentionPolicy string
 
-	// The user authorized to insert the data.
-	User *User
+	// Points are dropped while the batch queue is full.
+	BatchSize     int
+	FlushInterval time.Duration
 }
 
 // NewUDPServer returns an instance of UDPServer attached to a Server.
 func NewUDPServer(server *Server) *UDPServer {
-	return &UDPServer{server: server}
+	return &UDPServer{
+		server:        server,
+		BatchSize:     DefaultPointBatchSize,
+		FlushInterval: DefaultPointBatchTimeout,
+	}
 }
 
-// ListenAndServe opens a UDP socket to listen for messages.
+// ListenAndServe opens a UDP socket and processes messages in a separate goroutine.
 func (s *UDPServer) ListenAndServe() error {
-	panic("not yet implemented: UDPServer.ListenAndServe()")
-
-	/* TEMPORARILY REMOVED FOR PROTOBUFS.
-	// Validate that server has a UDP address.
+	// Validate that server has a UDP address and a database.
 	if s.Addr == nil {
 		return ErrBindAddressRequired
+	} else if s.Database == "" {
+		return ErrDatabaseRequired
 	}
 
 	// Open UDP connection.
@@ -43,57 +59,83 @@ func (s *UDPServer) ListenAndServe() error {
 	if err != nil {
 		return err
 	}
-	defer conn.Close()
+	s.mu.Lock()
+	s.conn = conn
+	s.mu.Unlock()
 
-	// Read messages off the connection and handle them.
-	buffer := make([]byte, 2048)
-	for {
-		n, _, err := conn.ReadFromUDP(buffer)
-		if err != nil || n == 0 {
-			log.Error("UDP ReadFromUDP error: %s", err)
-			continue
-		}
+	// Batch points before writing them to the server.
+	s.batcher = StartPointBatcher(s.server, s.Database, s.RetentionPolicy, s.BatchSize, s.FlushInterval, &s.wg)
 
-		// Create a JSON decoder.
-		dec := json.NewDecoder(bytes.NewBuffer(buffer[0:n]))
-		dec.UseNumber()
+	s.wg.Add(1)
+	go s.serve(conn)
 
-		// Deserialize data into series.
-		var a []*serializedSeries
-		if err := dec.Decode(&a); err != nil {
-			log.Error("UDP json error: %s", err)
-			continue
-		}
+	return nil
+}
 
-		// Write data points to the data store.
-		for _, ss := range a {
-			if len(ss.Points) == 0 {
-				continue
-			}
+// Stats returns the counters of the server's point batcher.
+func (s *UDPServer) Stats() PointBatcherStats {
+	if s.batcher == nil {
+		return PointBatcherStats{}
+	}
+	return s.batcher.Stats()
+}
 
-			// Convert to the internal series format.
-			series, err := ss.series(SecondPrecision)
-			if err != nil {
-				log.Error("udp cannot convert received data: %s", err)
-				continue
-			}
+// Close stops the listener and writes any buffered points.
+func (s *UDPServer) Close() error {
+	s.mu.Lock()
+	if s.conn == nil {
+		s.mu.Unlock()
+		return ErrServerClosed
+	}
+	_ = s.conn.Close()
+	s.conn = nil
+	s.mu.Unlock()
 
-			// TODO: Authorization.
+	// Write queued points and wait for goroutines to finish.
+	s.batcher.Stop()
+	s.wg.Wait()
 
-			// Lookup database.
-			db := s.server.Database(s.Database)
-			if db == nil {
-				log.Error("udp: %s", ErrDatabaseNotFound)
-				continue
-			}
+	return nil
+}
+
+// serve reads datagrams from the connection until it is closed.
+func (s *UDPServer) serve(conn *net.UDPConn) {
+	defer s.wg.Done()
 
-			// Write series data to server.
-			if err := db.WriteSeries(series); err != nil {
-				log.Error("udp: write data error: %s", err)
-				continue
+	buf := make([]byte, udpBufferSize)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			// Exit if the connection has been closed.
+			s.mu.Lock()
+			closed := s.conn == nil
+			s.mu.Unlock()
+			if closed {
+				return
 			}
+			log.Printf("udp: read error: %s", err)
+			continue
 		}
+		s.handleMessage(buf[:n])
+	}
+}
+
+// handleMessage decodes a datagram and queues its points.
+// Points are dropped if the queue is full.
+func (s *UDPServer) handleMessage(b []byte) {
+	var bp BatchPoints
+	if err := json.NewDecoder(bytes.NewReader(b)).Decode(&bp); err != nil {
+		log.Printf("udp: json error: %s", err)
+		return
+	}
+
+	points, err := NormalizeBatchPoints(bp)
+	if err != nil {
+		log.Printf("udp: cannot normalize points: %s", err)
+		return
+	}
 
+	for _, p := range points {
+		s.batcher.TryWrite(p)
 	}
-	*/
 }
@@ -0,0 +1,81 @@
+package influxdb_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb"
+)
+
+// Ensure the UDP server can buffer points from multiple datagrams and write them.
+func TestUDPServer(t *testing.T) {
+	c := NewMessagingClient()
+	s := OpenDefaultServer(c)
+	defer s.Close()
+
+	// Start a UDP server with a long flush interval so points are only written on close.
+	u := influxdb.NewUDPServer(s.Server)
+	u.Addr = MustResolveFreeUDPAddr()
+	u.Database = "db"
+	u.RetentionPolicy = "raw"
+	u.FlushInterval = 1 * time.Hour
+	if err := u.ListenAndServe(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Send points in separate datagrams.
+	conn, err := net.DialUDP("udp", nil, u.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for _, data := range []string{
+		`{"points":[{"name":"cpu","tags":{"host":"serverA"},"timestamp":"2000-01-01T00:00:00Z","values":{"value":10}}]}`,
+		`{"tags":{"host":"serverA"},"timestamp":"2000-01-01T00:00:10Z","points":[{"name":"cpu","values":{"value":20}}]}`,
+	} {
+		if _, err := conn.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Wait for the datagrams to be received then close to flush the points.
+	time.Sleep(100 * time.Millisecond)
+	if err := u.Close(); err != nil {
+		t.Fatal(err)
+	} else if stats := u.Stats(); stats.PointTotal != 2 || stats.BatchTotal != 1 {
+		t.Fatalf("unexpected stats: %#v", stats)
+	}
+	s.Sync(c.index)
+
+	// Verify both points were written.
+	for _, tt := range []struct {
+		timestamp string
+		value     float64
+	}{{"2000-01-01T00:00:00Z", 10}, {"2000-01-01T00:00:10Z", 20}} {
+		if v, err := s.ReadSeries("db", "raw", "cpu", map[string]string{"host": "serverA"}, mustParseTime(tt.timestamp)); err != nil {
+			t.Fatal(err)
+		} else if v["value"] != tt.value {
+			t.Fatalf("unexpected value at %s: %#v", tt.timestamp, v)
+		}
+	}
+}
+
+// Ensure the UDP server returns an error when started without a database.
+func TestUDPServer_ErrDatabaseRequired(t *testing.T) {
+	u := influxdb.NewUDPServer(nil)
+	u.Addr = MustResolveFreeUDPAddr()
+	if err := u.ListenAndServe(); err != influxdb.ErrDatabaseRequired {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// MustResolveFreeUDPAddr returns a local UDP address with an unused port. Panic on error.
+func MustResolveFreeUDPAddr() *net.UDPAddr {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		panic(err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr)
+}